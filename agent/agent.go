@@ -12,12 +12,129 @@ import (
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/config"
 	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/logger"
 	"github.com/influxdata/telegraf/plugins/serializers/influx"
+	"github.com/influxdata/telegraf/selfstat"
 )
 
 // Agent runs a set of plugins.
 type Agent struct {
 	Config *config.Config
+
+	// mu protects the plugin lists in Config and running while plugins are
+	// added or removed by Reload.
+	mu      sync.RWMutex
+	running *runState
+
+	// reloadMu serializes calls to Reload with the shutdown of the agent.
+	reloadMu sync.Mutex
+}
+
+// runState is the state of a running agent needed to start and stop
+// individual plugins.
+type runState struct {
+	startTime time.Time
+	stopping  bool
+	api       *apiServer
+
+	pipelines map[string]*pipeline
+
+	inputCtx context.Context
+	inputs   map[*models.RunningInput]*pluginUnit
+
+	processors  map[*models.RunningProcessor]*processorUnit
+	aggregators map[*models.RunningAggregator]*pluginUnit
+	outputs     map[*models.RunningOutput]*pluginUnit
+}
+
+// pipeline connects the inputs of a pipeline to its processors, aggregators
+// and outputs.  Each pipeline is stopped independently of the others, once
+// all of its metrics are written.
+type pipeline struct {
+	name string
+
+	inputC       chan telegraf.Metric
+	processed    chan telegraf.Metric
+	aggregations chan telegraf.Metric
+
+	aggregatorCtx    context.Context
+	aggregatorCancel context.CancelFunc
+
+	outputCtx    context.Context
+	outputCancel context.CancelFunc
+
+	metricsIn  selfstat.Stat
+	metricsOut selfstat.Stat
+}
+
+func newPipeline(name string) *pipeline {
+	p := &pipeline{
+		name:         name,
+		inputC:       make(chan telegraf.Metric, 100),
+		processed:    make(chan telegraf.Metric, 100),
+		aggregations: make(chan telegraf.Metric, 100),
+	}
+	p.aggregatorCtx, p.aggregatorCancel = context.WithCancel(context.Background())
+	p.outputCtx, p.outputCancel = context.WithCancel(context.Background())
+
+	tags := map[string]string{"pipeline": models.PipelineName(name)}
+	p.metricsIn = selfstat.Register("pipeline", "metrics_in", tags)
+	p.metricsOut = selfstat.Register("pipeline", "metrics_out", tags)
+	return p
+}
+
+// pluginUnit is a goroutine running a single plugin.
+type pluginUnit struct {
+	cancel   context.CancelFunc
+	done     chan struct{}
+	triggerC chan struct{}
+}
+
+// startUnit runs f in a new goroutine with a context derived from parent.
+// The trigger channel passed to f receives a value when the unit is
+// triggered.
+func startUnit(
+	parent context.Context,
+	f func(ctx context.Context, trigger <-chan struct{}),
+) *pluginUnit {
+	ctx, cancel := context.WithCancel(parent)
+	u := &pluginUnit{
+		cancel:   cancel,
+		done:     make(chan struct{}),
+		triggerC: make(chan struct{}, 1),
+	}
+	go func() {
+		defer close(u.done)
+		f(ctx, u.triggerC)
+	}()
+	return u
+}
+
+// trigger asks the unit to run its plugin immediately.
+func (u *pluginUnit) trigger() {
+	select {
+	case u.triggerC <- struct{}{}:
+	default:
+	}
+}
+
+// stop cancels the unit and waits for it to return.
+func (u *pluginUnit) stop() {
+	u.cancel()
+	<-u.done
+}
+
+// processorUnit passes the metrics emitted by a streaming processor through
+// the processors following it.
+type processorUnit struct {
+	metricC chan telegraf.Metric
+	done    chan struct{}
+
+	// followers are the processors applied to the metrics of a processor
+	// removed by a reload, instead of the processors following it in the
+	// current configuration.
+	removed   bool
+	followers []*models.RunningProcessor
 }
 
 // NewAgent returns an Agent for the given Config.
@@ -51,28 +168,84 @@ func (a *Agent) Run(ctx context.Context) error {
 		return err
 	}
 
-	inputC := make(chan telegraf.Metric, 100)
-	procC := make(chan telegraf.Metric, 100)
-	outputC := make(chan telegraf.Metric, 100)
+	// The API is started once the plugins are initialized, the buffers of
+	// the outputs are replaced when they are initialized.
+	var api *apiServer
+	if a.Config.Agent.HTTPAPIAddress != "" {
+		api, err = newAPIServer(a, a.Config.Agent.HTTPAPIAddress)
+		if err != nil {
+			return fmt.Errorf("could not start API: %v", err)
+		}
+		api.start()
+		defer api.stop()
+	}
+
+	pipelines := make(map[string]*pipeline)
+	for _, name := range a.Config.Pipelines() {
+		pipelines[name] = newPipeline(name)
+	}
 
 	startTime := time.Now()
 
+	log.Printf("D! [agent] Starting processors")
+	processors := make(map[*models.RunningProcessor]*processorUnit)
+	for _, proc := range a.Config.Processors {
+		unit, err := a.startProcessor(pipelines[proc.Config.Pipeline], proc)
+		if err != nil {
+			stopProcessors(processors)
+			return fmt.Errorf("could not start processor %s: %v",
+				proc.Config.Name, err)
+		}
+		if unit != nil {
+			processors[proc] = unit
+		}
+	}
+
 	log.Printf("D! [agent] Starting service inputs")
-	err = a.startServiceInputs(ctx, inputC)
+	err = a.startServiceInputs(ctx, func(input *models.RunningInput) chan<- telegraf.Metric {
+		return pipelines[input.Config.Pipeline].inputC
+	})
 	if err != nil {
+		stopProcessors(processors)
 		return err
 	}
 
-	var wg sync.WaitGroup
+	a.reloadMu.Lock()
+	a.mu.Lock()
+	a.running = &runState{
+		startTime:   startTime,
+		api:         api,
+		pipelines:   pipelines,
+		inputCtx:    ctx,
+		inputs:      make(map[*models.RunningInput]*pluginUnit),
+		processors:  processors,
+		aggregators: make(map[*models.RunningAggregator]*pluginUnit),
+		outputs:     make(map[*models.RunningOutput]*pluginUnit),
+	}
+	for _, output := range a.Config.Outputs {
+		a.startOutput(output, startTime)
+	}
+	for _, agg := range a.Config.Aggregators {
+		// Before calling Add, initialize the aggregation window.  This
+		// ensures that any metric created after start time will be
+		// aggregated.
+		since, until := updateWindow(startTime, a.Config.Agent.RoundInterval, agg.Period())
+		agg.UpdateWindow(since, until)
+		a.startAggregator(agg)
+	}
+	for _, input := range a.Config.Inputs {
+		a.startInput(input, startTime)
+	}
+	a.mu.Unlock()
+	a.reloadMu.Unlock()
 
-	src := inputC
-	dst := inputC
+	var wg sync.WaitGroup
 
 	wg.Add(1)
-	go func(dst chan telegraf.Metric) {
+	go func() {
 		defer wg.Done()
 
-		err := a.runInputs(ctx, startTime, dst)
+		err := a.runInputs(ctx)
 		if err != nil {
 			log.Printf("E! [agent] Error running inputs: %v", err)
 		}
@@ -80,63 +253,66 @@ func (a *Agent) Run(ctx context.Context) error {
 		log.Printf("D! [agent] Stopping service inputs")
 		a.stopServiceInputs()
 
-		close(dst)
-		log.Printf("D! [agent] Input channel closed")
-	}(dst)
-
-	src = dst
+		for _, p := range pipelines {
+			close(p.inputC)
+		}
+		log.Printf("D! [agent] Input channels closed")
+	}()
 
-	if len(a.Config.Processors) > 0 {
-		dst = procC
+	for _, p := range pipelines {
+		outputC := make(chan telegraf.Metric, 100)
 
+		// The processor and aggregator stages always run so that plugins can
+		// be added to them by a reload.
 		wg.Add(1)
-		go func(src, dst chan telegraf.Metric) {
+		go func(p *pipeline, src, dst chan telegraf.Metric) {
 			defer wg.Done()
 
-			err := a.runProcessors(src, dst)
+			err := a.runProcessors(p, src, dst)
 			if err != nil {
-				log.Printf("E! [agent] Error running processors: %v", err)
+				log.Printf("E! [agent] Error running processors of pipeline %s: %v",
+					models.PipelineName(p.name), err)
 			}
 			close(dst)
-			log.Printf("D! [agent] Processor channel closed")
-		}(src, dst)
-
-		src = dst
-	}
-
-	if len(a.Config.Aggregators) > 0 {
-		dst = outputC
+			log.Printf("D! [agent] Processor channel of pipeline %s closed",
+				models.PipelineName(p.name))
+		}(p, p.inputC, p.processed)
 
 		wg.Add(1)
-		go func(src, dst chan telegraf.Metric) {
+		go func(p *pipeline, src, dst chan telegraf.Metric) {
 			defer wg.Done()
 
-			err := a.runAggregators(startTime, src, dst)
+			err := a.runAggregators(p, src, dst)
 			if err != nil {
-				log.Printf("E! [agent] Error running aggregators: %v", err)
+				log.Printf("E! [agent] Error running aggregators of pipeline %s: %v",
+					models.PipelineName(p.name), err)
 			}
 			close(dst)
-			log.Printf("D! [agent] Output channel closed")
-		}(src, dst)
-
-		src = dst
-	}
+			log.Printf("D! [agent] Output channel of pipeline %s closed",
+				models.PipelineName(p.name))
+		}(p, p.processed, outputC)
 
-	wg.Add(1)
-	go func(src chan telegraf.Metric) {
-		defer wg.Done()
+		wg.Add(1)
+		go func(p *pipeline, src chan telegraf.Metric) {
+			defer wg.Done()
 
-		err := a.runOutputs(startTime, src)
-		if err != nil {
-			log.Printf("E! [agent] Error running outputs: %v", err)
-		}
-	}(src)
+			err := a.runOutputs(p, src)
+			if err != nil {
+				log.Printf("E! [agent] Error running outputs of pipeline %s: %v",
+					models.PipelineName(p.name), err)
+			}
+		}(p, outputC)
+	}
 
 	wg.Wait()
 
 	log.Printf("D! [agent] Closing outputs")
 	a.closeOutputs()
 
+	a.reloadMu.Lock()
+	a.running = nil
+	a.reloadMu.Unlock()
+
 	log.Printf("D! [agent] Stopped Successfully")
 	return nil
 }
@@ -161,7 +337,7 @@ func (a *Agent) Test(ctx context.Context, waitDuration time.Duration) error {
 		for metric := range metricC {
 			octets, err := s.Serialize(metric)
 			if err == nil {
-				fmt.Print("> ", string(octets))
+				fmt.Print("> ", logger.Redact(string(octets)))
 			}
 			metric.Reject()
 		}
@@ -190,7 +366,9 @@ func (a *Agent) Test(ctx context.Context, waitDuration time.Duration) error {
 
 	if hasServiceInputs {
 		log.Printf("D! [agent] Starting service inputs")
-		err := a.startServiceInputs(ctx, metricC)
+		err := a.startServiceInputs(ctx, func(*models.RunningInput) chan<- telegraf.Metric {
+			return metricC
+		})
 		if err != nil {
 			return err
 		}
@@ -238,56 +416,68 @@ func (a *Agent) Test(ctx context.Context, waitDuration time.Duration) error {
 	return nil
 }
 
-// runInputs starts and triggers the periodic gather for Inputs.
+// runInputs waits for the context to be done and then stops the periodic
+// gather for Inputs.
 //
-// When the context is done the timers are stopped and this function returns
-// after all ongoing Gather calls complete.
-func (a *Agent) runInputs(
-	ctx context.Context,
-	startTime time.Time,
-	dst chan<- telegraf.Metric,
-) error {
-	var wg sync.WaitGroup
-	for _, input := range a.Config.Inputs {
-		interval := a.Config.Agent.Interval.Duration
-		jitter := a.Config.Agent.CollectionJitter.Duration
-
-		// Overwrite agent interval if this plugin has its own.
-		if input.Config.Interval != 0 {
-			interval = input.Config.Interval
-		}
+// Returns after all ongoing Gather calls complete.
+func (a *Agent) runInputs(ctx context.Context) error {
+	<-ctx.Done()
+
+	// Wait for a reload in progress and prevent new ones.
+	a.reloadMu.Lock()
+	a.running.stopping = true
+	a.reloadMu.Unlock()
+
+	a.mu.RLock()
+	units := make([]*pluginUnit, 0, len(a.running.inputs))
+	for _, unit := range a.running.inputs {
+		units = append(units, unit)
+	}
+	a.mu.RUnlock()
 
-		acc := NewAccumulator(input, dst)
-		acc.SetPrecision(a.Precision())
+	for _, unit := range units {
+		unit.stop()
+	}
 
-		wg.Add(1)
-		go func(input *models.RunningInput) {
-			defer wg.Done()
+	return nil
+}
 
-			if a.Config.Agent.RoundInterval {
-				err := internal.SleepContext(
-					ctx, internal.AlignDuration(startTime, interval))
-				if err != nil {
-					return
-				}
-			}
+// startInput starts the periodic gather for an input.  The caller must hold
+// a.mu.
+func (a *Agent) startInput(input *models.RunningInput, startTime time.Time) {
+	interval := a.Config.Agent.Interval.Duration
+	jitter := a.Config.Agent.CollectionJitter.Duration
 
-			a.gatherOnInterval(ctx, acc, input, interval, jitter)
-		}(input)
+	// Overwrite agent interval if this plugin has its own.
+	if input.Config.Interval != 0 {
+		interval = input.Config.Interval
 	}
-	wg.Wait()
 
-	return nil
+	acc := NewAccumulator(input, a.running.pipelines[input.Config.Pipeline].inputC)
+	acc.SetPrecision(a.Precision())
+
+	a.running.inputs[input] = startUnit(a.running.inputCtx, func(ctx context.Context, trigger <-chan struct{}) {
+		if a.Config.Agent.RoundInterval {
+			err := internal.SleepContext(
+				ctx, internal.AlignDuration(startTime, interval))
+			if err != nil {
+				return
+			}
+		}
+
+		a.gatherOnInterval(ctx, acc, input, interval, jitter, trigger)
+	})
 }
 
 // gather runs an input's gather function periodically until the context is
-// done.
+// done.  A value received on trigger runs an additional gather immediately.
 func (a *Agent) gatherOnInterval(
 	ctx context.Context,
 	acc telegraf.Accumulator,
 	input *models.RunningInput,
 	interval time.Duration,
 	jitter time.Duration,
+	trigger <-chan struct{},
 ) {
 	defer panicRecover(input)
 
@@ -305,11 +495,18 @@ func (a *Agent) gatherOnInterval(
 			acc.AddError(err)
 		}
 
-		select {
-		case <-ticker.C:
-			continue
-		case <-ctx.Done():
-			return
+		for waiting := true; waiting; {
+			select {
+			case <-ticker.C:
+				waiting = false
+			case <-trigger:
+				err = a.gatherOnce(acc, input, interval)
+				if err != nil {
+					acc.AddError(err)
+				}
+			case <-ctx.Done():
+				return
+			}
 		}
 	}
 }
@@ -340,32 +537,148 @@ func (a *Agent) gatherOnce(
 	}
 }
 
-// runProcessors applies processors to metrics.
+// runProcessors applies the processors of the pipeline to metrics.
 func (a *Agent) runProcessors(
+	p *pipeline,
 	src <-chan telegraf.Metric,
 	agg chan<- telegraf.Metric,
 ) error {
 	for metric := range src {
-		metrics := a.applyProcessors(metric)
+		p.metricsIn.Incr(1)
+		metrics := a.applyProcessors(p, metric)
 
 		for _, metric := range metrics {
 			agg <- metric
 		}
 	}
 
+	// The streaming processors are stopped in order, the metrics emitted by
+	// one of them while stopping pass through the following processors
+	// before these are stopped.
+	a.mu.RLock()
+	units := make(map[*models.RunningProcessor]*processorUnit)
+	order := make([]*models.RunningProcessor, 0, len(a.running.processors))
+	for _, proc := range a.Config.Processors {
+		if unit, ok := a.running.processors[proc]; ok && proc.Config.Pipeline == p.name {
+			units[proc] = unit
+			order = append(order, proc)
+		}
+	}
+	a.mu.RUnlock()
+
+	for _, proc := range order {
+		stopProcessor(proc, units[proc])
+	}
+
 	return nil
 }
 
-// applyProcessors applies all processors to a metric.
-func (a *Agent) applyProcessors(m telegraf.Metric) []telegraf.Metric {
+// applyProcessors applies all processors of the pipeline to a metric.
+//
+// The processors are applied without holding a.mu, a streaming processor may
+// block until the metrics it emits have passed the following processors.  A
+// processor removed by a reload in the meantime passes the metric unmodified.
+func (a *Agent) applyProcessors(p *pipeline, m telegraf.Metric) []telegraf.Metric {
+	a.mu.RLock()
+	processors := a.Config.Processors
+	a.mu.RUnlock()
+
 	metrics := []telegraf.Metric{m}
-	for _, processor := range a.Config.Processors {
+	for _, processor := range processors {
+		if processor.Config.Pipeline != p.name {
+			continue
+		}
 		metrics = processor.Apply(metrics...)
 	}
 
 	return metrics
 }
 
+// startProcessor starts a streaming processor, the returned unit passes the
+// metrics it emits through the following processors of the pipeline.  It
+// returns nil for other processors.
+func (a *Agent) startProcessor(p *pipeline, proc *models.RunningProcessor) (*processorUnit, error) {
+	if _, ok := proc.Processor.(telegraf.StreamingProcessor); !ok {
+		return nil, nil
+	}
+
+	unit := &processorUnit{
+		metricC: make(chan telegraf.Metric, 100),
+		done:    make(chan struct{}),
+	}
+	err := proc.Start(NewAccumulator(processorMaker{proc}, unit.metricC))
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		defer close(unit.done)
+		for m := range unit.metricC {
+			a.mu.RLock()
+			followers := unit.followers
+			if !unit.removed {
+				followers = processorsAfter(a.Config.Processors, proc)
+			}
+			a.mu.RUnlock()
+
+			metrics := []telegraf.Metric{m}
+			for _, follower := range followers {
+				metrics = follower.Apply(metrics...)
+			}
+			for _, m := range metrics {
+				p.processed <- m
+			}
+		}
+	}()
+	return unit, nil
+}
+
+// stopProcessor stops a streaming processor and waits until the metrics it
+// emitted have been passed on.
+func stopProcessor(proc *models.RunningProcessor, unit *processorUnit) {
+	proc.Stop()
+	close(unit.metricC)
+	<-unit.done
+}
+
+// stopProcessors stops the streaming processors of a failed start.
+func stopProcessors(units map[*models.RunningProcessor]*processorUnit) {
+	for proc, unit := range units {
+		stopProcessor(proc, unit)
+	}
+}
+
+// processorsAfter returns the processors following proc in its pipeline.
+func processorsAfter(processors []*models.RunningProcessor, proc *models.RunningProcessor) []*models.RunningProcessor {
+	for i, p := range processors {
+		if p != proc {
+			continue
+		}
+		var after []*models.RunningProcessor
+		for _, p := range processors[i+1:] {
+			if p.Config.Pipeline == proc.Config.Pipeline {
+				after = append(after, p)
+			}
+		}
+		return after
+	}
+	return nil
+}
+
+// processorMaker is the MetricMaker of the accumulator of a streaming
+// processor, the metrics it emits are passed on unmodified.
+type processorMaker struct {
+	proc *models.RunningProcessor
+}
+
+func (m processorMaker) Name() string {
+	return "processors." + m.proc.Config.Name
+}
+
+func (m processorMaker) MakeMetric(metric telegraf.Metric) telegraf.Metric {
+	return metric
+}
+
 func updateWindow(start time.Time, roundInterval bool, period time.Duration) (time.Time, time.Time) {
 	var until time.Time
 	if roundInterval {
@@ -382,36 +695,32 @@ func updateWindow(start time.Time, roundInterval bool, period time.Duration) (ti
 	return since, until
 }
 
-// runAggregators adds metrics to the aggregators and triggers their periodic
-// push call.
+// runAggregators adds metrics to the aggregators of the pipeline and emits
+// their aggregations.
 //
-// Runs until src is closed and all metrics have been processed.  Will call
-// push one final time before returning.
+// Runs until src is closed and all metrics have been processed.  The
+// aggregators call push one final time before this function returns.
 func (a *Agent) runAggregators(
-	startTime time.Time,
+	p *pipeline,
 	src <-chan telegraf.Metric,
 	dst chan<- telegraf.Metric,
 ) error {
-	ctx, cancel := context.WithCancel(context.Background())
-
-	// Before calling Add, initialize the aggregation window.  This ensures
-	// that any metric created after start time will be aggregated.
-	for _, agg := range a.Config.Aggregators {
-		since, until := updateWindow(startTime, a.Config.Agent.RoundInterval, agg.Period())
-		agg.UpdateWindow(since, until)
-	}
-
 	var wg sync.WaitGroup
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
 		for metric := range src {
 			var dropOriginal bool
+			a.mu.RLock()
 			for _, agg := range a.Config.Aggregators {
+				if agg.Config.Pipeline != p.name {
+					continue
+				}
 				if ok := agg.Add(metric); ok {
 					dropOriginal = true
 				}
 			}
+			a.mu.RUnlock()
 
 			if !dropOriginal {
 				dst <- metric
@@ -419,32 +728,25 @@ func (a *Agent) runAggregators(
 				metric.Drop()
 			}
 		}
-		cancel()
-	}()
-
-	aggregations := make(chan telegraf.Metric, 100)
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
+		p.aggregatorCancel()
 
-		var aggWg sync.WaitGroup
-		for _, agg := range a.Config.Aggregators {
-			aggWg.Add(1)
-			go func(agg *models.RunningAggregator) {
-				defer aggWg.Done()
-
-				acc := NewAccumulator(agg, aggregations)
-				acc.SetPrecision(a.Precision())
-				a.push(ctx, agg, acc)
-			}(agg)
+		a.mu.RLock()
+		units := make([]*pluginUnit, 0, len(a.running.aggregators))
+		for agg, unit := range a.running.aggregators {
+			if agg.Config.Pipeline == p.name {
+				units = append(units, unit)
+			}
 		}
+		a.mu.RUnlock()
 
-		aggWg.Wait()
-		close(aggregations)
+		for _, unit := range units {
+			<-unit.done
+		}
+		close(p.aggregations)
 	}()
 
-	for metric := range aggregations {
-		metrics := a.applyProcessors(metric)
+	for metric := range p.aggregations {
+		metrics := a.applyProcessors(p, metric)
 		for _, metric := range metrics {
 			dst <- metric
 		}
@@ -454,6 +756,18 @@ func (a *Agent) runAggregators(
 	return nil
 }
 
+// startAggregator starts the periodic push for an aggregator.  The caller
+// must hold a.mu.
+func (a *Agent) startAggregator(agg *models.RunningAggregator) {
+	p := a.running.pipelines[agg.Config.Pipeline]
+	acc := NewAccumulator(agg, p.aggregations)
+	acc.SetPrecision(a.Precision())
+
+	a.running.aggregators[agg] = startUnit(p.aggregatorCtx, func(ctx context.Context, _ <-chan struct{}) {
+		a.push(ctx, agg, acc)
+	})
+}
+
 // push runs the push for a single aggregator every period.
 func (a *Agent) push(
 	ctx context.Context,
@@ -478,68 +792,91 @@ func (a *Agent) push(
 	}
 }
 
-// runOutputs triggers the periodic write for Outputs.
+// runOutputs adds metrics to the outputs of the pipeline.
 //
-
-// Runs until src is closed and all metrics have been processed.  Will call
-// Write one final time before returning.
+// Runs until src is closed and all metrics have been processed.  The
+// outputs call WriteFinal one final time before this function returns.
 func (a *Agent) runOutputs(
-	startTime time.Time,
+	p *pipeline,
 	src <-chan telegraf.Metric,
 ) error {
-	interval := a.Config.Agent.FlushInterval.Duration
-	jitter := a.Config.Agent.FlushJitter.Duration
+	for metric := range src {
+		p.metricsOut.Incr(1)
+
+		// Each output receives a copy of the metric, apart from the last
+		// one which receives the original.
+		var last *models.RunningOutput
+		a.mu.RLock()
+		for _, output := range a.Config.Outputs {
+			if output.Config.Pipeline != p.name {
+				continue
+			}
+			if last != nil {
+				last.AddMetric(metric.Copy())
+			}
+			last = output
+		}
+		if last != nil {
+			last.AddMetric(metric)
+		} else {
+			metric.Drop()
+		}
+		a.mu.RUnlock()
+	}
 
-	ctx, cancel := context.WithCancel(context.Background())
+	log.Printf("I! [agent] Hang on, flushing any cached metrics of pipeline %s before shutdown",
+		models.PipelineName(p.name))
+	p.outputCancel()
 
-	var wg sync.WaitGroup
-	for _, output := range a.Config.Outputs {
-		interval := interval
-		// Overwrite agent flush_interval if this plugin has its own.
-		if output.Config.FlushInterval != 0 {
-			interval = output.Config.FlushInterval
+	a.mu.RLock()
+	units := make([]*pluginUnit, 0, len(a.running.outputs))
+	for output, unit := range a.running.outputs {
+		if output.Config.Pipeline == p.name {
+			units = append(units, unit)
 		}
+	}
+	a.mu.RUnlock()
 
-		wg.Add(1)
-		go func(output *models.RunningOutput) {
-			defer wg.Done()
+	for _, unit := range units {
+		<-unit.done
+	}
 
-			if a.Config.Agent.RoundInterval {
-				err := internal.SleepContext(
-					ctx, internal.AlignDuration(startTime, interval))
-				if err != nil {
-					return
-				}
-			}
+	return nil
+}
 
-			a.flush(ctx, output, interval, jitter)
-		}(output)
+// startOutput starts the periodic write for an output.  The caller must hold
+// a.mu.
+func (a *Agent) startOutput(output *models.RunningOutput, startTime time.Time) {
+	interval := a.Config.Agent.FlushInterval.Duration
+	jitter := a.Config.Agent.FlushJitter.Duration
+
+	// Overwrite agent flush_interval if this plugin has its own.
+	if output.Config.FlushInterval != 0 {
+		interval = output.Config.FlushInterval
 	}
 
-	for metric := range src {
-		for i, output := range a.Config.Outputs {
-			if i == len(a.Config.Outputs)-1 {
-				output.AddMetric(metric)
-			} else {
-				output.AddMetric(metric.Copy())
+	p := a.running.pipelines[output.Config.Pipeline]
+	a.running.outputs[output] = startUnit(p.outputCtx, func(ctx context.Context, trigger <-chan struct{}) {
+		if a.Config.Agent.RoundInterval {
+			err := internal.SleepContext(
+				ctx, internal.AlignDuration(startTime, interval))
+			if err != nil {
+				return
 			}
 		}
-	}
-
-	log.Println("I! [agent] Hang on, flushing any cached metrics before shutdown")
-	cancel()
-	wg.Wait()
 
-	return nil
+		a.flush(ctx, output, interval, jitter, trigger)
+	})
 }
 
 // flush runs an output's flush function periodically until the context is
-// done.
+// done.  A value received on trigger runs an additional flush immediately.
 func (a *Agent) flush(
 	ctx context.Context,
 	output *models.RunningOutput,
 	interval time.Duration,
 	jitter time.Duration,
+	trigger <-chan struct{},
 ) {
 	// since we are watching two channels we need a ticker with the jitter
 	// integrated.
@@ -556,7 +893,7 @@ func (a *Agent) flush(
 		// Favor shutdown over other methods.
 		select {
 		case <-ctx.Done():
-			logError(a.flushOnce(output, interval, output.Write))
+			logError(a.flushOnce(output, interval, output.WriteFinal))
 			return
 		default:
 		}
@@ -564,6 +901,8 @@ func (a *Agent) flush(
 		select {
 		case <-ticker.C:
 			logError(a.flushOnce(output, interval, output.Write))
+		case <-trigger:
+			logError(a.flushOnce(output, interval, output.Write))
 		case <-output.BatchReady:
 			// Favor the ticker over batch ready
 			select {
@@ -573,7 +912,7 @@ func (a *Agent) flush(
 				logError(a.flushOnce(output, interval, output.WriteBatch))
 			}
 		case <-ctx.Done():
-			logError(a.flushOnce(output, interval, output.Write))
+			logError(a.flushOnce(output, interval, output.WriteFinal))
 			return
 		}
 	}
@@ -610,29 +949,34 @@ func (a *Agent) flushOnce(
 
 // initPlugins runs the Init function on plugins.
 func (a *Agent) initPlugins() error {
+	err := a.Config.ResolveSecrets()
+	if err != nil {
+		return err
+	}
+
 	for _, input := range a.Config.Inputs {
-		err := input.Init()
+		err = input.Init()
 		if err != nil {
 			return fmt.Errorf("could not initialize input %s: %v",
 				input.Config.Name, err)
 		}
 	}
 	for _, processor := range a.Config.Processors {
-		err := processor.Init()
+		err = processor.Init()
 		if err != nil {
 			return fmt.Errorf("could not initialize processor %s: %v",
 				processor.Config.Name, err)
 		}
 	}
 	for _, aggregator := range a.Config.Aggregators {
-		err := aggregator.Init()
+		err = aggregator.Init()
 		if err != nil {
 			return fmt.Errorf("could not initialize aggregator %s: %v",
 				aggregator.Config.Name, err)
 		}
 	}
 	for _, output := range a.Config.Outputs {
-		err := output.Init()
+		err = output.Init()
 		if err != nil {
 			return fmt.Errorf("could not initialize output %s: %v",
 				output.Config.Name, err)
@@ -675,7 +1019,7 @@ func (a *Agent) closeOutputs() {
 // startServiceInputs starts all service inputs.
 func (a *Agent) startServiceInputs(
 	ctx context.Context,
-	dst chan<- telegraf.Metric,
+	dst func(input *models.RunningInput) chan<- telegraf.Metric,
 ) error {
 	started := []telegraf.ServiceInput{}
 
@@ -685,7 +1029,7 @@ func (a *Agent) startServiceInputs(
 			// This only applies to the accumulator passed to Start(), the
 			// Gather() accumulator does apply rounding according to the
 			// precision agent setting.
-			acc := NewAccumulator(input, dst)
+			acc := NewAccumulator(input, dst(input))
 			acc.SetPrecision(time.Nanosecond)
 
 			err := si.Start(acc)
@@ -0,0 +1,273 @@
+package agent
+
+import (
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/influxdata/telegraf/selfstat"
+)
+
+// apiServer serves the HTTP API used to inspect and control a running agent.
+type apiServer struct {
+	agent    *Agent
+	listener net.Listener
+	server   *http.Server
+}
+
+type apiPlugin struct {
+	Name   string      `json:"name"`
+	Config interface{} `json:"config"`
+}
+
+type apiPlugins struct {
+	Inputs      []apiPlugin `json:"inputs"`
+	Processors  []apiPlugin `json:"processors"`
+	Aggregators []apiPlugin `json:"aggregators"`
+	Outputs     []apiPlugin `json:"outputs"`
+}
+
+type apiOutput struct {
+	Name                string     `json:"name"`
+	BufferSize          int        `json:"buffer_size"`
+	BufferLimit         int        `json:"buffer_limit"`
+	ConsecutiveFailures int64      `json:"consecutive_failures"`
+	CircuitState        int64      `json:"circuit_state"`
+	LastError           string     `json:"last_error,omitempty"`
+	LastErrorTime       *time.Time `json:"last_error_time,omitempty"`
+}
+
+type apiStat struct {
+	Name   string                 `json:"name"`
+	Tags   map[string]string      `json:"tags"`
+	Fields map[string]interface{} `json:"fields"`
+}
+
+type apiTriggered struct {
+	Triggered int `json:"triggered"`
+}
+
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// newAPIServer creates an API server listening on address.
+func newAPIServer(a *Agent, address string) (*apiServer, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &apiServer{
+		agent:    a,
+		listener: listener,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/plugins", s.handlePlugins)
+	mux.HandleFunc("/api/outputs", s.handleOutputs)
+	mux.HandleFunc("/api/stats", s.handleStats)
+	mux.HandleFunc("/api/gather", s.handleGather)
+	mux.HandleFunc("/api/flush", s.handleFlush)
+	s.server = &http.Server{Handler: mux}
+
+	return s, nil
+}
+
+// start serves requests until stop is called.
+func (s *apiServer) start() {
+	log.Printf("I! [agent] Serving API on %s", s.listener.Addr())
+	go func() {
+		err := s.server.Serve(s.listener)
+		if err != nil && err != http.ErrServerClosed {
+			log.Printf("E! [agent] Error serving API: %v", err)
+		}
+	}()
+}
+
+// stop closes the server.  All requests are answered immediately, so there
+// is no need to wait for requests in progress.
+func (s *apiServer) stop() {
+	err := s.server.Close()
+	if err != nil {
+		log.Printf("E! [agent] Error stopping API: %v", err)
+	}
+}
+
+// handlePlugins lists the running plugins with their configuration.
+func (s *apiServer) handlePlugins(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	a := s.agent
+	a.mu.RLock()
+	plugins := apiPlugins{
+		Inputs:      make([]apiPlugin, 0, len(a.Config.Inputs)),
+		Processors:  make([]apiPlugin, 0, len(a.Config.Processors)),
+		Aggregators: make([]apiPlugin, 0, len(a.Config.Aggregators)),
+		Outputs:     make([]apiPlugin, 0, len(a.Config.Outputs)),
+	}
+	for _, input := range a.Config.Inputs {
+		plugins.Inputs = append(plugins.Inputs,
+			apiPlugin{Name: input.Name(), Config: input.Config})
+	}
+	for _, processor := range a.Config.Processors {
+		plugins.Processors = append(plugins.Processors,
+			apiPlugin{Name: "processors." + processor.Config.Name, Config: processor.Config})
+	}
+	for _, agg := range a.Config.Aggregators {
+		plugins.Aggregators = append(plugins.Aggregators,
+			apiPlugin{Name: agg.Name(), Config: agg.Config})
+	}
+	for _, output := range a.Config.Outputs {
+		plugins.Outputs = append(plugins.Outputs,
+			apiPlugin{Name: "outputs." + output.Config.Name, Config: output.Config})
+	}
+	a.mu.RUnlock()
+
+	writeJSON(w, http.StatusOK, plugins)
+}
+
+// handleOutputs reports the buffer and error status of each output.
+func (s *apiServer) handleOutputs(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	a := s.agent
+	a.mu.RLock()
+	outputs := make([]apiOutput, 0, len(a.Config.Outputs))
+	for _, output := range a.Config.Outputs {
+		status := apiOutput{
+			Name:                "outputs." + output.Config.Name,
+			BufferSize:          output.BufferLength(),
+			BufferLimit:         output.MetricBufferLimit,
+			ConsecutiveFailures: output.ConsecutiveFailures.Get(),
+			CircuitState:        output.CircuitState.Get(),
+		}
+		if errTime, err := output.LastError(); err != nil {
+			status.LastError = err.Error()
+			status.LastErrorTime = &errTime
+		}
+		outputs = append(outputs, status)
+	}
+	a.mu.RUnlock()
+
+	writeJSON(w, http.StatusOK, outputs)
+}
+
+// handleStats reports the internal statistics collected by the agent.
+func (s *apiServer) handleStats(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+
+	stats := []apiStat{}
+	for _, m := range selfstat.Metrics() {
+		if m == nil {
+			continue
+		}
+		stats = append(stats, apiStat{
+			Name:   m.Name(),
+			Tags:   m.Tags(),
+			Fields: m.Fields(),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, stats)
+}
+
+// handleGather triggers an immediate gather of the inputs named by the input
+// query parameter.
+func (s *apiServer) handleGather(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	name := r.URL.Query().Get("input")
+	if name == "" {
+		writeJSON(w, http.StatusBadRequest, apiError{"missing input parameter"})
+		return
+	}
+
+	a := s.agent
+	triggered := 0
+	a.mu.RLock()
+	if a.running != nil {
+		for input, unit := range a.running.inputs {
+			if matchName(name, "inputs", input.Config.Name) {
+				unit.trigger()
+				triggered++
+			}
+		}
+	}
+	a.mu.RUnlock()
+
+	s.writeTriggered(w, triggered)
+}
+
+// handleFlush triggers an immediate write of the outputs named by the output
+// query parameter.
+func (s *apiServer) handleFlush(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+
+	name := r.URL.Query().Get("output")
+	if name == "" {
+		writeJSON(w, http.StatusBadRequest, apiError{"missing output parameter"})
+		return
+	}
+
+	a := s.agent
+	triggered := 0
+	a.mu.RLock()
+	if a.running != nil {
+		for output, unit := range a.running.outputs {
+			if matchName(name, "outputs", output.Config.Name) {
+				unit.trigger()
+				triggered++
+			}
+		}
+	}
+	a.mu.RUnlock()
+
+	s.writeTriggered(w, triggered)
+}
+
+func (s *apiServer) writeTriggered(w http.ResponseWriter, triggered int) {
+	if triggered == 0 {
+		writeJSON(w, http.StatusNotFound, apiError{"no running plugin with this name"})
+		return
+	}
+	writeJSON(w, http.StatusAccepted, apiTriggered{triggered})
+}
+
+// matchName returns true if name refers to the plugin, either with or without
+// the plugin type prefix.
+func matchName(name, kind, pluginName string) bool {
+	return name == pluginName || name == kind+"."+pluginName
+}
+
+// allowMethod writes an error response and returns false if the request
+// does not use method.
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		writeJSON(w, http.StatusMethodNotAllowed, apiError{"method not allowed"})
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		log.Printf("E! [agent] Error writing API response: %v", err)
+	}
+}
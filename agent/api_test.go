@@ -0,0 +1,160 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/models"
+	"github.com/stretchr/testify/require"
+)
+
+type apiTestInput struct {
+	sync.Mutex
+	gathers int
+}
+
+func (i *apiTestInput) SampleConfig() string { return "" }
+func (i *apiTestInput) Description() string  { return "" }
+func (i *apiTestInput) Gather(acc telegraf.Accumulator) error {
+	i.Lock()
+	i.gathers++
+	i.Unlock()
+	acc.AddFields("test", map[string]interface{}{"value": 42}, nil)
+	return nil
+}
+
+func (i *apiTestInput) Gathers() int {
+	i.Lock()
+	defer i.Unlock()
+	return i.gathers
+}
+
+type apiTestOutput struct {
+	sync.Mutex
+	metrics int
+}
+
+func (o *apiTestOutput) SampleConfig() string { return "" }
+func (o *apiTestOutput) Description() string  { return "" }
+func (o *apiTestOutput) Connect() error       { return nil }
+func (o *apiTestOutput) Close() error         { return nil }
+func (o *apiTestOutput) Write(metrics []telegraf.Metric) error {
+	o.Lock()
+	o.metrics += len(metrics)
+	o.Unlock()
+	return nil
+}
+
+func (o *apiTestOutput) Metrics() int {
+	o.Lock()
+	defer o.Unlock()
+	return o.metrics
+}
+
+func newAPITestAgent(input telegraf.Input, output telegraf.Output) *Agent {
+	c := config.NewConfig()
+	c.Agent.Interval.Duration = time.Hour
+	c.Agent.FlushInterval.Duration = time.Hour
+	c.Agent.RoundInterval = false
+	c.Agent.HTTPAPIAddress = "localhost:0"
+	c.Inputs = append(c.Inputs,
+		models.NewRunningInput(input, &models.InputConfig{Name: "test"}))
+	c.Outputs = append(c.Outputs,
+		models.NewRunningOutput("test", output, &models.OutputConfig{Name: "test"}, 0, 0))
+
+	a, _ := NewAgent(c)
+	return a
+}
+
+func TestAPI_Plugins(t *testing.T) {
+	a := newAPITestAgent(&apiTestInput{}, &apiTestOutput{})
+	api, err := newAPIServer(a, "localhost:0")
+	require.NoError(t, err)
+	api.start()
+	defer api.stop()
+
+	resp, err := http.Get(fmt.Sprintf("http://%s/api/plugins", api.listener.Addr()))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var plugins apiPlugins
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&plugins))
+	require.Len(t, plugins.Inputs, 1)
+	require.Equal(t, "inputs.test", plugins.Inputs[0].Name)
+	require.Len(t, plugins.Outputs, 1)
+	require.Equal(t, "outputs.test", plugins.Outputs[0].Name)
+	require.Len(t, plugins.Processors, 0)
+	require.Len(t, plugins.Aggregators, 0)
+}
+
+func TestAPI_MethodNotAllowed(t *testing.T) {
+	a := newAPITestAgent(&apiTestInput{}, &apiTestOutput{})
+	api, err := newAPIServer(a, "localhost:0")
+	require.NoError(t, err)
+	api.start()
+	defer api.stop()
+
+	resp, err := http.Get(fmt.Sprintf("http://%s/api/gather?input=test", api.listener.Addr()))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+}
+
+func TestAPI_GatherAndFlush(t *testing.T) {
+	input := &apiTestInput{}
+	output := &apiTestOutput{}
+	a := newAPITestAgent(input, output)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- a.Run(ctx)
+	}()
+	defer func() {
+		cancel()
+		require.NoError(t, <-done)
+	}()
+
+	waitFor(t, func() bool { return input.Gathers() == 1 })
+
+	a.mu.RLock()
+	addr := a.running.api.listener.Addr()
+	a.mu.RUnlock()
+
+	resp, err := http.Post(fmt.Sprintf("http://%s/api/gather?input=inputs.test", addr), "", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	waitFor(t, func() bool { return input.Gathers() == 2 })
+	waitFor(t, func() bool { return a.Config.Outputs[0].BufferLength() == 2 })
+
+	resp, err = http.Post(fmt.Sprintf("http://%s/api/flush?output=test", addr), "", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusAccepted, resp.StatusCode)
+	waitFor(t, func() bool { return output.Metrics() == 2 })
+
+	resp, err = http.Post(fmt.Sprintf("http://%s/api/flush?output=missing", addr), "", nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+// waitFor waits up to a second for condition to become true.
+func waitFor(t *testing.T, condition func() bool) {
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("timeout waiting for condition")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,37 @@
+package agent
+
+import (
+	"context"
+	"testing"
+
+	"github.com/influxdata/telegraf/internal/models"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPipelines_Routing(t *testing.T) {
+	input := &apiTestInput{}
+	output := &apiTestOutput{}
+	a := newAPITestAgent(input, output)
+	a.Config.Agent.HTTPAPIAddress = ""
+
+	webInput := &apiTestInput{}
+	webOutput := &apiTestOutput{}
+	a.Config.Inputs = append(a.Config.Inputs,
+		models.NewRunningInput(webInput, &models.InputConfig{Name: "test", Pipeline: "web"}))
+	a.Config.Outputs = append(a.Config.Outputs,
+		models.NewRunningOutput("test", webOutput, &models.OutputConfig{Name: "test", Pipeline: "web"}, 0, 0))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- a.Run(ctx)
+	}()
+
+	waitFor(t, func() bool { return input.Gathers() == 1 && webInput.Gathers() == 1 })
+	cancel()
+	require.NoError(t, <-done)
+
+	// Each output only receives the metric of the input in its pipeline.
+	require.Equal(t, 1, output.Metrics())
+	require.Equal(t, 1, webOutput.Metrics())
+}
@@ -0,0 +1,381 @@
+package agent
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/models"
+)
+
+// Diff returns the differences between the running configuration and newer.
+func (a *Agent) Diff(newer *config.Config) *config.ConfigDiff {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	return a.Config.Diff(newer)
+}
+
+// Reload applies the changes in diff to the running agent.
+//
+// Only the plugins that were added or removed are started and stopped;
+// unchanged outputs keep their buffers and unchanged aggregators keep their
+// current period.  The new plugins are initialized before any change is
+// made, if this fails an error is returned and the agent is left as it was.
+// Removed outputs whose disk buffer is reused by a new output are stopped
+// last, and started again if the buffer cannot be opened.
+func (a *Agent) Reload(diff *config.ConfigDiff) error {
+	a.reloadMu.Lock()
+	defer a.reloadMu.Unlock()
+
+	if a.running == nil || a.running.stopping {
+		return errors.New("agent is not running")
+	}
+	if diff.Restart {
+		return errors.New("configuration change requires a restart")
+	}
+
+	// A disk buffer can only be opened once, so an output whose buffer
+	// directory is reused by a new output must be closed before the buffer
+	// of the new output is opened.
+	replaced := make(map[*models.RunningOutput]bool)
+	reusing := make(map[*models.RunningOutput]bool)
+	for _, output := range diff.RemovedOutputs {
+		if output.Config.BufferStrategy != models.BufferStrategyDisk {
+			continue
+		}
+		for _, added := range diff.AddedOutputs {
+			if added.Config.BufferStrategy == models.BufferStrategyDisk &&
+				added.Config.BufferDirectory == output.Config.BufferDirectory {
+				replaced[output] = true
+				reusing[added] = true
+			}
+		}
+	}
+
+	err := a.initAdded(diff, reusing)
+	if err != nil {
+		a.releaseAdded(diff, 0, nil, nil)
+		return err
+	}
+
+	for i, output := range diff.AddedOutputs {
+		log.Printf("D! [agent] Attempting connection to output: %s\n", output.Name)
+		err := output.Output.Connect()
+		if err != nil {
+			a.releaseAdded(diff, i+1, nil, nil)
+			return fmt.Errorf("could not connect to output %s: %v",
+				output.Name, err)
+		}
+		log.Printf("D! [agent] Successfully connected to output: %s\n", output.Name)
+	}
+
+	processors := make(map[*models.RunningProcessor]*processorUnit)
+	for _, proc := range diff.AddedProcessors {
+		unit, err := a.startProcessor(a.running.pipelines[proc.Config.Pipeline], proc)
+		if err != nil {
+			a.releaseAdded(diff, len(diff.AddedOutputs), processors, nil)
+			return fmt.Errorf("could not start processor %s: %v",
+				proc.Config.Name, err)
+		}
+		if unit != nil {
+			processors[proc] = unit
+		}
+	}
+
+	for output := range replaced {
+		a.stopOutput(output)
+	}
+	for _, output := range diff.AddedOutputs {
+		if !reusing[output] {
+			continue
+		}
+		err := output.OpenBuffer()
+		if err != nil {
+			a.releaseAdded(diff, len(diff.AddedOutputs), processors, replaced)
+			return fmt.Errorf("could not initialize output %s: %v",
+				output.Config.Name, err)
+		}
+	}
+
+	now := time.Now()
+
+	// Start the new outputs first so that no metrics are lost while the
+	// other plugins are replaced.
+	a.mu.Lock()
+	for _, output := range diff.AddedOutputs {
+		a.Config.Outputs = append(a.Config.Outputs, output)
+		a.startOutput(output, now)
+	}
+	a.mu.Unlock()
+
+	a.mu.Lock()
+	units := make([]*pluginUnit, 0, len(diff.RemovedAggregators))
+	for _, agg := range diff.RemovedAggregators {
+		a.Config.Aggregators = removeAggregator(a.Config.Aggregators, agg)
+		if unit, ok := a.running.aggregators[agg]; ok {
+			units = append(units, unit)
+			delete(a.running.aggregators, agg)
+		}
+	}
+	a.mu.Unlock()
+
+	// Stopping an aggregator pushes its current aggregation.
+	for _, unit := range units {
+		unit.stop()
+	}
+
+	a.mu.Lock()
+	for _, agg := range diff.AddedAggregators {
+		since, until := updateWindow(now, a.Config.Agent.RoundInterval, agg.Period())
+		agg.UpdateWindow(since, until)
+		a.Config.Aggregators = append(a.Config.Aggregators, agg)
+		a.startAggregator(agg)
+	}
+
+	// Removed streaming processors are stopped once they are no longer used,
+	// the metrics they still emit pass through the processors that followed
+	// them.
+	removed := make(map[*models.RunningProcessor]*processorUnit)
+	for _, proc := range diff.RemovedProcessors {
+		unit, ok := a.running.processors[proc]
+		if !ok {
+			continue
+		}
+		unit.removed = true
+		for _, follower := range processorsAfter(a.Config.Processors, proc) {
+			if containsProcessor(diff.Processors, follower) {
+				unit.followers = append(unit.followers, follower)
+			}
+		}
+		removed[proc] = unit
+		delete(a.running.processors, proc)
+	}
+	for proc, unit := range processors {
+		a.running.processors[proc] = unit
+	}
+	a.Config.Processors = diff.Processors
+	a.mu.Unlock()
+
+	for _, proc := range diff.RemovedProcessors {
+		if unit, ok := removed[proc]; ok {
+			stopProcessor(proc, unit)
+		} else {
+			proc.Stop()
+		}
+	}
+
+	a.mu.Lock()
+	units = make([]*pluginUnit, 0, len(diff.RemovedInputs))
+	for _, input := range diff.RemovedInputs {
+		a.Config.Inputs = removeInput(a.Config.Inputs, input)
+		if unit, ok := a.running.inputs[input]; ok {
+			units = append(units, unit)
+			delete(a.running.inputs, input)
+		}
+	}
+	a.mu.Unlock()
+
+	for _, unit := range units {
+		unit.stop()
+	}
+	for _, input := range diff.RemovedInputs {
+		if si, ok := input.Input.(telegraf.ServiceInput); ok {
+			si.Stop()
+		}
+	}
+
+	// Service inputs are started after the removed inputs are stopped, a
+	// changed input may need to reuse resources such as a listening port.
+	failed := make(map[*models.RunningInput]bool)
+	for _, input := range diff.AddedInputs {
+		if si, ok := input.Input.(telegraf.ServiceInput); ok {
+			acc := NewAccumulator(input, a.running.pipelines[input.Config.Pipeline].inputC)
+			acc.SetPrecision(time.Nanosecond)
+
+			err := si.Start(acc)
+			if err != nil {
+				log.Printf("E! [agent] Service for input %s failed to start: %v",
+					input.Name(), err)
+				failed[input] = true
+			}
+		}
+	}
+
+	a.mu.Lock()
+	for _, input := range diff.AddedInputs {
+		if failed[input] {
+			continue
+		}
+		a.Config.Inputs = append(a.Config.Inputs, input)
+		a.startInput(input, now)
+	}
+	a.mu.Unlock()
+
+	for _, output := range diff.RemovedOutputs {
+		if !replaced[output] {
+			a.stopOutput(output)
+		}
+	}
+
+	a.mu.Lock()
+	a.Config.Apply(diff)
+	for input := range failed {
+		a.Config.Inputs = removeInput(a.Config.Inputs, input)
+	}
+	a.mu.Unlock()
+
+	log.Printf("I! [agent] Reloaded config: added %d and removed %d plugins",
+		len(diff.AddedInputs)+len(diff.AddedOutputs)+
+			len(diff.AddedAggregators)+len(diff.AddedProcessors),
+		len(diff.RemovedInputs)+len(diff.RemovedOutputs)+
+			len(diff.RemovedAggregators)+len(diff.RemovedProcessors))
+	return nil
+}
+
+// stopOutput removes an output from the running agent, writes any metrics
+// remaining in its buffer and closes it.
+func (a *Agent) stopOutput(output *models.RunningOutput) {
+	a.mu.Lock()
+	a.Config.Outputs = removeOutput(a.Config.Outputs, output)
+	unit, ok := a.running.outputs[output]
+	delete(a.running.outputs, output)
+	a.mu.Unlock()
+
+	if ok {
+		unit.stop()
+	}
+	output.Close()
+}
+
+// restartOutput reopens the buffer of an output stopped by stopOutput,
+// connects it and starts it again.
+func (a *Agent) restartOutput(output *models.RunningOutput) error {
+	err := output.OpenBuffer()
+	if err != nil {
+		return err
+	}
+	err = output.Output.Connect()
+	if err != nil {
+		output.Release()
+		return err
+	}
+
+	a.mu.Lock()
+	a.Config.Outputs = append(a.Config.Outputs, output)
+	a.startOutput(output, time.Now())
+	a.mu.Unlock()
+	return nil
+}
+
+// releaseAdded releases the plugins added by diff after the reload failed.
+// The first connected outputs are closed, only the buffers of the others are
+// closed as they were never connected.  The replaced outputs, stopped so that
+// their disk buffer could be reused, are started again.
+func (a *Agent) releaseAdded(
+	diff *config.ConfigDiff,
+	connected int,
+	processors map[*models.RunningProcessor]*processorUnit,
+	replaced map[*models.RunningOutput]bool,
+) {
+	for i, output := range diff.AddedOutputs {
+		if i < connected {
+			output.Close()
+		} else {
+			output.Release()
+		}
+	}
+	stopProcessors(processors)
+
+	for output := range replaced {
+		err := a.restartOutput(output)
+		if err != nil {
+			log.Printf("E! [agent] Could not restart output %s: %v",
+				output.Name, err)
+		}
+	}
+}
+
+// initAdded runs the Init function on the plugins added by diff.  The disk
+// buffers of the outputs in reusing are not opened yet.
+func (a *Agent) initAdded(diff *config.ConfigDiff, reusing map[*models.RunningOutput]bool) error {
+	err := diff.ResolveSecrets()
+	if err != nil {
+		return err
+	}
+
+	for _, input := range diff.AddedInputs {
+		err = input.Init()
+		if err != nil {
+			return fmt.Errorf("could not initialize input %s: %v",
+				input.Config.Name, err)
+		}
+	}
+	for _, processor := range diff.AddedProcessors {
+		err = processor.Init()
+		if err != nil {
+			return fmt.Errorf("could not initialize processor %s: %v",
+				processor.Config.Name, err)
+		}
+	}
+	for _, aggregator := range diff.AddedAggregators {
+		err = aggregator.Init()
+		if err != nil {
+			return fmt.Errorf("could not initialize aggregator %s: %v",
+				aggregator.Config.Name, err)
+		}
+	}
+	for _, output := range diff.AddedOutputs {
+		err = output.InitPlugin()
+		if err == nil && !reusing[output] {
+			err = output.OpenBuffer()
+		}
+		if err != nil {
+			return fmt.Errorf("could not initialize output %s: %v",
+				output.Config.Name, err)
+		}
+	}
+	return nil
+}
+
+func removeInput(inputs []*models.RunningInput, input *models.RunningInput) []*models.RunningInput {
+	result := make([]*models.RunningInput, 0, len(inputs))
+	for _, i := range inputs {
+		if i != input {
+			result = append(result, i)
+		}
+	}
+	return result
+}
+
+func removeOutput(outputs []*models.RunningOutput, output *models.RunningOutput) []*models.RunningOutput {
+	result := make([]*models.RunningOutput, 0, len(outputs))
+	for _, o := range outputs {
+		if o != output {
+			result = append(result, o)
+		}
+	}
+	return result
+}
+
+func containsProcessor(processors []*models.RunningProcessor, proc *models.RunningProcessor) bool {
+	for _, p := range processors {
+		if p == proc {
+			return true
+		}
+	}
+	return false
+}
+
+func removeAggregator(aggs []*models.RunningAggregator, agg *models.RunningAggregator) []*models.RunningAggregator {
+	result := make([]*models.RunningAggregator, 0, len(aggs))
+	for _, a := range aggs {
+		if a != agg {
+			result = append(result, a)
+		}
+	}
+	return result
+}
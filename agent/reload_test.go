@@ -0,0 +1,238 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/config"
+	"github.com/influxdata/telegraf/internal/models"
+	"github.com/stretchr/testify/require"
+)
+
+// streamingTestProcessor holds the metrics until it is stopped.
+type streamingTestProcessor struct {
+	sync.Mutex
+	acc     telegraf.Accumulator
+	held    []telegraf.Metric
+	stopped bool
+}
+
+func (p *streamingTestProcessor) SampleConfig() string { return "" }
+func (p *streamingTestProcessor) Description() string  { return "" }
+
+func (p *streamingTestProcessor) Start(acc telegraf.Accumulator) error {
+	p.acc = acc
+	return nil
+}
+
+func (p *streamingTestProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	p.Lock()
+	defer p.Unlock()
+	p.held = append(p.held, in...)
+	return nil
+}
+
+func (p *streamingTestProcessor) Stop() {
+	p.Lock()
+	defer p.Unlock()
+	for _, m := range p.held {
+		p.acc.AddMetric(m)
+	}
+	p.held = nil
+	p.stopped = true
+}
+
+func (p *streamingTestProcessor) Stopped() bool {
+	p.Lock()
+	defer p.Unlock()
+	return p.stopped
+}
+
+type reloadTestOutput struct {
+	apiTestOutput
+	connectErr error
+	closed     bool
+}
+
+func (o *reloadTestOutput) Connect() error {
+	return o.connectErr
+}
+
+func (o *reloadTestOutput) Close() error {
+	o.closed = true
+	return nil
+}
+
+func newDiskTestOutput(output telegraf.Output, dir string) *models.RunningOutput {
+	return models.NewRunningOutput("test", output, &models.OutputConfig{
+		Name:            "test",
+		BufferStrategy:  models.BufferStrategyDisk,
+		BufferDirectory: dir,
+	}, 0, 0)
+}
+
+func runTestAgent(a *Agent) (context.CancelFunc, <-chan error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- a.Run(ctx)
+	}()
+	return cancel, done
+}
+
+func TestProcessors_StopStreamingAtShutdown(t *testing.T) {
+	input := &apiTestInput{}
+	output := &apiTestOutput{}
+	a := newAPITestAgent(input, output)
+	a.Config.Agent.HTTPAPIAddress = ""
+
+	proc := &streamingTestProcessor{}
+	a.Config.Processors = append(a.Config.Processors,
+		&models.RunningProcessor{Name: "test", Processor: proc, Config: &models.ProcessorConfig{Name: "test"}})
+
+	cancel, done := runTestAgent(a)
+	waitFor(t, func() bool { return input.Gathers() == 1 })
+	cancel()
+	require.NoError(t, <-done)
+
+	// The metric held by the processor is written once it is stopped.
+	require.True(t, proc.Stopped())
+	require.Equal(t, 1, output.Metrics())
+}
+
+func TestReload_StopsRemovedProcessor(t *testing.T) {
+	input := &apiTestInput{}
+	output := &apiTestOutput{}
+	a := newAPITestAgent(input, output)
+	a.Config.Agent.HTTPAPIAddress = ""
+
+	proc := &streamingTestProcessor{}
+	rp := &models.RunningProcessor{Name: "test", Processor: proc, Config: &models.ProcessorConfig{Name: "test"}}
+	a.Config.Processors = append(a.Config.Processors, rp)
+
+	cancel, done := runTestAgent(a)
+	defer func() {
+		cancel()
+		require.NoError(t, <-done)
+	}()
+	waitFor(t, func() bool { return input.Gathers() == 1 })
+	waitFor(t, func() bool {
+		proc.Lock()
+		defer proc.Unlock()
+		return len(proc.held) == 1
+	})
+
+	err := a.Reload(&config.ConfigDiff{
+		RemovedProcessors: []*models.RunningProcessor{rp},
+		Inputs:            a.Config.Inputs,
+		Outputs:           a.Config.Outputs,
+	})
+	require.NoError(t, err)
+
+	require.True(t, proc.Stopped())
+	require.Len(t, a.Config.Processors, 0)
+}
+
+func TestReload_ReleasesAddedOnFailure(t *testing.T) {
+	input := &apiTestInput{}
+	output := &apiTestOutput{}
+	a := newAPITestAgent(input, output)
+	a.Config.Agent.HTTPAPIAddress = ""
+
+	cancel, done := runTestAgent(a)
+	defer func() {
+		cancel()
+		require.NoError(t, <-done)
+	}()
+	waitFor(t, func() bool { return input.Gathers() == 1 })
+
+	failing := &reloadTestOutput{connectErr: errors.New("connection refused")}
+	next := &reloadTestOutput{}
+	added := []*models.RunningOutput{
+		models.NewRunningOutput("failing", failing, &models.OutputConfig{Name: "failing"}, 0, 0),
+		models.NewRunningOutput("next", next, &models.OutputConfig{Name: "next"}, 0, 0),
+	}
+	err := a.Reload(&config.ConfigDiff{
+		AddedOutputs: added,
+		Inputs:       a.Config.Inputs,
+		Outputs:      append(a.Config.Outputs, added...),
+	})
+	require.Error(t, err)
+
+	// The output failing to connect is closed, the following output was
+	// never connected.
+	require.True(t, failing.closed)
+	require.False(t, next.closed)
+	require.Len(t, a.Config.Outputs, 1)
+}
+
+func TestReload_KeepsReplacedOutputOnFailure(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-buffer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	input := &apiTestInput{}
+	a := newAPITestAgent(input, &apiTestOutput{})
+	a.Config.Agent.HTTPAPIAddress = ""
+	old := &reloadTestOutput{}
+	replaced := newDiskTestOutput(old, dir)
+	a.Config.Outputs = append(a.Config.Outputs, replaced)
+
+	cancel, done := runTestAgent(a)
+	defer func() {
+		cancel()
+		require.NoError(t, <-done)
+	}()
+	waitFor(t, func() bool { return input.Gathers() == 1 })
+
+	added := newDiskTestOutput(&reloadTestOutput{connectErr: errors.New("connection refused")}, dir)
+	err = a.Reload(&config.ConfigDiff{
+		AddedOutputs:   []*models.RunningOutput{added},
+		RemovedOutputs: []*models.RunningOutput{replaced},
+		Inputs:         a.Config.Inputs,
+		Outputs:        []*models.RunningOutput{a.Config.Outputs[0], added},
+	})
+	require.Error(t, err)
+
+	// The output whose disk buffer would have been reused is not stopped
+	// when the new output fails to connect.
+	require.False(t, old.closed)
+	require.Len(t, a.Config.Outputs, 2)
+}
+
+func TestReload_RestartsReplacedOutput(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-buffer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	input := &apiTestInput{}
+	a := newAPITestAgent(input, &apiTestOutput{})
+	a.Config.Agent.HTTPAPIAddress = ""
+	old := &reloadTestOutput{}
+	replaced := newDiskTestOutput(old, dir)
+	a.Config.Outputs = append(a.Config.Outputs, replaced)
+
+	cancel, done := runTestAgent(a)
+	defer func() {
+		cancel()
+		require.NoError(t, <-done)
+	}()
+	waitFor(t, func() bool { return input.Gathers() == 1 })
+
+	// The replaced output is stopped before the buffer of the new output is
+	// opened, if this fails it is started again.
+	a.stopOutput(replaced)
+	require.True(t, old.closed)
+	a.releaseAdded(&config.ConfigDiff{}, 0, nil,
+		map[*models.RunningOutput]bool{replaced: true})
+
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	require.Len(t, a.Config.Outputs, 2)
+	require.Contains(t, a.running.outputs, replaced)
+}
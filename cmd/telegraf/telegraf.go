@@ -5,6 +5,7 @@ import (
 	"errors"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"net/http"
 	_ "net/http/pprof" // Comment this line to disable pprof endpoint.
@@ -39,6 +40,8 @@ var fTestWait = flag.Int("test-wait", 0, "wait up to this many seconds for servi
 var fConfig = flag.String("config", "", "configuration file to load")
 var fConfigDirectory = flag.String("config-directory", "",
 	"directory containing additional *.conf files")
+var fWatchConfig = flag.Bool("watch-config", false,
+	"reload the configuration when the config files change")
 var fVersion = flag.Bool("version", false, "display the version and exit")
 var fSampleConfig = flag.Bool("sample-config", false,
 	"print out full sample configuration")
@@ -73,6 +76,14 @@ var (
 
 var stop chan struct{}
 
+// watchDebounce is the time to wait for further changes after a config file
+// changed before reloading.
+const watchDebounce = time.Second
+
+// errRestart is returned by runAgent when the agent must be restarted to
+// apply a new configuration.
+var errRestart = errors.New("agent restart required")
+
 func reloadLoop(
 	stop chan struct{},
 	inputFilters []string,
@@ -86,61 +97,83 @@ func reloadLoop(
 		reload <- false
 
 		ctx, cancel := context.WithCancel(context.Background())
+		hup := make(chan struct{}, 1)
 
 		signals := make(chan os.Signal)
 		signal.Notify(signals, os.Interrupt, syscall.SIGHUP,
 			syscall.SIGTERM, syscall.SIGINT)
 		go func() {
-			select {
-			case sig := <-signals:
-				if sig == syscall.SIGHUP {
-					log.Printf("I! Reloading Telegraf config")
-					<-reload
-					reload <- true
+			for {
+				select {
+				case sig := <-signals:
+					if sig == syscall.SIGHUP {
+						log.Printf("I! Reloading Telegraf config")
+						select {
+						case hup <- struct{}{}:
+						default:
+						}
+						continue
+					}
+					cancel()
+				case <-stop:
+					cancel()
+				case <-ctx.Done():
 				}
-				cancel()
-			case <-stop:
-				cancel()
+				return
 			}
 		}()
 
-		err := runAgent(ctx, inputFilters, outputFilters)
+		err := runAgent(ctx, hup, inputFilters, outputFilters)
+		cancel()
+		signal.Stop(signals)
+		if err == errRestart {
+			<-reload
+			reload <- true
+			continue
+		}
 		if err != nil && err != context.Canceled {
 			log.Fatalf("E! [telegraf] Error running agent: %v", err)
 		}
 	}
 }
 
-func runAgent(ctx context.Context,
-	inputFilters []string,
-	outputFilters []string,
-) error {
-	// Setup default logging. This may need to change after reading the config
-	// file, but we can configure it to use our logger implementation now.
-	logger.SetupLogging(logger.LogConfig{})
-	log.Printf("I! Starting Telegraf %s", version)
-
-	// If no other options are specified, load the config file and run.
+// loadConfig loads and validates the configuration files.
+func loadConfig(inputFilters []string, outputFilters []string) (*config.Config, error) {
 	c := config.NewConfig()
 	c.OutputFilters = outputFilters
 	c.InputFilters = inputFilters
 	err := c.LoadConfig(*fConfig)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if *fConfigDirectory != "" {
 		err = c.LoadDirectory(*fConfigDirectory)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
+
+	err = validateConfig(c)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// validateConfig checks the settings that are required to run the agent.
+func validateConfig(c *config.Config) error {
 	if !*fTest && len(c.Outputs) == 0 {
 		return errors.New("Error: no outputs found, did you provide a valid config file?")
 	}
 	if len(c.Inputs) == 0 {
 		return errors.New("Error: no inputs found, did you provide a valid config file?")
 	}
+	if !*fTest {
+		if err := c.ValidatePipelines(); err != nil {
+			return err
+		}
+	}
 
 	if int64(c.Agent.Interval.Duration) <= 0 {
 		return fmt.Errorf("Agent interval must be positive, found %s",
@@ -151,6 +184,73 @@ func runAgent(ctx context.Context,
 		return fmt.Errorf("Agent flush_interval must be positive; found %s",
 			c.Agent.Interval.Duration)
 	}
+	return nil
+}
+
+// setSecret stores the secret read from stdin in the secret store with the
+// given ID.
+func setSecret(store string, key string) error {
+	c := config.NewConfig()
+	err := c.LoadConfig(*fConfig)
+	if err != nil {
+		return err
+	}
+	if *fConfigDirectory != "" {
+		err = c.LoadDirectory(*fConfigDirectory)
+		if err != nil {
+			return err
+		}
+	}
+
+	secret, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		return err
+	}
+	return c.SetSecret(store, key, strings.TrimRight(string(secret), "\r\n"))
+}
+
+// checkConfig builds all plugins of the configuration without running them
+// and prints every problem found.  It returns the exit code.
+func checkConfig(inputFilters []string, outputFilters []string) int {
+	c := config.NewConfig()
+	c.OutputFilters = outputFilters
+	c.InputFilters = inputFilters
+
+	errs := c.Check(*fConfig, *fConfigDirectory)
+	if len(errs) == 0 {
+		if err := validateConfig(c); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Fprintf(os.Stderr, "E! %v\n", err)
+		}
+		fmt.Fprintf(os.Stderr, "Found %d problems in the configuration\n", len(errs))
+		return 1
+	}
+
+	fmt.Printf("Configuration OK: %d inputs, %d processors, %d aggregators, %d outputs\n",
+		len(c.Inputs), len(c.Processors), len(c.Aggregators), len(c.Outputs))
+	return 0
+}
+
+func runAgent(ctx context.Context,
+	hup <-chan struct{},
+	inputFilters []string,
+	outputFilters []string,
+) error {
+	// Setup default logging. This may need to change after reading the config
+	// file, but we can configure it to use our logger implementation now.
+	logger.SetupLogging(logger.LogConfig{})
+	log.Printf("I! Starting Telegraf %s", version)
+
+	// If no other options are specified, load the config file and run.
+	c, err := loadConfig(inputFilters, outputFilters)
+	if err != nil {
+		return err
+	}
 
 	ag, err := agent.NewAgent(c)
 	if err != nil {
@@ -198,7 +298,73 @@ func runAgent(ctx context.Context,
 		}
 	}
 
-	return ag.Run(ctx)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var watcher *config.Watcher
+	var changes <-chan struct{}
+	if *fWatchConfig {
+		watcher, err = config.NewWatcher(c, watchDebounce)
+		if err != nil {
+			return fmt.Errorf("could not watch config: %v", err)
+		}
+		defer func() {
+			watcher.Close()
+		}()
+		changes = watcher.Changes()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- ag.Run(ctx)
+	}()
+
+	for {
+		select {
+		case err := <-done:
+			return err
+		case <-hup:
+		case <-changes:
+			log.Printf("I! Config changed, reloading Telegraf config")
+		}
+
+		// The running config is kept if the new one is invalid.
+		newer, err := loadConfig(inputFilters, outputFilters)
+		if err != nil {
+			log.Printf("E! [telegraf] Error loading config, keeping the running config: %v", err)
+			continue
+		}
+
+		// Watch the files of the new config, which may include new files
+		// in the config directory.
+		if watcher != nil {
+			w, err := config.NewWatcher(newer, watchDebounce)
+			if err != nil {
+				log.Printf("E! [telegraf] Could not watch new config files: %v", err)
+			} else {
+				watcher.Close()
+				watcher = w
+				changes = watcher.Changes()
+			}
+		}
+
+		diff := ag.Diff(newer)
+		if diff.Restart {
+			log.Printf("I! Agent settings changed, restarting Telegraf")
+			cancel()
+			<-done
+			return errRestart
+		}
+		if diff.Empty() {
+			log.Printf("I! Config unchanged, nothing to reload")
+			continue
+		}
+
+		err = ag.Reload(diff)
+		if err != nil {
+			log.Printf("E! [telegraf] Error reloading config: %v", err)
+		}
+	}
 }
 
 func usageExit(rc int) {
@@ -302,6 +468,9 @@ func main() {
 			fmt.Println(formatFullVersion())
 			return
 		case "config":
+			if len(args) > 1 && args[1] == "check" {
+				os.Exit(checkConfig(inputFilters, outputFilters))
+			}
 			config.PrintSampleConfig(
 				sectionFilters,
 				inputFilters,
@@ -310,6 +479,15 @@ func main() {
 				processorFilters,
 			)
 			return
+		case "secret":
+			if len(args) != 4 || args[1] != "set" {
+				log.Fatal("E! Usage: telegraf secret set <store> <key>")
+			}
+			err := setSecret(args[2], args[3])
+			if err != nil {
+				log.Fatal("E! " + err.Error())
+			}
+			return
 		}
 	}
 
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/toml/ast"
+)
+
+// pluginSource is the location of a plugin table in the configuration.
+type pluginSource struct {
+	File string
+	Line int
+}
+
+// PluginError is a problem with a plugin found while checking the
+// configuration.
+type PluginError struct {
+	File   string
+	Line   int
+	Plugin string
+	Err    error
+}
+
+func (e *PluginError) Error() string {
+	return fmt.Sprintf("%s:%d: %s: %v", e.File, e.Line, e.Plugin, e.Err)
+}
+
+// source returns the location of a table in the file being loaded.
+func (c *Config) source(tbl *ast.Table) pluginSource {
+	return pluginSource{File: c.file, Line: tbl.Line}
+}
+
+// pluginError returns the error for a plugin that failed to load.  While
+// checking the configuration the error is recorded and nil is returned, so
+// that loading continues with the next plugin.
+func (c *Config) pluginError(path string, plugin string, tbl *ast.Table, err error) error {
+	if !c.checking {
+		return fmt.Errorf("Error parsing %s, %s", path, err)
+	}
+
+	c.checkErrors = append(c.checkErrors, &PluginError{
+		File:   path,
+		Line:   tbl.Line,
+		Plugin: plugin,
+		Err:    err,
+	})
+	return nil
+}
+
+// Check loads the configuration file and, if not empty, the configuration
+// directory like LoadConfig and LoadDirectory.  Every plugin is built, its
+// secrets are resolved and the Init function of plugins implementing
+// telegraf.Initializer is called, but no plugin is started.
+//
+// Instead of stopping at the first error, all problems found are returned.
+func (c *Config) Check(path string, directory string) []error {
+	c.checking = true
+	c.checkErrors = nil
+	defer func() {
+		c.checking = false
+	}()
+
+	err := c.LoadConfig(path)
+	if err != nil {
+		c.checkErrors = append(c.checkErrors, err)
+	}
+	if directory != "" {
+		err = c.LoadDirectory(directory)
+		if err != nil {
+			c.checkErrors = append(c.checkErrors, err)
+		}
+	}
+
+	for _, input := range c.Inputs {
+		c.checkInit(input, input.Name(), input.Input)
+	}
+	for _, processor := range c.Processors {
+		c.checkInit(processor, "processors."+processor.Config.Name, processor.Processor)
+	}
+	for _, aggregator := range c.Aggregators {
+		c.checkInit(aggregator, aggregator.Name(), aggregator.Aggregator)
+	}
+	for _, output := range c.Outputs {
+		c.checkInit(output, "outputs."+output.Config.Name, output.Output)
+	}
+
+	return c.checkErrors
+}
+
+// checkInit resolves the secrets of the plugin and calls its Init function,
+// recording any error.
+func (c *Config) checkInit(running interface{}, name string, plugin interface{}) {
+	source := c.sources[running]
+	err := resolveSecrets(c.secretStores, plugin)
+	if err != nil {
+		c.checkErrors = append(c.checkErrors, &PluginError{
+			File:   source.File,
+			Line:   source.Line,
+			Plugin: name,
+			Err:    fmt.Errorf("could not resolve secrets: %v", err),
+		})
+		return
+	}
+
+	p, ok := plugin.(telegraf.Initializer)
+	if !ok {
+		return
+	}
+
+	err = p.Init()
+	if err != nil {
+		c.checkErrors = append(c.checkErrors, &PluginError{
+			File:   source.File,
+			Line:   source.Line,
+			Plugin: name,
+			Err:    fmt.Errorf("could not initialize: %v", err),
+		})
+	}
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/stretchr/testify/require"
+)
+
+type checkInitInput struct{}
+
+func (i *checkInitInput) SampleConfig() string                  { return "" }
+func (i *checkInitInput) Description() string                   { return "" }
+func (i *checkInitInput) Gather(acc telegraf.Accumulator) error { return nil }
+func (i *checkInitInput) Init() error                           { return errors.New("invalid settings") }
+
+func init() {
+	inputs.Add("check_init", func() telegraf.Input { return &checkInitInput{} })
+}
+
+func TestConfig_Check(t *testing.T) {
+	c := NewConfig()
+	errs := c.Check("./testdata/check_invalid.toml", "")
+
+	var messages []string
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	require.ElementsMatch(t, []string{
+		"./testdata/check_invalid.toml:4: inputs.http_listener_v2: line 5: field corresponding to `not_a_field' is not defined in http_listener_v2.HTTPListenerV2",
+		"./testdata/check_invalid.toml:7: inputs.not_a_plugin: Undefined but requested input: not_a_plugin",
+		"./testdata/check_invalid.toml:9: inputs.check_init: could not initialize: invalid settings",
+	}, messages)
+
+	require.Len(t, c.Inputs, 2)
+	require.Len(t, c.Outputs, 1)
+}
+
+func TestConfig_CheckValid(t *testing.T) {
+	c := NewConfig()
+	errs := c.Check("./testdata/single_plugin.toml", "./testdata/subconfig")
+	require.Empty(t, errs)
+	require.NotEmpty(t, c.Inputs)
+}
@@ -66,6 +66,30 @@ type Config struct {
 	Aggregators []*models.RunningAggregator
 	// Processors have a slice wrapper type because they need to be sorted
 	Processors models.RunningProcessors
+
+	// Local files and directories the configuration was loaded from.
+	Files       []string
+	Directories []string
+
+	// pluginIDs maps each running plugin to the ID of its configuration,
+	// used to compare plugins across reloads.
+	pluginIDs map[interface{}]string
+
+	// sources maps each running plugin to the location of its table.
+	sources map[interface{}]pluginSource
+
+	// secretStores maps the ID of each secret store to the store, and
+	// secretStoreIDs holds the hash of each secret store table.
+	secretStores   map[string]SecretStore
+	secretStoreIDs []string
+
+	// file is the path of the file being loaded.
+	file string
+
+	// checking is set by Check to collect all errors in checkErrors instead
+	// of failing on the first one.
+	checking    bool
+	checkErrors []error
 }
 
 func NewConfig() *Config {
@@ -84,6 +108,9 @@ func NewConfig() *Config {
 		Processors:    make([]*models.RunningProcessor, 0),
 		InputFilters:  make([]string, 0),
 		OutputFilters: make([]string, 0),
+		pluginIDs:     make(map[interface{}]string),
+		sources:       make(map[interface{}]pluginSource),
+		secretStores:  make(map[string]SecretStore),
 	}
 	return c
 }
@@ -163,6 +190,14 @@ type AgentConfig struct {
 
 	Hostname     string
 	OmitHostname bool
+
+	// Address of the HTTP API for inspecting and controlling the agent, the
+	// empty string disables the API.
+	HTTPAPIAddress string `toml:"http_api_address"`
+
+	// StrictConfig fails loading plugins with configuration keys that are
+	// not used, instead of only failing on the first unknown key.
+	StrictConfig bool `toml:"strict_config"`
 }
 
 // Inputs returns a list of strings of the configured inputs.
@@ -305,6 +340,16 @@ var agentConfig = `
   ## If set to true, do no set the "host" tag in the telegraf agent.
   omit_hostname = false
 
+  ## Address of the HTTP API used to inspect the running plugins and trigger
+  ## gathers and flushes, the empty string disables the API.  The API is not
+  ## authenticated and should only listen on a local address.
+  # http_api_address = "localhost:8183"
+
+  ## Fail to load plugins with options that are not used, such as misspelled
+  ## options or options of another data_format, listing all of them with
+  ## their line numbers.
+  # strict_config = false
+
 `
 
 var outputHeader = `
@@ -603,6 +648,8 @@ func PrintOutputConfig(name string) error {
 }
 
 func (c *Config) LoadDirectory(path string) error {
+	c.Directories = append(c.Directories, path)
+
 	walkfn := func(thispath string, info os.FileInfo, _ error) error {
 		if info == nil {
 			log.Printf("W! Telegraf is not permitted to read %s", thispath)
@@ -623,6 +670,10 @@ func (c *Config) LoadDirectory(path string) error {
 		}
 		err := c.LoadConfig(thispath)
 		if err != nil {
+			if c.checking {
+				c.checkErrors = append(c.checkErrors, err)
+				return nil
+			}
 			return err
 		}
 		return nil
@@ -666,6 +717,10 @@ func (c *Config) LoadConfig(path string) error {
 	if err != nil {
 		return fmt.Errorf("Error loading %s, %s", path, err)
 	}
+	if !isURL(path) {
+		c.Files = append(c.Files, path)
+	}
+	c.file = path
 
 	tbl, err := parseConfig(data)
 	if err != nil {
@@ -720,18 +775,38 @@ func (c *Config) LoadConfig(path string) error {
 
 		switch name {
 		case "agent", "global_tags", "tags":
+		case "secretstores":
+			for pluginName, pluginVal := range subTable.Fields {
+				switch pluginSubTable := pluginVal.(type) {
+				case []*ast.Table:
+					for _, t := range pluginSubTable {
+						if err = c.addSecretStore(pluginName, t); err != nil {
+							if err = c.pluginError(path, "secretstores."+pluginName, t, err); err != nil {
+								return err
+							}
+						}
+					}
+				default:
+					return fmt.Errorf("Unsupported config format: %s, file %s",
+						pluginName, path)
+				}
+			}
 		case "outputs":
 			for pluginName, pluginVal := range subTable.Fields {
 				switch pluginSubTable := pluginVal.(type) {
 				// legacy [outputs.influxdb] support
 				case *ast.Table:
 					if err = c.addOutput(pluginName, pluginSubTable); err != nil {
-						return fmt.Errorf("Error parsing %s, %s", path, err)
+						if err = c.pluginError(path, "outputs."+pluginName, pluginSubTable, err); err != nil {
+							return err
+						}
 					}
 				case []*ast.Table:
 					for _, t := range pluginSubTable {
 						if err = c.addOutput(pluginName, t); err != nil {
-							return fmt.Errorf("Error parsing %s, %s", path, err)
+							if err = c.pluginError(path, "outputs."+pluginName, t, err); err != nil {
+								return err
+							}
 						}
 					}
 				default:
@@ -745,12 +820,16 @@ func (c *Config) LoadConfig(path string) error {
 				// legacy [inputs.cpu] support
 				case *ast.Table:
 					if err = c.addInput(pluginName, pluginSubTable); err != nil {
-						return fmt.Errorf("Error parsing %s, %s", path, err)
+						if err = c.pluginError(path, "inputs."+pluginName, pluginSubTable, err); err != nil {
+							return err
+						}
 					}
 				case []*ast.Table:
 					for _, t := range pluginSubTable {
 						if err = c.addInput(pluginName, t); err != nil {
-							return fmt.Errorf("Error parsing %s, %s", path, err)
+							if err = c.pluginError(path, "inputs."+pluginName, t, err); err != nil {
+								return err
+							}
 						}
 					}
 				default:
@@ -764,7 +843,9 @@ func (c *Config) LoadConfig(path string) error {
 				case []*ast.Table:
 					for _, t := range pluginSubTable {
 						if err = c.addProcessor(pluginName, t); err != nil {
-							return fmt.Errorf("Error parsing %s, %s", path, err)
+							if err = c.pluginError(path, "processors."+pluginName, t, err); err != nil {
+								return err
+							}
 						}
 					}
 				default:
@@ -778,7 +859,9 @@ func (c *Config) LoadConfig(path string) error {
 				case []*ast.Table:
 					for _, t := range pluginSubTable {
 						if err = c.addAggregator(pluginName, t); err != nil {
-							return fmt.Errorf("Error parsing %s, %s", path, err)
+							if err = c.pluginError(path, "aggregators."+pluginName, t, err); err != nil {
+								return err
+							}
 						}
 					}
 				default:
@@ -790,7 +873,9 @@ func (c *Config) LoadConfig(path string) error {
 		// identifiers are present
 		default:
 			if err = c.addInput(name, subTable); err != nil {
-				return fmt.Errorf("Error parsing %s, %s", path, err)
+				if err = c.pluginError(path, "inputs."+name, subTable, err); err != nil {
+					return err
+				}
 			}
 		}
 	}
@@ -830,6 +915,13 @@ func loadConfig(config string) ([]byte, error) {
 
 }
 
+// isURL returns true if the config is fetched over http instead of read from
+// a file.
+func isURL(config string) bool {
+	u, err := url.Parse(config)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https")
+}
+
 func fetchConfig(u *url.URL) ([]byte, error) {
 	v := os.Getenv("INFLUX_TOKEN")
 
@@ -889,17 +981,26 @@ func (c *Config) addAggregator(name string, table *ast.Table) error {
 		return fmt.Errorf("Undefined but requested aggregator: %s", name)
 	}
 	aggregator := creator()
+	id := pluginID("aggregators", name, table)
+
+	var keys *tableKeys
+	if c.Agent.StrictConfig {
+		keys = newTableKeys(table)
+	}
 
 	conf, err := buildAggregator(name, table)
 	if err != nil {
 		return err
 	}
 
-	if err := toml.UnmarshalTable(table, aggregator); err != nil {
+	if err := unmarshalTable(table, aggregator, keys); err != nil {
 		return err
 	}
 
-	c.Aggregators = append(c.Aggregators, models.NewRunningAggregator(aggregator, conf))
+	ra := models.NewRunningAggregator(aggregator, conf)
+	c.pluginIDs[ra] = id
+	c.sources[ra] = c.source(table)
+	c.Aggregators = append(c.Aggregators, ra)
 	return nil
 }
 
@@ -909,13 +1010,48 @@ func (c *Config) addProcessor(name string, table *ast.Table) error {
 		return fmt.Errorf("Undefined but requested processor: %s", name)
 	}
 	processor := creator()
+	id := pluginID("processors", name, table)
+
+	var keys *tableKeys
+	if c.Agent.StrictConfig {
+		keys = newTableKeys(table)
+	}
+
+	// Processors exchanging metrics with an external program serialize and
+	// parse them with the same data format.
+	if t, ok := processor.(serializers.SerializerOutput); ok {
+		dataFormat, hasFormat := table.Fields["data_format"]
+		serializer, err := buildSerializer(name, table)
+		if err != nil {
+			return err
+		}
+		t.SetSerializer(serializer)
+
+		if _, ok := processor.(parsers.ParserInput); ok && hasFormat {
+			table.Fields["data_format"] = dataFormat
+		}
+		if keys != nil {
+			keys.checkFormat(serializerFormatKeys, "influx")
+		}
+	}
+	if t, ok := processor.(parsers.ParserInput); ok {
+		parser, err := buildParser(name, table)
+		if err != nil {
+			return err
+		}
+		t.SetParser(parser)
+
+		if keys != nil {
+			keys.checkFormat(parserFormatKeys, "influx")
+		}
+	}
 
 	processorConfig, err := buildProcessor(name, table)
 	if err != nil {
 		return err
 	}
 
-	if err := toml.UnmarshalTable(table, processor); err != nil {
+	if err := unmarshalTable(table, processor, keys); err != nil {
 		return err
 	}
 
@@ -925,6 +1061,8 @@ func (c *Config) addProcessor(name string, table *ast.Table) error {
 		Config:    processorConfig,
 	}
 
+	c.pluginIDs[rf] = id
+	c.sources[rf] = c.source(table)
 	c.Processors = append(c.Processors, rf)
 	return nil
 }
@@ -938,6 +1076,12 @@ func (c *Config) addOutput(name string, table *ast.Table) error {
 		return fmt.Errorf("Undefined but requested output: %s", name)
 	}
 	output := creator()
+	id := pluginID("outputs", name, table)
+
+	var keys *tableKeys
+	if c.Agent.StrictConfig {
+		keys = newTableKeys(table)
+	}
 
 	// If the output has a SetSerializer function, then this means it can write
 	// arbitrary types of output, so build the serializer and set it.
@@ -948,6 +1092,10 @@ func (c *Config) addOutput(name string, table *ast.Table) error {
 			return err
 		}
 		t.SetSerializer(serializer)
+
+		if keys != nil {
+			keys.checkFormat(serializerFormatKeys, "influx")
+		}
 	}
 
 	outputConfig, err := buildOutput(name, table)
@@ -955,12 +1103,24 @@ func (c *Config) addOutput(name string, table *ast.Table) error {
 		return err
 	}
 
-	if err := toml.UnmarshalTable(table, output); err != nil {
+	if outputConfig.BufferStrategy == models.BufferStrategyDisk {
+		for _, ro := range c.Outputs {
+			if ro.Config.BufferStrategy == models.BufferStrategyDisk &&
+				filepath.Clean(ro.Config.BufferDirectory) == filepath.Clean(outputConfig.BufferDirectory) {
+				return fmt.Errorf("buffer_directory %q is used by multiple outputs",
+					outputConfig.BufferDirectory)
+			}
+		}
+	}
+
+	if err := unmarshalTable(table, output, keys); err != nil {
 		return err
 	}
 
 	ro := models.NewRunningOutput(name, output, outputConfig,
 		c.Agent.MetricBatchSize, c.Agent.MetricBufferLimit)
+	c.pluginIDs[ro] = id
+	c.sources[ro] = c.source(table)
 	c.Outputs = append(c.Outputs, ro)
 	return nil
 }
@@ -979,6 +1139,12 @@ func (c *Config) addInput(name string, table *ast.Table) error {
 		return fmt.Errorf("Undefined but requested input: %s", name)
 	}
 	input := creator()
+	id := pluginID("inputs", name, table)
+
+	var keys *tableKeys
+	if c.Agent.StrictConfig {
+		keys = newTableKeys(table)
+	}
 
 	// If the input has a SetParser function, then this means it can accept
 	// arbitrary types of input, so build the parser and set it.
@@ -1002,17 +1168,31 @@ func (c *Config) addInput(name string, table *ast.Table) error {
 		})
 	}
 
+	if keys != nil {
+		switch input.(type) {
+		case parsers.ParserInput, parsers.ParserFuncInput:
+			// Legacy support, exec plugin originally parsed JSON by default.
+			if name == "exec" {
+				keys.checkFormat(parserFormatKeys, "json")
+			} else {
+				keys.checkFormat(parserFormatKeys, "influx")
+			}
+		}
+	}
+
 	pluginConfig, err := buildInput(name, table)
 	if err != nil {
 		return err
 	}
 
-	if err := toml.UnmarshalTable(table, input); err != nil {
+	if err := unmarshalTable(table, input, keys); err != nil {
 		return err
 	}
 
 	rp := models.NewRunningInput(input, pluginConfig)
 	rp.SetDefaultTags(c.Tags)
+	c.pluginIDs[rp] = id
+	c.sources[rp] = c.source(table)
 	c.Inputs = append(c.Inputs, rp)
 	return nil
 }
@@ -1105,6 +1285,7 @@ func buildAggregator(name string, tbl *ast.Table) (*models.AggregatorConfig, err
 	delete(tbl.Fields, "name_suffix")
 	delete(tbl.Fields, "name_override")
 	delete(tbl.Fields, "tags")
+	conf.Pipeline = buildPipeline(tbl)
 	var err error
 	conf.Filter, err = buildFilter(tbl)
 	if err != nil {
@@ -1132,6 +1313,7 @@ func buildProcessor(name string, tbl *ast.Table) (*models.ProcessorConfig, error
 	}
 
 	delete(tbl.Fields, "order")
+	conf.Pipeline = buildPipeline(tbl)
 	var err error
 	conf.Filter, err = buildFilter(tbl)
 	if err != nil {
@@ -1277,6 +1459,25 @@ func buildFilter(tbl *ast.Table) (models.Filter, error) {
 	return f, nil
 }
 
+// buildPipeline returns the pipeline of a plugin, the empty string for the
+// default pipeline.
+func buildPipeline(tbl *ast.Table) string {
+	var pipeline string
+	if node, ok := tbl.Fields["pipeline"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				pipeline = str.Value
+			}
+		}
+	}
+
+	delete(tbl.Fields, "pipeline")
+	if pipeline == models.DefaultPipeline {
+		return ""
+	}
+	return pipeline
+}
+
 // buildInput parses input specific items from the ast.Table,
 // builds the filter and returns a
 // models.InputConfig to be inserted into models.RunningInput
@@ -1328,11 +1529,60 @@ func buildInput(name string, tbl *ast.Table) (*models.InputConfig, error) {
 		}
 	}
 
+	for key, limit := range map[string]*int{
+		"metric_limit": &cp.Limit.MetricLimit,
+		"series_limit": &cp.Limit.SeriesLimit,
+	} {
+		if node, ok := tbl.Fields[key]; ok {
+			if kv, ok := node.(*ast.KeyValue); ok {
+				if integer, ok := kv.Value.(*ast.Integer); ok {
+					v, err := integer.Int()
+					if err != nil {
+						return nil, err
+					}
+					*limit = int(v)
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["series_window"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				dur, err := time.ParseDuration(str.Value)
+				if err != nil {
+					return nil, err
+				}
+
+				cp.Limit.SeriesWindow = dur
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["series_limit_action"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				cp.Limit.SeriesLimitAction = str.Value
+			}
+		}
+	}
+
+	switch cp.Limit.SeriesLimitAction {
+	case "", models.SeriesLimitDrop, models.SeriesLimitAggregate:
+	default:
+		return nil, fmt.Errorf("invalid series_limit_action %q", cp.Limit.SeriesLimitAction)
+	}
+
 	delete(tbl.Fields, "name_prefix")
 	delete(tbl.Fields, "name_suffix")
 	delete(tbl.Fields, "name_override")
 	delete(tbl.Fields, "interval")
 	delete(tbl.Fields, "tags")
+	delete(tbl.Fields, "metric_limit")
+	delete(tbl.Fields, "series_limit")
+	delete(tbl.Fields, "series_window")
+	delete(tbl.Fields, "series_limit_action")
+	cp.Pipeline = buildPipeline(tbl)
 	var err error
 	cp.Filter, err = buildFilter(tbl)
 	if err != nil {
@@ -1942,8 +2192,9 @@ func buildOutput(name string, tbl *ast.Table) (*models.OutputConfig, error) {
 		return nil, err
 	}
 	oc := &models.OutputConfig{
-		Name:   name,
-		Filter: filter,
+		Name:     name,
+		Filter:   filter,
+		Pipeline: buildPipeline(tbl),
 	}
 
 	// TODO
@@ -1992,9 +2243,72 @@ func buildOutput(name string, tbl *ast.Table) (*models.OutputConfig, error) {
 		}
 	}
 
+	if node, ok := tbl.Fields["buffer_strategy"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				oc.BufferStrategy = str.Value
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["buffer_directory"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				oc.BufferDirectory = str.Value
+			}
+		}
+	}
+
+	for key, dur := range map[string]*time.Duration{
+		"retry_initial_backoff": &oc.Retry.InitialBackoff,
+		"retry_max_backoff":     &oc.Retry.MaxBackoff,
+		"retry_jitter":          &oc.Retry.Jitter,
+	} {
+		if node, ok := tbl.Fields[key]; ok {
+			if kv, ok := node.(*ast.KeyValue); ok {
+				if str, ok := kv.Value.(*ast.String); ok {
+					d, err := time.ParseDuration(str.Value)
+					if err != nil {
+						return nil, err
+					}
+					*dur = d
+				}
+			}
+		}
+	}
+
+	if node, ok := tbl.Fields["retry_max_failures"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if integer, ok := kv.Value.(*ast.Integer); ok {
+				v, err := integer.Int()
+				if err != nil {
+					return nil, err
+				}
+				oc.Retry.MaxFailures = int(v)
+			}
+		}
+	}
+
+	switch oc.BufferStrategy {
+	case "", models.BufferStrategyMemory:
+	case models.BufferStrategyDisk:
+		if oc.BufferDirectory == "" {
+			return nil, fmt.Errorf("buffer_directory is required when using the %q buffer_strategy",
+				oc.BufferStrategy)
+		}
+	default:
+		return nil, fmt.Errorf("invalid buffer_strategy %q", oc.BufferStrategy)
+	}
+
 	delete(tbl.Fields, "flush_interval")
 	delete(tbl.Fields, "metric_buffer_limit")
 	delete(tbl.Fields, "metric_batch_size")
+	delete(tbl.Fields, "buffer_strategy")
+	delete(tbl.Fields, "buffer_directory")
+	delete(tbl.Fields, "retry_initial_backoff")
+	delete(tbl.Fields, "retry_max_backoff")
+	delete(tbl.Fields, "retry_jitter")
+	delete(tbl.Fields, "retry_max_failures")
 
 	return oc, nil
 }
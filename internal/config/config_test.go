@@ -14,6 +14,7 @@ import (
 	"github.com/influxdata/telegraf/plugins/inputs/procstat"
 	httpOut "github.com/influxdata/telegraf/plugins/outputs/http"
 	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/processors/execd"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -251,3 +252,35 @@ func TestConfig_BadOrdering(t *testing.T) {
 	require.Error(t, err, "bad ordering")
 	assert.Equal(t, "Error parsing ./testdata/non_slice_slice.toml, line 4: cannot unmarshal TOML array into string (need slice)", err.Error())
 }
+
+func TestConfig_InputLimits(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/input_limits.toml")
+	require.NoError(t, err)
+	require.Len(t, c.Inputs, 1)
+
+	require.Equal(t, models.LimitConfig{
+		MetricLimit:       1000,
+		SeriesLimit:       100,
+		SeriesWindow:      10 * time.Minute,
+		SeriesLimitAction: models.SeriesLimitAggregate,
+	}, c.Inputs[0].Config.Limit)
+}
+
+func TestConfig_InputLimitsInvalidAction(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/input_limits_invalid.toml")
+	require.EqualError(t, err,
+		`Error parsing ./testdata/input_limits_invalid.toml, invalid series_limit_action "sample"`)
+}
+
+func TestConfig_ProcessorParserSerializer(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/processor_execd.toml")
+	require.NoError(t, err)
+
+	require.Len(t, c.Processors, 1)
+	p, ok := c.Processors[0].Processor.(*execd.Execd)
+	require.True(t, ok)
+	require.Equal(t, []string{"cat"}, p.Command)
+}
@@ -0,0 +1,280 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"reflect"
+	"sort"
+
+	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/toml/ast"
+)
+
+// ConfigDiff describes the changes between two configurations.
+//
+// A plugin whose configuration was modified is reported as removed and added.
+// Plugins that did not change keep their running instance from the old
+// configuration.
+type ConfigDiff struct {
+	// Restart is true when settings shared by all plugins, such as the agent
+	// table or the global tags, have changed.  The changes can only be
+	// applied by restarting the agent.
+	Restart bool
+
+	AddedInputs   []*models.RunningInput
+	RemovedInputs []*models.RunningInput
+
+	AddedOutputs   []*models.RunningOutput
+	RemovedOutputs []*models.RunningOutput
+
+	AddedAggregators   []*models.RunningAggregator
+	RemovedAggregators []*models.RunningAggregator
+
+	AddedProcessors   []*models.RunningProcessor
+	RemovedProcessors []*models.RunningProcessor
+
+	// The complete plugin lists of the new configuration, using the old
+	// instance of unchanged plugins.
+	Inputs      []*models.RunningInput
+	Outputs     []*models.RunningOutput
+	Aggregators []*models.RunningAggregator
+	Processors  models.RunningProcessors
+
+	pluginIDs      map[interface{}]string
+	secretStores   map[string]SecretStore
+	secretStoreIDs []string
+}
+
+// Empty returns true if there are no differences.
+func (d *ConfigDiff) Empty() bool {
+	return !d.Restart &&
+		len(d.AddedInputs) == 0 && len(d.RemovedInputs) == 0 &&
+		len(d.AddedOutputs) == 0 && len(d.RemovedOutputs) == 0 &&
+		len(d.AddedAggregators) == 0 && len(d.RemovedAggregators) == 0 &&
+		len(d.AddedProcessors) == 0 && len(d.RemovedProcessors) == 0
+}
+
+// ResolveSecrets replaces the secret references in the settings of the added
+// plugins with the secrets from the secret stores of the new configuration.
+func (d *ConfigDiff) ResolveSecrets() error {
+	return resolvePluginSecrets(d.secretStores,
+		d.AddedInputs, d.AddedProcessors, d.AddedAggregators, d.AddedOutputs)
+}
+
+// Diff computes the changes required to go from the plugins in c to the
+// plugins in newer.  Plugins are matched using the ID computed from their
+// configuration table.
+//
+// Changes of the secret stores require a restart, as the secrets of running
+// plugins are only resolved when they are initialized.  So does adding or
+// removing a pipeline.
+func (c *Config) Diff(newer *Config) *ConfigDiff {
+	d := &ConfigDiff{
+		Restart: !reflect.DeepEqual(c.Agent, newer.Agent) ||
+			!reflect.DeepEqual(c.Tags, newer.Tags) ||
+			!sameStrings(c.secretStoreIDs, newer.secretStoreIDs) ||
+			!sameStrings(c.Pipelines(), newer.Pipelines()),
+		pluginIDs:      make(map[interface{}]string),
+		secretStores:   newer.secretStores,
+		secretStoreIDs: newer.secretStoreIDs,
+	}
+
+	oldInputs := make(map[string][]*models.RunningInput)
+	for _, input := range c.Inputs {
+		id := c.pluginIDs[input]
+		oldInputs[id] = append(oldInputs[id], input)
+	}
+	for _, input := range newer.Inputs {
+		id := newer.pluginIDs[input]
+		if kept := oldInputs[id]; len(kept) > 0 {
+			oldInputs[id] = kept[1:]
+			d.Inputs = append(d.Inputs, kept[0])
+			d.pluginIDs[kept[0]] = id
+			continue
+		}
+		d.AddedInputs = append(d.AddedInputs, input)
+		d.Inputs = append(d.Inputs, input)
+		d.pluginIDs[input] = id
+	}
+	for _, input := range c.Inputs {
+		if containsInput(oldInputs[c.pluginIDs[input]], input) {
+			d.RemovedInputs = append(d.RemovedInputs, input)
+		}
+	}
+
+	oldOutputs := make(map[string][]*models.RunningOutput)
+	for _, output := range c.Outputs {
+		id := c.pluginIDs[output]
+		oldOutputs[id] = append(oldOutputs[id], output)
+	}
+	for _, output := range newer.Outputs {
+		id := newer.pluginIDs[output]
+		if kept := oldOutputs[id]; len(kept) > 0 {
+			oldOutputs[id] = kept[1:]
+			d.Outputs = append(d.Outputs, kept[0])
+			d.pluginIDs[kept[0]] = id
+			continue
+		}
+		d.AddedOutputs = append(d.AddedOutputs, output)
+		d.Outputs = append(d.Outputs, output)
+		d.pluginIDs[output] = id
+	}
+	for _, output := range c.Outputs {
+		if containsOutput(oldOutputs[c.pluginIDs[output]], output) {
+			d.RemovedOutputs = append(d.RemovedOutputs, output)
+		}
+	}
+
+	oldAggregators := make(map[string][]*models.RunningAggregator)
+	for _, agg := range c.Aggregators {
+		id := c.pluginIDs[agg]
+		oldAggregators[id] = append(oldAggregators[id], agg)
+	}
+	for _, agg := range newer.Aggregators {
+		id := newer.pluginIDs[agg]
+		if kept := oldAggregators[id]; len(kept) > 0 {
+			oldAggregators[id] = kept[1:]
+			d.Aggregators = append(d.Aggregators, kept[0])
+			d.pluginIDs[kept[0]] = id
+			continue
+		}
+		d.AddedAggregators = append(d.AddedAggregators, agg)
+		d.Aggregators = append(d.Aggregators, agg)
+		d.pluginIDs[agg] = id
+	}
+	for _, agg := range c.Aggregators {
+		if containsAggregator(oldAggregators[c.pluginIDs[agg]], agg) {
+			d.RemovedAggregators = append(d.RemovedAggregators, agg)
+		}
+	}
+
+	oldProcessors := make(map[string][]*models.RunningProcessor)
+	for _, proc := range c.Processors {
+		id := c.pluginIDs[proc]
+		oldProcessors[id] = append(oldProcessors[id], proc)
+	}
+	for _, proc := range newer.Processors {
+		id := newer.pluginIDs[proc]
+		if kept := oldProcessors[id]; len(kept) > 0 {
+			oldProcessors[id] = kept[1:]
+			d.Processors = append(d.Processors, kept[0])
+			d.pluginIDs[kept[0]] = id
+			continue
+		}
+		d.AddedProcessors = append(d.AddedProcessors, proc)
+		d.Processors = append(d.Processors, proc)
+		d.pluginIDs[proc] = id
+	}
+	for _, proc := range c.Processors {
+		if containsProcessor(oldProcessors[c.pluginIDs[proc]], proc) {
+			d.RemovedProcessors = append(d.RemovedProcessors, proc)
+		}
+	}
+	sort.Stable(d.Processors)
+
+	return d
+}
+
+// Apply replaces the plugins of c with the plugin lists of the diff, so that
+// c can be compared against the next configuration.
+func (c *Config) Apply(d *ConfigDiff) {
+	c.Inputs = d.Inputs
+	c.Outputs = d.Outputs
+	c.Aggregators = d.Aggregators
+	c.Processors = d.Processors
+	c.pluginIDs = d.pluginIDs
+	c.secretStores = d.secretStores
+	c.secretStoreIDs = d.secretStoreIDs
+}
+
+// sameStrings returns true if both lists contain the same strings, in any
+// order.
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a = append([]string(nil), a...)
+	b = append([]string(nil), b...)
+	sort.Strings(a)
+	sort.Strings(b)
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func containsInput(inputs []*models.RunningInput, input *models.RunningInput) bool {
+	for _, i := range inputs {
+		if i == input {
+			return true
+		}
+	}
+	return false
+}
+
+func containsOutput(outputs []*models.RunningOutput, output *models.RunningOutput) bool {
+	for _, o := range outputs {
+		if o == output {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAggregator(aggs []*models.RunningAggregator, agg *models.RunningAggregator) bool {
+	for _, a := range aggs {
+		if a == agg {
+			return true
+		}
+	}
+	return false
+}
+
+func containsProcessor(procs []*models.RunningProcessor, proc *models.RunningProcessor) bool {
+	for _, p := range procs {
+		if p == proc {
+			return true
+		}
+	}
+	return false
+}
+
+// pluginID returns an identifier for a plugin built from its type, name and
+// the contents of its configuration table.  It must be computed before the
+// table is consumed by the build functions.
+func pluginID(kind, name string, tbl *ast.Table) string {
+	h := sha256.New()
+	io.WriteString(h, kind+"."+name)
+	writeTable(h, tbl)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// writeTable writes a canonical representation of the table to w.
+func writeTable(w io.Writer, tbl *ast.Table) {
+	keys := make([]string, 0, len(tbl.Fields))
+	for key := range tbl.Fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	io.WriteString(w, "{")
+	for _, key := range keys {
+		io.WriteString(w, key)
+		switch v := tbl.Fields[key].(type) {
+		case *ast.KeyValue:
+			io.WriteString(w, "="+v.Value.Source()+"\n")
+		case *ast.Table:
+			writeTable(w, v)
+		case []*ast.Table:
+			io.WriteString(w, "[")
+			for _, t := range v {
+				writeTable(w, t)
+			}
+			io.WriteString(w, "]")
+		}
+	}
+	io.WriteString(w, "}")
+}
@@ -0,0 +1,95 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/plugins/inputs/memcached"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_DiffUnchanged(t *testing.T) {
+	c := NewConfig()
+	require.NoError(t, c.LoadConfig("./testdata/reload_old.toml"))
+	newer := NewConfig()
+	require.NoError(t, newer.LoadConfig("./testdata/reload_old.toml"))
+
+	diff := c.Diff(newer)
+	require.True(t, diff.Empty())
+	require.ElementsMatch(t, c.Inputs, diff.Inputs)
+	require.ElementsMatch(t, c.Outputs, diff.Outputs)
+}
+
+func TestConfig_DiffPlugins(t *testing.T) {
+	c := NewConfig()
+	require.NoError(t, c.LoadConfig("./testdata/reload_old.toml"))
+	newer := NewConfig()
+	require.NoError(t, newer.LoadConfig("./testdata/reload_new.toml"))
+
+	diff := c.Diff(newer)
+	require.False(t, diff.Empty())
+	require.False(t, diff.Restart)
+
+	// The changed memcached input and the exec input are removed.
+	require.Len(t, diff.RemovedInputs, 2)
+	require.ElementsMatch(t, []string{"inputs.exec", "192.168.1.1"},
+		inputServers(diff.RemovedInputs))
+
+	// The changed memcached input and the second localhost input are added.
+	require.Len(t, diff.AddedInputs, 2)
+	require.ElementsMatch(t, []string{"localhost", "192.168.1.2"},
+		inputServers(diff.AddedInputs))
+
+	// The unchanged input keeps its running instance.
+	require.Len(t, diff.Inputs, 3)
+	for _, input := range c.Inputs {
+		if inputServers([]*models.RunningInput{input})[0] == "localhost" {
+			require.Contains(t, diff.Inputs, input)
+		}
+	}
+
+	require.Empty(t, diff.AddedOutputs)
+	require.Empty(t, diff.RemovedOutputs)
+	require.Equal(t, c.Outputs, diff.Outputs)
+}
+
+func TestConfig_DiffApply(t *testing.T) {
+	c := NewConfig()
+	require.NoError(t, c.LoadConfig("./testdata/reload_old.toml"))
+	newer := NewConfig()
+	require.NoError(t, newer.LoadConfig("./testdata/reload_new.toml"))
+
+	c.Apply(c.Diff(newer))
+	require.Len(t, c.Inputs, 3)
+
+	again := NewConfig()
+	require.NoError(t, again.LoadConfig("./testdata/reload_new.toml"))
+	require.True(t, c.Diff(again).Empty())
+}
+
+func TestConfig_DiffAgentRestart(t *testing.T) {
+	c := NewConfig()
+	require.NoError(t, c.LoadConfig("./testdata/reload_old.toml"))
+	newer := NewConfig()
+	require.NoError(t, newer.LoadConfig("./testdata/reload_old.toml"))
+	newer.Agent.Interval.Duration = time.Minute
+
+	diff := c.Diff(newer)
+	require.True(t, diff.Restart)
+	require.False(t, diff.Empty())
+}
+
+// inputServers returns the memcached server of each input, or the name of
+// inputs that are not memcached.
+func inputServers(inputs []*models.RunningInput) []string {
+	servers := make([]string, 0, len(inputs))
+	for _, input := range inputs {
+		if m, ok := input.Input.(*memcached.Memcached); ok {
+			servers = append(servers, m.Servers[0])
+		} else {
+			servers = append(servers, input.Name())
+		}
+	}
+	return servers
+}
@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/influxdata/telegraf/internal/models"
+)
+
+// Pipelines returns the sorted names of the pipelines used by the plugins,
+// the default pipeline is named by the empty string.
+func (c *Config) Pipelines() []string {
+	names := make(map[string]bool)
+	for _, input := range c.Inputs {
+		names[input.Config.Pipeline] = true
+	}
+	for _, processor := range c.Processors {
+		names[processor.Config.Pipeline] = true
+	}
+	for _, aggregator := range c.Aggregators {
+		names[aggregator.Config.Pipeline] = true
+	}
+	for _, output := range c.Outputs {
+		names[output.Config.Pipeline] = true
+	}
+
+	pipelines := make([]string, 0, len(names))
+	for name := range names {
+		pipelines = append(pipelines, name)
+	}
+	sort.Strings(pipelines)
+	return pipelines
+}
+
+// ValidatePipelines checks that every pipeline has inputs and outputs.  A
+// pipeline without either would silently discard metrics, usually because
+// of a misspelled pipeline name.
+func (c *Config) ValidatePipelines() error {
+	inputs := make(map[string]int)
+	for _, input := range c.Inputs {
+		inputs[input.Config.Pipeline]++
+	}
+	outputs := make(map[string]int)
+	for _, output := range c.Outputs {
+		outputs[output.Config.Pipeline]++
+	}
+
+	for _, pipeline := range c.Pipelines() {
+		if inputs[pipeline] == 0 {
+			return fmt.Errorf("pipeline %q has no inputs", models.PipelineName(pipeline))
+		}
+		if outputs[pipeline] == 0 {
+			return fmt.Errorf("pipeline %q has no outputs", models.PipelineName(pipeline))
+		}
+	}
+	return nil
+}
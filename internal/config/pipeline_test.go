@@ -0,0 +1,35 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Pipelines(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/pipelines.toml")
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"", "web"}, c.Pipelines())
+	require.NoError(t, c.ValidatePipelines())
+
+	var inputs, outputs []string
+	for _, input := range c.Inputs {
+		inputs = append(inputs, input.Config.Pipeline)
+	}
+	for _, output := range c.Outputs {
+		outputs = append(outputs, output.Config.Pipeline)
+	}
+	require.ElementsMatch(t, []string{"", "web"}, inputs)
+	require.ElementsMatch(t, []string{"", "web"}, outputs)
+}
+
+func TestConfig_PipelinesNoOutputs(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/pipelines_no_outputs.toml")
+	require.NoError(t, err)
+
+	err = c.ValidatePipelines()
+	require.EqualError(t, err, `pipeline "web" has no outputs`)
+}
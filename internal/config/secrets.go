@@ -0,0 +1,255 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+
+	"github.com/influxdata/telegraf/internal/models"
+	"github.com/influxdata/telegraf/logger"
+	"github.com/influxdata/toml/ast"
+)
+
+var (
+	// secretRefRe matches a secret reference of the form @{store:key},
+	// including a reference escaped as @@{store:key}.
+	secretRefRe = regexp.MustCompile(`(@?)@\{([\w-]+):([^}]+)\}`)
+
+	// secretStoreIDRe matches the valid IDs of secret stores.
+	secretStoreIDRe = regexp.MustCompile(`^[\w-]+$`)
+)
+
+// SecretStore provides the secrets referenced in plugin configurations as
+// @{<store id>:<key>}.
+type SecretStore interface {
+	// Get returns the secret stored under key.
+	Get(key string) (string, error)
+}
+
+// SecretSetter is implemented by secret stores that can store secrets.
+type SecretSetter interface {
+	// Set stores the secret under key, replacing any existing secret.
+	Set(key string, secret string) error
+}
+
+type SecretStoreCreator func() SecretStore
+
+// SecretStores are the available secret store types, by the name used in the
+// [[secretstores.<name>]] configuration tables.
+var SecretStores = map[string]SecretStoreCreator{}
+
+func AddSecretStore(name string, creator SecretStoreCreator) {
+	SecretStores[name] = creator
+}
+
+func (c *Config) addSecretStore(name string, table *ast.Table) error {
+	creator, ok := SecretStores[name]
+	if !ok {
+		return fmt.Errorf("Undefined but requested secret store: %s", name)
+	}
+	store := creator()
+	hash := pluginID("secretstores", name, table)
+
+	var keys *tableKeys
+	if c.Agent.StrictConfig {
+		keys = newTableKeys(table)
+	}
+
+	var id string
+	if node, ok := table.Fields["id"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok {
+				id = str.Value
+			}
+		}
+	}
+	delete(table.Fields, "id")
+
+	if !secretStoreIDRe.MatchString(id) {
+		return fmt.Errorf("invalid secret store id %q, it must only contain letters, digits, '_' and '-'", id)
+	}
+	if _, ok := c.secretStores[id]; ok {
+		return fmt.Errorf("secret store id %q is used by multiple secret stores", id)
+	}
+
+	if err := unmarshalTable(table, store, keys); err != nil {
+		return err
+	}
+
+	c.secretStores[id] = store
+	c.secretStoreIDs = append(c.secretStoreIDs, hash)
+	return nil
+}
+
+// SetSecret stores a secret in the secret store with the given ID.
+func (c *Config) SetSecret(id string, key string, secret string) error {
+	store, ok := c.secretStores[id]
+	if !ok {
+		return fmt.Errorf("unknown secret store %q", id)
+	}
+
+	setter, ok := store.(SecretSetter)
+	if !ok {
+		return fmt.Errorf("secret store %q does not support storing secrets", id)
+	}
+	return setter.Set(key, secret)
+}
+
+// ResolveSecrets replaces the secret references in the settings of all
+// plugins with the secrets from the secret stores.  Resolved secrets are
+// redacted from the log output.
+func (c *Config) ResolveSecrets() error {
+	return resolvePluginSecrets(c.secretStores,
+		c.Inputs, c.Processors, c.Aggregators, c.Outputs)
+}
+
+func resolvePluginSecrets(
+	stores map[string]SecretStore,
+	inputs []*models.RunningInput,
+	processors []*models.RunningProcessor,
+	aggregators []*models.RunningAggregator,
+	outputs []*models.RunningOutput,
+) error {
+	for _, input := range inputs {
+		if err := resolveSecrets(stores, input.Input); err != nil {
+			return fmt.Errorf("could not resolve secrets of input %s: %v",
+				input.Config.Name, err)
+		}
+	}
+	for _, processor := range processors {
+		if err := resolveSecrets(stores, processor.Processor); err != nil {
+			return fmt.Errorf("could not resolve secrets of processor %s: %v",
+				processor.Config.Name, err)
+		}
+	}
+	for _, aggregator := range aggregators {
+		if err := resolveSecrets(stores, aggregator.Aggregator); err != nil {
+			return fmt.Errorf("could not resolve secrets of aggregator %s: %v",
+				aggregator.Config.Name, err)
+		}
+	}
+	for _, output := range outputs {
+		if err := resolveSecrets(stores, output.Output); err != nil {
+			return fmt.Errorf("could not resolve secrets of output %s: %v",
+				output.Config.Name, err)
+		}
+	}
+	return nil
+}
+
+// resolveSecrets replaces the secret references in the exported string
+// settings of the plugin, including strings in slices, maps, interfaces and
+// nested structs.  Only references to the given stores are replaced, other
+// strings that look like a reference are kept as they are.
+func resolveSecrets(stores map[string]SecretStore, plugin interface{}) error {
+	if len(stores) == 0 {
+		return nil
+	}
+
+	r := &secretResolver{
+		stores:  stores,
+		visited: make(map[uintptr]bool),
+	}
+	return r.resolve(reflect.ValueOf(plugin))
+}
+
+type secretResolver struct {
+	stores  map[string]SecretStore
+	visited map[uintptr]bool
+}
+
+func (r *secretResolver) resolve(v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Kind() == reflect.Interface && v.Elem().Kind() == reflect.String {
+			// The string held by an interface cannot be set, the interface
+			// is set to the resolved string instead.
+			if !v.CanSet() {
+				return nil
+			}
+			s, err := r.resolveString(v.Elem().String())
+			if err != nil {
+				return err
+			}
+			v.Set(reflect.ValueOf(s).Convert(v.Elem().Type()))
+			return nil
+		}
+		if v.Kind() == reflect.Ptr {
+			if r.visited[v.Pointer()] {
+				return nil
+			}
+			r.visited[v.Pointer()] = true
+		}
+		return r.resolve(v.Elem())
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).PkgPath != "" {
+				continue
+			}
+			if err := r.resolve(v.Field(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := r.resolve(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		// Map values cannot be set, each value is resolved in a copy that
+		// replaces it.
+		for _, key := range v.MapKeys() {
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(key))
+			if err := r.resolve(elem); err != nil {
+				return err
+			}
+			v.SetMapIndex(key, elem)
+		}
+	case reflect.String:
+		if !v.CanSet() {
+			return nil
+		}
+		s, err := r.resolveString(v.String())
+		if err != nil {
+			return err
+		}
+		v.SetString(s)
+	}
+	return nil
+}
+
+// resolveString replaces all references to the secret stores in s.  An
+// escaped reference is replaced with the reference itself.
+func (r *secretResolver) resolveString(s string) (string, error) {
+	var err error
+	resolved := secretRefRe.ReplaceAllStringFunc(s, func(ref string) string {
+		if err != nil {
+			return ref
+		}
+
+		match := secretRefRe.FindStringSubmatch(ref)
+		escaped, id, key := match[1] != "", match[2], match[3]
+		store, ok := r.stores[id]
+		if !ok {
+			return ref
+		}
+		if escaped {
+			return ref[1:]
+		}
+
+		var secret string
+		secret, err = store.Get(key)
+		if err != nil {
+			err = fmt.Errorf("could not get secret %q from store %q: %v", key, id, err)
+			return ref
+		}
+		logger.RedactSecret(secret)
+		return secret
+	})
+	return resolved, err
+}
@@ -0,0 +1,46 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+)
+
+// execSecretStore gets secrets from the output of a command, which is run
+// with the key as last argument.
+type execSecretStore struct {
+	Command []string          `toml:"command"`
+	Timeout internal.Duration `toml:"timeout"`
+}
+
+func (s *execSecretStore) Get(key string) (string, error) {
+	if len(s.Command) == 0 {
+		return "", errors.New("no command configured")
+	}
+
+	args := append(append([]string(nil), s.Command[1:]...), key)
+	cmd := exec.Command(s.Command[0], args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := internal.RunTimeout(cmd, s.Timeout.Duration)
+	if err != nil {
+		return "", fmt.Errorf("%s failed: %v: %s", s.Command[0], err,
+			strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimRight(stdout.String(), "\r\n"), nil
+}
+
+func init() {
+	AddSecretStore("exec", func() SecretStore {
+		return &execSecretStore{
+			Timeout: internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
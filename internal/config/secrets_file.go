@@ -0,0 +1,62 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// fileSecretStore reads secrets from a directory containing a file per
+// secret, as used by Docker and Kubernetes secrets, or from a file with a
+// "key = secret" line per secret.
+type fileSecretStore struct {
+	Path string `toml:"path"`
+}
+
+func (s *fileSecretStore) Get(key string) (string, error) {
+	info, err := os.Stat(s.Path)
+	if err != nil {
+		return "", err
+	}
+
+	if info.IsDir() {
+		if key == "." || key == ".." || strings.ContainsAny(key, `/\`) {
+			return "", fmt.Errorf("invalid key %q", key)
+		}
+		data, err := ioutil.ReadFile(filepath.Join(s.Path, key))
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+
+	data, err := ioutil.ReadFile(s.Path)
+	if err != nil {
+		return "", err
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) == 2 && strings.TrimSpace(parts[0]) == key {
+			return strings.TrimSpace(parts[1]), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("secret %q not found in %s", key, s.Path)
+}
+
+func init() {
+	AddSecretStore("file", func() SecretStore {
+		return &fileSecretStore{}
+	})
+}
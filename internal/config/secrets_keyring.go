@@ -0,0 +1,174 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const keyringIterations = 100000
+
+// keyringFile is the content of a keyring file.  The secrets are stored as a
+// JSON object encrypted with AES-256-GCM, using a key derived from the
+// password with PBKDF2.
+type keyringFile struct {
+	Salt  []byte `json:"salt"`
+	Nonce []byte `json:"nonce"`
+	Data  []byte `json:"data"`
+}
+
+// keyringSecretStore stores secrets in a local file encrypted with a
+// password.
+type keyringSecretStore struct {
+	Path     string `toml:"path"`
+	Password string `toml:"password"`
+
+	mu      sync.Mutex
+	secrets map[string]string
+}
+
+func (s *keyringSecretStore) Get(key string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.secrets == nil {
+		secrets, err := s.read()
+		if err != nil {
+			return "", err
+		}
+		s.secrets = secrets
+	}
+
+	secret, ok := s.secrets[key]
+	if !ok {
+		return "", fmt.Errorf("secret %q not found in %s", key, s.Path)
+	}
+	return secret, nil
+}
+
+func (s *keyringSecretStore) Set(key string, secret string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	secrets, err := s.read()
+	if os.IsNotExist(err) {
+		secrets = make(map[string]string)
+	} else if err != nil {
+		return err
+	}
+	secrets[key] = secret
+
+	err = s.write(secrets)
+	if err != nil {
+		return err
+	}
+	s.secrets = secrets
+	return nil
+}
+
+// read decrypts the secrets of the keyring file.
+func (s *keyringSecretStore) read() (map[string]string, error) {
+	data, err := ioutil.ReadFile(s.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file keyringFile
+	err = json.Unmarshal(data, &file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid keyring file %s: %v", s.Path, err)
+	}
+
+	gcm, err := s.cipher(file.Salt)
+	if err != nil {
+		return nil, err
+	}
+	if len(file.Nonce) != gcm.NonceSize() {
+		return nil, fmt.Errorf("invalid keyring file %s: wrong nonce size", s.Path)
+	}
+	plain, err := gcm.Open(nil, file.Nonce, file.Data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not decrypt keyring file %s, wrong password?", s.Path)
+	}
+
+	secrets := make(map[string]string)
+	err = json.Unmarshal(plain, &secrets)
+	if err != nil {
+		return nil, fmt.Errorf("invalid keyring file %s: %v", s.Path, err)
+	}
+	return secrets, nil
+}
+
+// write encrypts the secrets with a new salt and nonce and replaces the
+// keyring file.
+func (s *keyringSecretStore) write(secrets map[string]string) error {
+	plain, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+
+	file := keyringFile{
+		Salt: make([]byte, 16),
+	}
+	if _, err := io.ReadFull(rand.Reader, file.Salt); err != nil {
+		return err
+	}
+	gcm, err := s.cipher(file.Salt)
+	if err != nil {
+		return err
+	}
+	file.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, file.Nonce); err != nil {
+		return err
+	}
+	file.Data = gcm.Seal(nil, file.Nonce, plain, nil)
+
+	data, err := json.Marshal(file)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(s.Path), filepath.Base(s.Path)+".tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), s.Path)
+}
+
+func (s *keyringSecretStore) cipher(salt []byte) (cipher.AEAD, error) {
+	if s.Password == "" {
+		return nil, errors.New("no password configured")
+	}
+
+	key := pbkdf2.Key([]byte(s.Password), salt, keyringIterations, 32, sha256.New)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func init() {
+	AddSecretStore("keyring", func() SecretStore {
+		return &keyringSecretStore{}
+	})
+}
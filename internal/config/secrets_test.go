@@ -0,0 +1,183 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/influxdata/telegraf/plugins/inputs/memcached"
+	"github.com/stretchr/testify/require"
+)
+
+type secretTestPlugin struct {
+	Password string
+	Servers  []string
+	Headers  map[string]string
+	Fields   map[string]interface{}
+	Value    interface{}
+	Nested   struct {
+		Token string
+	}
+	Ptr *struct {
+		Key string
+	}
+	unexported string
+}
+
+type secretTestStore map[string]string
+
+func (s secretTestStore) Get(key string) (string, error) {
+	return s[key], nil
+}
+
+func TestResolveSecrets(t *testing.T) {
+	plugin := &secretTestPlugin{
+		Password: "@{test:password}",
+		Servers:  []string{"user:@{test:password}@localhost", "plain"},
+		Headers:  map[string]string{"Authorization": "Bearer @{test:token}"},
+		Fields: map[string]interface{}{
+			"token": "@{test:token}",
+			"list":  []interface{}{"@{test:password}", 42},
+			"value": 42,
+		},
+		Value:      "@{test:password}",
+		unexported: "@{test:password}",
+	}
+	plugin.Nested.Token = "@{test:token}"
+	plugin.Ptr = &struct{ Key string }{Key: "@{test:token}"}
+
+	stores := map[string]SecretStore{
+		"test": secretTestStore{"password": "secret", "token": "abc"},
+	}
+	require.NoError(t, resolveSecrets(stores, plugin))
+	require.Equal(t, "secret", plugin.Password)
+	require.Equal(t, []string{"user:secret@localhost", "plain"}, plugin.Servers)
+	require.Equal(t, map[string]string{"Authorization": "Bearer abc"}, plugin.Headers)
+	require.Equal(t, map[string]interface{}{
+		"token": "abc",
+		"list":  []interface{}{"secret", 42},
+		"value": 42,
+	}, plugin.Fields)
+	require.Equal(t, "secret", plugin.Value)
+	require.Equal(t, "abc", plugin.Nested.Token)
+	require.Equal(t, "abc", plugin.Ptr.Key)
+	require.Equal(t, "@{test:password}", plugin.unexported)
+
+}
+
+func TestResolveSecrets_OtherReferences(t *testing.T) {
+	stores := map[string]SecretStore{
+		"test": secretTestStore{"password": "secret"},
+	}
+
+	// Strings referencing a store that is not configured are kept, an
+	// escaped reference to a configured store is unescaped.
+	plugin := &secretTestPlugin{
+		Password: "@{missing:password}",
+		Servers:  []string{"@@{test:password}", "@@{missing:password}"},
+	}
+	require.NoError(t, resolveSecrets(stores, plugin))
+	require.Equal(t, "@{missing:password}", plugin.Password)
+	require.Equal(t, []string{"@{test:password}", "@@{missing:password}"}, plugin.Servers)
+
+	// Without secret stores nothing is replaced.
+	plugin = &secretTestPlugin{Password: "@@{test:password}"}
+	require.NoError(t, resolveSecrets(nil, plugin))
+	require.Equal(t, "@@{test:password}", plugin.Password)
+}
+
+func TestConfig_LoadSecretStores(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "memcached"), []byte("localhost:11211\n"), 0600))
+	require.NoError(t, os.Setenv("SECRETS_DIR", dir))
+
+	c := NewConfig()
+	require.NoError(t, c.LoadConfig("./testdata/secrets.toml"))
+	require.Len(t, c.secretStores, 2)
+
+	input := c.Inputs[0].Input.(*memcached.Memcached)
+	require.Equal(t, []string{"@{files:memcached}"}, input.Servers)
+
+	require.NoError(t, c.ResolveSecrets())
+	require.Equal(t, []string{"localhost:11211"}, input.Servers)
+}
+
+func TestConfig_DuplicateSecretStore(t *testing.T) {
+	c := NewConfig()
+	err := c.LoadConfig("./testdata/secrets_duplicate.toml")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), `secret store id "files" is used by multiple secret stores`)
+}
+
+func TestFileSecretStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "password"), []byte("secret\n"), 0600))
+	store := &fileSecretStore{Path: dir}
+	secret, err := store.Get("password")
+	require.NoError(t, err)
+	require.Equal(t, "secret", secret)
+	_, err = store.Get("../password")
+	require.Error(t, err)
+
+	file := filepath.Join(dir, "secrets")
+	require.NoError(t, ioutil.WriteFile(file, []byte("# comment\ntoken = abc=def\n\npassword=secret\n"), 0600))
+	store = &fileSecretStore{Path: file}
+	secret, err = store.Get("token")
+	require.NoError(t, err)
+	require.Equal(t, "abc=def", secret)
+	secret, err = store.Get("password")
+	require.NoError(t, err)
+	require.Equal(t, "secret", secret)
+	_, err = store.Get("missing")
+	require.Error(t, err)
+}
+
+func TestKeyringSecretStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "secrets")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "secrets.keyring")
+	store := &keyringSecretStore{Path: path, Password: "password"}
+	require.NoError(t, store.Set("token", "abc"))
+	require.NoError(t, store.Set("password", "secret"))
+
+	data, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "secret")
+
+	store = &keyringSecretStore{Path: path, Password: "password"}
+	secret, err := store.Get("token")
+	require.NoError(t, err)
+	require.Equal(t, "abc", secret)
+	secret, err = store.Get("password")
+	require.NoError(t, err)
+	require.Equal(t, "secret", secret)
+
+	store = &keyringSecretStore{Path: path, Password: "wrong"}
+	_, err = store.Get("token")
+	require.Error(t, err)
+}
+
+func TestExecSecretStore(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test on windows")
+	}
+
+	store := SecretStores["exec"]().(*execSecretStore)
+	store.Command = []string{"echo", "secret"}
+	secret, err := store.Get("token")
+	require.NoError(t, err)
+	require.Equal(t, "secret token", secret)
+
+	store.Command = []string{"false"}
+	_, err = store.Get("token")
+	require.Error(t, err)
+}
@@ -0,0 +1,190 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/influxdata/toml"
+	"github.com/influxdata/toml/ast"
+)
+
+// parserFormatKeys are the parser options used by each data format.  Options
+// of other data formats are not used.
+var parserFormatKeys = map[string][]string{
+	"json": {"tag_keys", "json_name_key", "json_query", "json_string_fields",
+		"json_time_format", "json_time_key", "json_timezone"},
+	"value":    {"data_type"},
+	"graphite": {"separator", "templates"},
+	"collectd": {"collectd_auth_file", "collectd_security_level",
+		"collectd_typesdb", "collectd_parse_multivalue"},
+	"dropwizard": {"separator", "templates", "dropwizard_metric_registry_path",
+		"dropwizard_time_path", "dropwizard_time_format", "dropwizard_tags_path",
+		"dropwizard_tag_paths"},
+	"grok": {"grok_named_patterns", "grok_patterns", "grok_custom_patterns",
+		"grok_custom_pattern_files", "grok_timezone", "grok_unique_timestamp"},
+	"csv": {"csv_column_names", "csv_column_types", "csv_comment",
+		"csv_delimiter", "csv_field_columns", "csv_header_row_count",
+		"csv_measurement_column", "csv_skip_columns", "csv_skip_rows",
+		"csv_tag_columns", "csv_timestamp_column", "csv_timestamp_format",
+		"csv_trim_space"},
+	"form_urlencoded": {"form_urlencoded_tag_keys"},
+}
+
+// serializerFormatKeys are the serializer options used by each data format.
+var serializerFormatKeys = map[string][]string{
+	"influx": {"influx_max_line_bytes", "influx_sort_fields",
+		"influx_uint_support"},
+	"graphite":     {"prefix", "template", "graphite_tag_support"},
+	"json":         {"json_timestamp_units"},
+	"splunkmetric": {"splunkmetric_hec_routing"},
+	"wavefront":    {"prefix", "wavefront_source_override", "wavefront_use_strict"},
+}
+
+// tableKeys tracks which keys of a plugin table are used to build the plugin,
+// for the strict_config agent option.
+type tableKeys struct {
+	table *ast.Table
+	// fields are the fields of the table before building the plugin, the
+	// build functions delete the fields they consume from the table.
+	fields map[string]interface{}
+	unused map[string]bool
+}
+
+func newTableKeys(tbl *ast.Table) *tableKeys {
+	k := &tableKeys{
+		table:  tbl,
+		fields: make(map[string]interface{}, len(tbl.Fields)),
+		unused: make(map[string]bool),
+	}
+	for key, field := range tbl.Fields {
+		k.fields[key] = field
+	}
+	return k
+}
+
+// checkFormat marks the data format options that do not apply to the data
+// format of the plugin as unused.
+func (k *tableKeys) checkFormat(formatKeys map[string][]string, defaultFormat string) {
+	format := defaultFormat
+	if node, ok := k.fields["data_format"]; ok {
+		if kv, ok := node.(*ast.KeyValue); ok {
+			if str, ok := kv.Value.(*ast.String); ok && str.Value != "" {
+				format = str.Value
+			}
+		}
+	}
+
+	used := make(map[string]bool)
+	for _, key := range formatKeys[format] {
+		used[key] = true
+	}
+	for _, keys := range formatKeys {
+		for _, key := range keys {
+			if _, ok := k.fields[key]; ok && !used[key] {
+				k.unused[key] = true
+			}
+		}
+	}
+}
+
+// unmarshal decodes the remaining fields of the table into the plugin.  Keys
+// without a matching field in the plugin are marked as unused.
+func (k *tableKeys) unmarshal(plugin interface{}) error {
+	cfg := toml.DefaultConfig
+	cfg.MissingField = func(typ reflect.Type, key string) error {
+		k.unused[key] = true
+		return nil
+	}
+	return cfg.UnmarshalTable(k.table, plugin)
+}
+
+// err returns an error listing the unused keys with their line numbers, or
+// nil if all keys were used.
+func (k *tableKeys) err() error {
+	if len(k.unused) == 0 {
+		return nil
+	}
+
+	type unusedKey struct {
+		key  string
+		line int
+	}
+	var keys []unusedKey
+	for key := range k.unused {
+		line, ok := fieldLine(k.fields[key])
+		if !ok {
+			line, ok = findLine(k.table, key)
+		}
+		if !ok {
+			line = k.table.Line
+		}
+		keys = append(keys, unusedKey{key, line})
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].line != keys[j].line {
+			return keys[i].line < keys[j].line
+		}
+		return keys[i].key < keys[j].key
+	})
+
+	var list []string
+	for _, key := range keys {
+		list = append(list, fmt.Sprintf("%q (line %d)", key.key, key.line))
+	}
+	return fmt.Errorf("unused configuration keys: %s", strings.Join(list, ", "))
+}
+
+// unmarshalTable decodes the table into the plugin.  If keys is not nil, all
+// keys that are not used are reported instead of failing on the first key
+// without a matching field.
+func unmarshalTable(table *ast.Table, plugin interface{}, keys *tableKeys) error {
+	if keys == nil {
+		return toml.UnmarshalTable(table, plugin)
+	}
+
+	if err := keys.unmarshal(plugin); err != nil {
+		return err
+	}
+	return keys.err()
+}
+
+// fieldLine returns the line of a field of a table.
+func fieldLine(field interface{}) (int, bool) {
+	switch f := field.(type) {
+	case *ast.KeyValue:
+		return f.Line, true
+	case *ast.Table:
+		return f.Line, true
+	case []*ast.Table:
+		if len(f) > 0 {
+			return f[0].Line, true
+		}
+	}
+	return 0, false
+}
+
+// findLine returns the line of the first field named key in the subtables of
+// tbl.
+func findLine(tbl *ast.Table, key string) (int, bool) {
+	for _, field := range tbl.Fields {
+		var tables []*ast.Table
+		switch f := field.(type) {
+		case *ast.Table:
+			tables = []*ast.Table{f}
+		case []*ast.Table:
+			tables = f
+		}
+
+		for _, sub := range tables {
+			if field, ok := sub.Fields[key]; ok {
+				return fieldLine(field)
+			}
+			if line, ok := findLine(sub, key); ok {
+				return line, true
+			}
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_StrictConfig(t *testing.T) {
+	c := NewConfig()
+	errs := c.Check("./testdata/strict_config.toml", "")
+
+	var messages []string
+	for _, err := range errs {
+		messages = append(messages, err.Error())
+	}
+	require.ElementsMatch(t, []string{
+		`./testdata/strict_config.toml:4: inputs.exec: unused configuration keys: "csv_delimiter" (line 8), "timout" (line 9)`,
+		`./testdata/strict_config.toml:11: outputs.http: unused configuration keys: "metric_buffer_limt" (line 13), "json_timestamp_units" (line 15)`,
+	}, messages)
+}
+
+func TestConfig_StrictConfigValid(t *testing.T) {
+	c := NewConfig()
+	c.Agent.StrictConfig = true
+	err := c.LoadConfig("./testdata/single_plugin.toml")
+	require.NoError(t, err)
+	require.Len(t, c.Inputs, 1)
+}
@@ -0,0 +1,169 @@
+// +build !solaris
+
+package config
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/fsnotify.v1"
+)
+
+// Watcher watches the files and directories a configuration was loaded from
+// and signals when they change.
+type Watcher struct {
+	watcher  *fsnotify.Watcher
+	files    map[string]bool
+	dirs     []string
+	debounce time.Duration
+	changes  chan struct{}
+}
+
+// NewWatcher starts watching the files and directories loaded into c.  A
+// burst of changes is reported once, after no further change occurred for the
+// debounce duration.
+func NewWatcher(c *Config, debounce time.Duration) (*Watcher, error) {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Watcher{
+		watcher:  fw,
+		files:    make(map[string]bool),
+		debounce: debounce,
+		changes:  make(chan struct{}, 1),
+	}
+
+	// Files are watched through their directory since editors often replace
+	// a file instead of writing to it, which would end a watch on the file.
+	for _, file := range c.Files {
+		path, err := filepath.Abs(file)
+		if err != nil {
+			fw.Close()
+			return nil, err
+		}
+		w.files[path] = true
+
+		err = fw.Add(filepath.Dir(path))
+		if err != nil {
+			fw.Close()
+			return nil, err
+		}
+	}
+
+	for _, dir := range c.Directories {
+		path, err := filepath.Abs(dir)
+		if err != nil {
+			fw.Close()
+			return nil, err
+		}
+		w.dirs = append(w.dirs, path)
+
+		err = w.addDirectory(path)
+		if err != nil {
+			fw.Close()
+			return nil, err
+		}
+	}
+
+	go w.run()
+	return w, nil
+}
+
+// Changes returns a channel that receives a value when the configuration has
+// changed.
+func (w *Watcher) Changes() <-chan struct{} {
+	return w.changes
+}
+
+// Close stops watching the configuration.
+func (w *Watcher) Close() error {
+	return w.watcher.Close()
+}
+
+// addDirectory watches a directory and its subdirectories, skipping the same
+// directories as LoadDirectory.
+func (w *Watcher) addDirectory(path string) error {
+	return filepath.Walk(path, func(thispath string, info os.FileInfo, _ error) error {
+		if info == nil || !info.IsDir() {
+			return nil
+		}
+		if thispath != path && strings.HasPrefix(info.Name(), "..") {
+			return filepath.SkipDir
+		}
+		return w.watcher.Add(thispath)
+	})
+}
+
+func (w *Watcher) run() {
+	var debounce <-chan time.Time
+	for {
+		select {
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			if !w.relevant(event) {
+				continue
+			}
+
+			log.Printf("D! [config] Detected change of %s", event.Name)
+			debounce = time.After(w.debounce)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("E! [config] Error watching config: %v", err)
+		case <-debounce:
+			debounce = nil
+			select {
+			case w.changes <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// relevant returns true if the event affects the configuration.
+func (w *Watcher) relevant(event fsnotify.Event) bool {
+	if event.Op == fsnotify.Chmod {
+		return false
+	}
+	if w.files[event.Name] {
+		return true
+	}
+
+	for _, dir := range w.dirs {
+		if !strings.HasPrefix(event.Name, dir+string(os.PathSeparator)) {
+			continue
+		}
+
+		name := filepath.Base(event.Name)
+		if strings.HasSuffix(name, ".conf") {
+			return true
+		}
+
+		// Kubernetes updates mounted config maps by replacing the "..data"
+		// symlink the files point to.
+		if strings.HasPrefix(name, "..") {
+			return true
+		}
+
+		// A new directory may contain configuration files.
+		if event.Op&fsnotify.Create != 0 {
+			info, err := os.Stat(event.Name)
+			if err == nil && info.IsDir() {
+				err = w.addDirectory(event.Name)
+				if err != nil {
+					log.Printf("E! [config] Error watching %s: %v", event.Name, err)
+				}
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,26 @@
+// +build solaris
+
+package config
+
+import (
+	"errors"
+	"time"
+)
+
+// Watcher is not supported on Solaris due to the lack of fsnotify support.
+type Watcher struct{}
+
+// NewWatcher returns an error, watching the configuration is not supported.
+func NewWatcher(c *Config, debounce time.Duration) (*Watcher, error) {
+	return nil, errors.New("watching the configuration is not supported on solaris")
+}
+
+// Changes returns nil.
+func (w *Watcher) Changes() <-chan struct{} {
+	return nil
+}
+
+// Close does nothing.
+func (w *Watcher) Close() error {
+	return nil
+}
@@ -0,0 +1,98 @@
+// +build !solaris
+
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const watchTestConfig = `
+[[inputs.memcached]]
+  servers = ["localhost"]
+`
+
+func newWatchTestConfig(t *testing.T) (*Config, string) {
+	dir, err := ioutil.TempDir("", "telegraf-watch")
+	require.NoError(t, err)
+
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "telegraf.d"), 0755))
+	path := filepath.Join(dir, "telegraf.conf")
+	require.NoError(t, ioutil.WriteFile(path, []byte(watchTestConfig), 0644))
+
+	c := NewConfig()
+	require.NoError(t, c.LoadConfig(path))
+	require.NoError(t, c.LoadDirectory(filepath.Join(dir, "telegraf.d")))
+	return c, dir
+}
+
+func requireChange(t *testing.T, w *Watcher) {
+	select {
+	case <-w.Changes():
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout waiting for config change")
+	}
+}
+
+func requireNoChange(t *testing.T, w *Watcher) {
+	select {
+	case <-w.Changes():
+		t.Fatal("unexpected config change")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestWatcher_File(t *testing.T) {
+	c, dir := newWatchTestConfig(t)
+	defer os.RemoveAll(dir)
+
+	w, err := NewWatcher(c, 10*time.Millisecond)
+	require.NoError(t, err)
+	defer w.Close()
+
+	// Files next to the config file are ignored.
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "other.conf"), nil, 0644))
+	requireNoChange(t, w)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "telegraf.conf"),
+		[]byte(watchTestConfig), 0644))
+	requireChange(t, w)
+}
+
+func TestWatcher_Directory(t *testing.T) {
+	c, dir := newWatchTestConfig(t)
+	defer os.RemoveAll(dir)
+
+	w, err := NewWatcher(c, 10*time.Millisecond)
+	require.NoError(t, err)
+	defer w.Close()
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "telegraf.d", "README"), nil, 0644))
+	requireNoChange(t, w)
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "telegraf.d", "cpu.conf"),
+		[]byte(watchTestConfig), 0644))
+	requireChange(t, w)
+}
+
+func TestWatcher_Debounce(t *testing.T) {
+	c, dir := newWatchTestConfig(t)
+	defer os.RemoveAll(dir)
+
+	w, err := NewWatcher(c, 200*time.Millisecond)
+	require.NoError(t, err)
+	defer w.Close()
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "telegraf.conf"),
+			[]byte(watchTestConfig), 0644))
+		time.Sleep(10 * time.Millisecond)
+	}
+	requireChange(t, w)
+	requireNoChange(t, w)
+}
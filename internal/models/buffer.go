@@ -12,18 +12,32 @@ var (
 	AgentMetricsDropped = selfstat.Register("agent", "metrics_dropped", map[string]string{})
 )
 
-// Buffer stores metrics in a circular buffer.
-type Buffer struct {
-	sync.Mutex
-	buf   []telegraf.Metric
-	first int // index of the first/oldest metric
-	last  int // one after the index of the last/newest metric
-	size  int // number of metrics currently in the buffer
-	cap   int // the capacity of the buffer
+// MetricBuffer is the interface implemented by the output metric buffers.
+type MetricBuffer interface {
+	// Len returns the number of metrics currently in the buffer.
+	Len() int
 
-	batchFirst int // index of the first metric in the batch
-	batchSize  int // number of metrics currently in the batch
+	// Add adds metrics to the buffer and returns number of dropped metrics.
+	Add(metrics ...telegraf.Metric) int
 
+	// Batch returns a slice containing up to batchSize metrics.  The batch
+	// must be returned with either Accept or Reject before the next call.
+	Batch(batchSize int) []telegraf.Metric
+
+	// Accept marks the batch, acquired from Batch(), as successfully written.
+	Accept(metrics []telegraf.Metric)
+
+	// Reject returns the batch, acquired from Batch(), to the buffer and
+	// marks it as unsent.
+	Reject(metrics []telegraf.Metric)
+
+	// Close releases any resources held by the buffer.
+	Close() error
+}
+
+// BufferStats holds the internal statistics shared by the buffer
+// implementations.
+type BufferStats struct {
 	MetricsAdded   selfstat.Stat
 	MetricsWritten selfstat.Stat
 	MetricsDropped selfstat.Stat
@@ -31,15 +45,9 @@ type Buffer struct {
 	BufferLimit    selfstat.Stat
 }
 
-// NewBuffer returns a new empty Buffer with the given capacity.
-func NewBuffer(name string, capacity int) *Buffer {
-	b := &Buffer{
-		buf:   make([]telegraf.Metric, capacity),
-		first: 0,
-		last:  0,
-		size:  0,
-		cap:   capacity,
-
+// NewBufferStats registers the buffer statistics for the named output.
+func NewBufferStats(name string, capacity int) BufferStats {
+	bs := BufferStats{
 		MetricsAdded: selfstat.Register(
 			"write",
 			"metrics_added",
@@ -66,39 +74,68 @@ func NewBuffer(name string, capacity int) *Buffer {
 			map[string]string{"output": name},
 		),
 	}
-	b.BufferSize.Set(int64(0))
-	b.BufferLimit.Set(int64(capacity))
-	return b
+	bs.BufferSize.Set(int64(0))
+	bs.BufferLimit.Set(int64(capacity))
+	return bs
 }
 
-// Len returns the number of metrics currently in the buffer.
-func (b *Buffer) Len() int {
-	b.Lock()
-	defer b.Unlock()
-
-	return b.length()
-}
-
-func (b *Buffer) length() int {
-	return min(b.size+b.batchSize, b.cap)
-}
-
-func (b *Buffer) metricAdded() {
+func (b *BufferStats) metricAdded() {
 	b.MetricsAdded.Incr(1)
 }
 
-func (b *Buffer) metricWritten(metric telegraf.Metric) {
+func (b *BufferStats) metricWritten(metric telegraf.Metric) {
 	AgentMetricsWritten.Incr(1)
 	b.MetricsWritten.Incr(1)
 	metric.Accept()
 }
 
-func (b *Buffer) metricDropped(metric telegraf.Metric) {
+func (b *BufferStats) metricDropped(metric telegraf.Metric) {
 	AgentMetricsDropped.Incr(1)
 	b.MetricsDropped.Incr(1)
 	metric.Reject()
 }
 
+// Buffer stores metrics in a circular buffer.
+type Buffer struct {
+	sync.Mutex
+	buf   []telegraf.Metric
+	first int // index of the first/oldest metric
+	last  int // one after the index of the last/newest metric
+	size  int // number of metrics currently in the buffer
+	cap   int // the capacity of the buffer
+
+	batchFirst int // index of the first metric in the batch
+	batchSize  int // number of metrics currently in the batch
+
+	BufferStats
+}
+
+// NewBuffer returns a new empty Buffer with the given capacity.
+func NewBuffer(name string, capacity int) *Buffer {
+	b := &Buffer{
+		buf:   make([]telegraf.Metric, capacity),
+		first: 0,
+		last:  0,
+		size:  0,
+		cap:   capacity,
+
+		BufferStats: NewBufferStats(name, capacity),
+	}
+	return b
+}
+
+// Len returns the number of metrics currently in the buffer.
+func (b *Buffer) Len() int {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.length()
+}
+
+func (b *Buffer) length() int {
+	return min(b.size+b.batchSize, b.cap)
+}
+
 func (b *Buffer) add(m telegraf.Metric) int {
 	dropped := 0
 	// Check if Buffer is full
@@ -232,6 +269,11 @@ func (b *Buffer) Reject(batch []telegraf.Metric) {
 	b.BufferSize.Set(int64(b.length()))
 }
 
+// Close is a no-op for the in-memory buffer.
+func (b *Buffer) Close() error {
+	return nil
+}
+
 // dist returns the distance between two indexes.  Because this data structure
 // uses a half open range the arguments must both either left side or right
 // side pairs.
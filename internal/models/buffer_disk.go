@@ -0,0 +1,603 @@
+package models
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+)
+
+const (
+	// Number of records stored in a single segment file of the disk buffer.
+	defaultDiskSegmentLen = 1000
+
+	diskSegmentSuffix    = ".wal"
+	diskCheckpointFile   = "checkpoint"
+	diskRecordHeaderSize = 8
+)
+
+var errCorruptRecord = errors.New("corrupt record")
+
+// diskMetric is the on-disk representation of a metric.
+type diskMetric struct {
+	Name      string
+	Tags      map[string]string
+	Fields    map[string]interface{}
+	Time      time.Time
+	Type      telegraf.ValueType
+	Aggregate bool
+}
+
+// diskSegment is a single append-only file of the write-ahead log.
+type diskSegment struct {
+	first   uint64  // sequence number of the first record in the segment
+	path    string  // path to the segment file
+	offsets []int64 // file offset of each record
+	size    int64   // offset one after the end of the last record
+}
+
+// DiskBuffer stores metrics in a write-ahead log on disk so that unsent
+// metrics survive a restart of the process.
+//
+// Metrics are persisted to segment files as they are added and removed once
+// a batch containing them has been accepted.  The sequence number of the
+// oldest unsent metric is kept in a checkpoint file, when the buffer is
+// reopened all metrics after the checkpoint are replayed.
+//
+// Unlike Buffer, batches are returned from oldest to newest.  Tracking
+// metrics are accepted as soon as they have been written to the segment.
+// Segments are only synced to disk when they are closed, so accepted metrics
+// survive a crash of the process but may be lost if the host crashes.
+type DiskBuffer struct {
+	sync.Mutex
+	name       string
+	path       string
+	cap        int // the capacity of the buffer
+	segmentLen int // the maximum number of records per segment
+
+	segments []*diskSegment
+	active   *os.File // the newest segment, opened for appending
+
+	first uint64 // sequence number of the first/oldest metric
+	last  uint64 // one after the sequence number of the last/newest metric
+
+	batchFirst uint64 // sequence number of the first metric in the batch
+	batchSize  int    // number of metrics currently in the batch
+
+	BufferStats
+}
+
+// NewDiskBuffer opens the disk buffer stored in the directory path, creating
+// it if required.  Any metrics left over from a previous run are restored.
+func NewDiskBuffer(name string, path string, capacity int) (*DiskBuffer, error) {
+	b := &DiskBuffer{
+		name:       name,
+		path:       path,
+		cap:        capacity,
+		segmentLen: defaultDiskSegmentLen,
+
+		BufferStats: NewBufferStats(name, capacity),
+	}
+
+	if err := os.MkdirAll(path, 0700); err != nil {
+		return nil, err
+	}
+
+	if err := b.open(); err != nil {
+		b.close()
+		return nil, err
+	}
+
+	b.BufferSize.Set(int64(b.length()))
+	return b, nil
+}
+
+// Len returns the number of metrics currently in the buffer.
+func (b *DiskBuffer) Len() int {
+	b.Lock()
+	defer b.Unlock()
+
+	return b.length()
+}
+
+func (b *DiskBuffer) length() int {
+	return int(b.last - b.first)
+}
+
+// Add adds metrics to the buffer and returns number of dropped metrics.
+func (b *DiskBuffer) Add(metrics ...telegraf.Metric) int {
+	b.Lock()
+	defer b.Unlock()
+
+	first := b.first
+	dropped := 0
+	for _, m := range metrics {
+		if err := b.add(m); err != nil {
+			log.Printf("E! [outputs.%s] Unable to write metric to disk buffer: %v",
+				b.name, err)
+			b.metricDropped(m)
+			dropped++
+			continue
+		}
+		b.metricAdded()
+		m.Accept()
+
+		// Check if Buffer is full
+		if b.length() > b.cap {
+			b.dropOldest()
+			dropped++
+		}
+	}
+
+	if b.first != first {
+		b.compact()
+	}
+
+	b.BufferSize.Set(int64(b.length()))
+	return dropped
+}
+
+// Batch returns a slice containing up to batchSize of the oldest metrics.
+// Metrics are ordered from oldest to newest in the batch.
+func (b *DiskBuffer) Batch(batchSize int) []telegraf.Metric {
+	b.Lock()
+	defer b.Unlock()
+
+	var r segmentReader
+	defer r.close()
+
+	first := b.first
+	out := make([]telegraf.Metric, 0, min(b.length(), batchSize))
+	for seq := b.first; seq < b.last && len(out) < batchSize; {
+		m, next, err := b.read(&r, seq)
+		if err != nil {
+			log.Printf("E! [outputs.%s] Unable to read metric from disk buffer: %v",
+				b.name, err)
+			// Records that cannot be read are discarded once they reach the
+			// front of the buffer.
+			if len(out) == 0 {
+				b.dropTo(next)
+				seq = next
+				continue
+			}
+			break
+		}
+		out = append(out, m)
+		seq = next
+	}
+
+	b.batchFirst = b.first
+	b.batchSize = len(out)
+
+	if b.first != first {
+		b.compact()
+		b.BufferSize.Set(int64(b.length()))
+	}
+	return out
+}
+
+// Accept marks the batch, acquired from Batch(), as successfully written.
+func (b *DiskBuffer) Accept(batch []telegraf.Metric) {
+	b.Lock()
+	defer b.Unlock()
+
+	for _, m := range batch {
+		b.metricWritten(m)
+	}
+
+	if end := b.batchFirst + uint64(b.batchSize); end > b.first {
+		b.first = end
+		b.compact()
+	}
+
+	b.resetBatch()
+	b.BufferSize.Set(int64(b.length()))
+}
+
+// Reject returns the batch, acquired from Batch(), to the buffer and marks it
+// as unsent.  The metrics are still stored on disk so nothing needs to be
+// restored.
+func (b *DiskBuffer) Reject(batch []telegraf.Metric) {
+	b.Lock()
+	defer b.Unlock()
+
+	b.resetBatch()
+	b.BufferSize.Set(int64(b.length()))
+}
+
+// Close flushes the buffer to disk and closes the open segment.
+func (b *DiskBuffer) Close() error {
+	b.Lock()
+	defer b.Unlock()
+
+	err := b.writeCheckpoint()
+	if cerr := b.close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func (b *DiskBuffer) close() error {
+	if b.active == nil {
+		return nil
+	}
+
+	err := b.active.Sync()
+	if cerr := b.active.Close(); err == nil {
+		err = cerr
+	}
+	b.active = nil
+	return err
+}
+
+func (b *DiskBuffer) add(m telegraf.Metric) error {
+	var payload bytes.Buffer
+	err := gob.NewEncoder(&payload).Encode(&diskMetric{
+		Name:      m.Name(),
+		Tags:      m.Tags(),
+		Fields:    m.Fields(),
+		Time:      m.Time(),
+		Type:      m.Type(),
+		Aggregate: m.IsAggregate(),
+	})
+	if err != nil {
+		return err
+	}
+
+	seg, err := b.activeSegment()
+	if err != nil {
+		return err
+	}
+
+	record := make([]byte, diskRecordHeaderSize+payload.Len())
+	binary.BigEndian.PutUint32(record[0:4], uint32(payload.Len()))
+	binary.BigEndian.PutUint32(record[4:8], crc32.ChecksumIEEE(payload.Bytes()))
+	copy(record[diskRecordHeaderSize:], payload.Bytes())
+
+	if _, err := b.active.Write(record); err != nil {
+		// Discard a partial write so the segment stays readable.
+		b.active.Truncate(seg.size)
+		b.active.Seek(seg.size, io.SeekStart)
+		return err
+	}
+
+	seg.offsets = append(seg.offsets, seg.size)
+	seg.size += int64(len(record))
+	b.last++
+	return nil
+}
+
+// activeSegment returns the segment new records are appended to, starting a
+// new one if the current segment is full.
+func (b *DiskBuffer) activeSegment() (*diskSegment, error) {
+	if len(b.segments) > 0 {
+		seg := b.segments[len(b.segments)-1]
+		if len(seg.offsets) < b.segmentLen {
+			if b.active == nil {
+				if err := b.openSegment(seg); err != nil {
+					return nil, err
+				}
+			}
+			return seg, nil
+		}
+	}
+
+	if err := b.close(); err != nil {
+		return nil, err
+	}
+
+	seg := &diskSegment{
+		first: b.last,
+		path:  filepath.Join(b.path, fmt.Sprintf("%020d%s", b.last, diskSegmentSuffix)),
+	}
+	f, err := os.OpenFile(seg.path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	b.active = f
+	b.segments = append(b.segments, seg)
+	return seg, nil
+}
+
+// openSegment opens an existing segment for appending, discarding any
+// partially written record at its end.
+func (b *DiskBuffer) openSegment(seg *diskSegment) error {
+	f, err := os.OpenFile(seg.path, os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if err := f.Truncate(seg.size); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := f.Seek(seg.size, io.SeekStart); err != nil {
+		f.Close()
+		return err
+	}
+
+	b.active = f
+	return nil
+}
+
+// read returns the metric with the given sequence number along with the
+// sequence number of the following record.  The segment file is kept open in
+// r between calls.
+func (b *DiskBuffer) read(r *segmentReader, seq uint64) (telegraf.Metric, uint64, error) {
+	for _, seg := range b.segments {
+		end := seg.first + uint64(len(seg.offsets))
+		if seq >= end {
+			continue
+		}
+		if seq < seg.first {
+			// The record is missing; skip ahead to the next known record.
+			return nil, seg.first, errCorruptRecord
+		}
+
+		f, err := r.open(seg)
+		if err != nil {
+			return nil, end, err
+		}
+
+		payload, err := readRecord(f, seg.offsets[seq-seg.first], seg.size)
+		if err != nil {
+			return nil, seq + 1, err
+		}
+
+		m, err := decodeMetric(payload)
+		return m, seq + 1, err
+	}
+	return nil, b.last, errCorruptRecord
+}
+
+// dropOldest removes the oldest metric from the buffer.
+func (b *DiskBuffer) dropOldest() {
+	AgentMetricsDropped.Incr(1)
+	b.MetricsDropped.Incr(1)
+
+	if b.first == b.batchFirst && b.batchSize > 0 {
+		b.batchSize--
+		b.batchFirst++
+	}
+	b.first++
+}
+
+// dropTo removes all metrics older than seq from the buffer.
+func (b *DiskBuffer) dropTo(seq uint64) {
+	for b.first < seq && b.first < b.last {
+		b.dropOldest()
+	}
+}
+
+// compact removes segments that only contain sent metrics and records the
+// current position in the checkpoint.
+func (b *DiskBuffer) compact() {
+	for len(b.segments) > 1 {
+		seg := b.segments[0]
+		if seg.first+uint64(len(seg.offsets)) > b.first {
+			break
+		}
+
+		if err := os.Remove(seg.path); err != nil && !os.IsNotExist(err) {
+			log.Printf("E! [outputs.%s] Unable to remove disk buffer segment: %v",
+				b.name, err)
+			break
+		}
+		b.segments = b.segments[1:]
+	}
+
+	if err := b.writeCheckpoint(); err != nil {
+		log.Printf("E! [outputs.%s] Unable to write disk buffer checkpoint: %v",
+			b.name, err)
+	}
+}
+
+// writeCheckpoint atomically stores the sequence number of the oldest
+// unsent metric.
+func (b *DiskBuffer) writeCheckpoint() error {
+	tmp := filepath.Join(b.path, diskCheckpointFile+".tmp")
+	data := []byte(strconv.FormatUint(b.first, 10) + "\n")
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(b.path, diskCheckpointFile))
+}
+
+// open loads the checkpoint and the segments stored in the buffer directory.
+func (b *DiskBuffer) open() error {
+	checkpoint, err := b.readCheckpoint()
+	if err != nil {
+		return err
+	}
+
+	files, err := ioutil.ReadDir(b.path)
+	if err != nil {
+		return err
+	}
+
+	for _, info := range files {
+		name := info.Name()
+		if info.IsDir() || !strings.HasSuffix(name, diskSegmentSuffix) {
+			continue
+		}
+
+		first, err := strconv.ParseUint(strings.TrimSuffix(name, diskSegmentSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		seg := &diskSegment{first: first, path: filepath.Join(b.path, name)}
+		if err := seg.load(); err != nil {
+			return err
+		}
+		b.segments = append(b.segments, seg)
+	}
+
+	sort.Slice(b.segments, func(i, j int) bool {
+		return b.segments[i].first < b.segments[j].first
+	})
+
+	b.first = checkpoint
+	b.last = checkpoint
+	if len(b.segments) > 0 {
+		seg := b.segments[len(b.segments)-1]
+		b.last = seg.first + uint64(len(seg.offsets))
+		if b.segments[0].first > b.first {
+			b.first = b.segments[0].first
+		}
+		if b.first > b.last {
+			b.first = b.last
+		}
+
+		if err := b.openSegment(seg); err != nil {
+			return err
+		}
+	}
+
+	// The capacity may have been reduced since the buffer was written.
+	for b.length() > b.cap {
+		b.dropOldest()
+	}
+
+	if n := b.length(); n > 0 {
+		log.Printf("I! [outputs.%s] Restored %d metrics from disk buffer", b.name, n)
+	}
+
+	b.compact()
+	return nil
+}
+
+func (b *DiskBuffer) readCheckpoint() (uint64, error) {
+	data, err := ioutil.ReadFile(filepath.Join(b.path, diskCheckpointFile))
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	first, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid disk buffer checkpoint: %v", err)
+	}
+	return first, nil
+}
+
+func (b *DiskBuffer) resetBatch() {
+	b.batchFirst = 0
+	b.batchSize = 0
+}
+
+// load scans the segment file for the offsets of all complete records.
+func (s *diskSegment) load() error {
+	f, err := os.Open(s.path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	for {
+		payload, err := readRecord(f, s.size, info.Size())
+		if err == io.EOF || err == errCorruptRecord {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		s.offsets = append(s.offsets, s.size)
+		s.size += int64(diskRecordHeaderSize + len(payload))
+	}
+}
+
+// segmentReader keeps the most recently read segment file open.
+type segmentReader struct {
+	seg  *diskSegment
+	file *os.File
+}
+
+func (r *segmentReader) open(seg *diskSegment) (*os.File, error) {
+	if r.seg == seg {
+		return r.file, nil
+	}
+
+	r.close()
+	f, err := os.Open(seg.path)
+	if err != nil {
+		return nil, err
+	}
+	r.seg = seg
+	r.file = f
+	return f, nil
+}
+
+func (r *segmentReader) close() {
+	if r.file != nil {
+		r.file.Close()
+	}
+	r.seg = nil
+	r.file = nil
+}
+
+// readRecord reads and verifies the record at offset.  The length stored in
+// the header is checked against size, the end of the segment, before the
+// payload is allocated.
+func readRecord(r io.ReaderAt, offset int64, size int64) ([]byte, error) {
+	header := make([]byte, diskRecordHeaderSize)
+	n, err := r.ReadAt(header, offset)
+	if n == 0 && err == io.EOF {
+		return nil, io.EOF
+	}
+	if n < diskRecordHeaderSize {
+		return nil, errCorruptRecord
+	}
+
+	length := int64(binary.BigEndian.Uint32(header[0:4]))
+	if offset+diskRecordHeaderSize+length > size {
+		return nil, errCorruptRecord
+	}
+
+	payload := make([]byte, length)
+	if n, _ := r.ReadAt(payload, offset+diskRecordHeaderSize); n < len(payload) {
+		return nil, errCorruptRecord
+	}
+
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:8]) {
+		return nil, errCorruptRecord
+	}
+	return payload, nil
+}
+
+func decodeMetric(payload []byte) (telegraf.Metric, error) {
+	var dm diskMetric
+	if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(&dm); err != nil {
+		return nil, err
+	}
+
+	m, err := metric.New(dm.Name, dm.Tags, dm.Fields, dm.Time, dm.Type)
+	if err != nil {
+		return nil, err
+	}
+	m.SetAggregate(dm.Aggregate)
+	return m, nil
+}
@@ -0,0 +1,272 @@
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDiskBuffer(t *testing.T, path string, capacity int) *DiskBuffer {
+	b, err := NewDiskBuffer("test", path, capacity)
+	require.NoError(t, err)
+	b.MetricsAdded.Set(0)
+	b.MetricsWritten.Set(0)
+	b.MetricsDropped.Set(0)
+	return b
+}
+
+func tempBufferDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "telegraf-buffer")
+	require.NoError(t, err)
+	return dir
+}
+
+func TestDiskBuffer_LenEmpty(t *testing.T) {
+	dir := tempBufferDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 5)
+	defer b.Close()
+
+	require.Equal(t, 0, b.Len())
+	require.Len(t, b.Batch(5), 0)
+}
+
+func TestDiskBuffer_BatchOldestFirst(t *testing.T) {
+	dir := tempBufferDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 5)
+	defer b.Close()
+
+	b.Add(MetricTime(1))
+	b.Add(MetricTime(2))
+	b.Add(MetricTime(3))
+	require.Equal(t, 3, b.Len())
+
+	batch := b.Batch(2)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{
+			MetricTime(1),
+			MetricTime(2),
+		}, batch)
+	b.Accept(batch)
+
+	require.Equal(t, 1, b.Len())
+	require.Equal(t, int64(3), b.MetricsAdded.Get())
+	require.Equal(t, int64(2), b.MetricsWritten.Get())
+
+	batch = b.Batch(2)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{
+			MetricTime(3),
+		}, batch)
+}
+
+func TestDiskBuffer_RejectKeepsMetrics(t *testing.T) {
+	dir := tempBufferDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 5)
+	defer b.Close()
+
+	b.Add(MetricTime(1))
+	b.Add(MetricTime(2))
+	batch := b.Batch(2)
+	b.Add(MetricTime(3))
+	b.Reject(batch)
+
+	require.Equal(t, 3, b.Len())
+	require.Equal(t, int64(0), b.MetricsDropped.Get())
+
+	batch = b.Batch(5)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{
+			MetricTime(1),
+			MetricTime(2),
+			MetricTime(3),
+		}, batch)
+}
+
+func TestDiskBuffer_FullDropsOldest(t *testing.T) {
+	dir := tempBufferDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 3)
+	defer b.Close()
+
+	dropped := b.Add(MetricTime(1), MetricTime(2), MetricTime(3), MetricTime(4))
+	require.Equal(t, 1, dropped)
+	require.Equal(t, 3, b.Len())
+	require.Equal(t, int64(1), b.MetricsDropped.Get())
+
+	batch := b.Batch(5)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{
+			MetricTime(2),
+			MetricTime(3),
+			MetricTime(4),
+		}, batch)
+}
+
+func TestDiskBuffer_FullDuringBatch(t *testing.T) {
+	dir := tempBufferDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 3)
+	defer b.Close()
+
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3))
+	batch := b.Batch(2)
+	b.Add(MetricTime(4))
+	b.Accept(batch)
+
+	batch = b.Batch(5)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{
+			MetricTime(3),
+			MetricTime(4),
+		}, batch)
+}
+
+func TestDiskBuffer_Restore(t *testing.T) {
+	dir := tempBufferDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 5)
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3))
+	b.Accept(b.Batch(1))
+	b.Reject(b.Batch(1))
+	require.NoError(t, b.Close())
+
+	b = newTestDiskBuffer(t, dir, 5)
+	defer b.Close()
+
+	require.Equal(t, 2, b.Len())
+	b.Add(MetricTime(4))
+
+	batch := b.Batch(5)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{
+			MetricTime(2),
+			MetricTime(3),
+			MetricTime(4),
+		}, batch)
+}
+
+func TestDiskBuffer_RestoreFieldTypes(t *testing.T) {
+	dir := tempBufferDir(t)
+	defer os.RemoveAll(dir)
+
+	m, err := metric.New(
+		"cpu",
+		map[string]string{"host": "localhost"},
+		map[string]interface{}{
+			"int":    int64(42),
+			"uint":   uint64(42),
+			"float":  42.0,
+			"string": "forty two",
+			"bool":   true,
+		},
+		time.Unix(0, 42),
+		telegraf.Counter,
+	)
+	require.NoError(t, err)
+
+	b := newTestDiskBuffer(t, dir, 5)
+	b.Add(m.Copy())
+	require.NoError(t, b.Close())
+
+	b = newTestDiskBuffer(t, dir, 5)
+	defer b.Close()
+
+	batch := b.Batch(5)
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{m}, batch)
+	require.Equal(t, telegraf.Counter, batch[0].Type())
+}
+
+func TestDiskBuffer_RestoreTruncatedRecord(t *testing.T) {
+	dir := tempBufferDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 5)
+	b.Add(MetricTime(1), MetricTime(2))
+	require.NoError(t, b.Close())
+
+	// Simulate a crash while writing the last record.
+	path := b.segments[0].path
+	require.NoError(t, os.Truncate(path, b.segments[0].size-1))
+
+	b = newTestDiskBuffer(t, dir, 5)
+	defer b.Close()
+
+	require.Equal(t, 1, b.Len())
+	b.Add(MetricTime(3))
+
+	batch := b.Batch(5)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{
+			MetricTime(1),
+			MetricTime(3),
+		}, batch)
+}
+
+func TestDiskBuffer_RestoreCorruptLength(t *testing.T) {
+	dir := tempBufferDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 5)
+	b.Add(MetricTime(1), MetricTime(2))
+	require.NoError(t, b.Close())
+
+	// Overwrite the length of the last record with a length past the end of
+	// the segment.
+	seg := b.segments[0]
+	f, err := os.OpenFile(seg.path, os.O_WRONLY, 0600)
+	require.NoError(t, err)
+	_, err = f.WriteAt([]byte{0xff, 0xff, 0xff, 0xff}, seg.offsets[1])
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	b = newTestDiskBuffer(t, dir, 5)
+	defer b.Close()
+
+	require.Equal(t, 1, b.Len())
+	batch := b.Batch(5)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{
+			MetricTime(1),
+		}, batch)
+}
+
+func TestDiskBuffer_RemovesSentSegments(t *testing.T) {
+	dir := tempBufferDir(t)
+	defer os.RemoveAll(dir)
+
+	b := newTestDiskBuffer(t, dir, 10)
+	defer b.Close()
+	b.segmentLen = 2
+
+	b.Add(MetricTime(1), MetricTime(2), MetricTime(3), MetricTime(4), MetricTime(5))
+	segments, err := filepath.Glob(filepath.Join(dir, "*"+diskSegmentSuffix))
+	require.NoError(t, err)
+	require.Len(t, segments, 3)
+
+	b.Accept(b.Batch(4))
+	segments, err = filepath.Glob(filepath.Join(dir, "*"+diskSegmentSuffix))
+	require.NoError(t, err)
+	require.Len(t, segments, 1)
+
+	batch := b.Batch(5)
+	testutil.RequireMetricsEqual(t,
+		[]telegraf.Metric{
+			MetricTime(5),
+		}, batch)
+}
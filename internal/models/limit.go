@@ -0,0 +1,130 @@
+package models
+
+import (
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+)
+
+const (
+	// Default window over which the distinct series of an input are counted.
+	DEFAULT_SERIES_WINDOW = time.Hour
+
+	// Actions for series over the series_limit, selectable with the
+	// series_limit_action option.
+	SeriesLimitDrop      = "drop"
+	SeriesLimitAggregate = "aggregate"
+
+	// Tag added to the metrics of series merged into an overflow series.
+	SeriesOverflowTag = "series_overflow"
+)
+
+// LimitConfig configures the limits on the metrics emitted by an input.
+type LimitConfig struct {
+	// MetricLimit is the maximum number of metrics per gather interval.
+	// Zero disables the limit.
+	MetricLimit int
+
+	// SeriesLimit is the maximum number of distinct series, by name and
+	// tagset, per SeriesWindow.  Zero disables the limit.
+	SeriesLimit int
+
+	// SeriesWindow is the time after which the seen series are forgotten.
+	SeriesWindow time.Duration
+
+	// SeriesLimitAction is what is done with the metrics of new series over
+	// the limit: they are either dropped or merged into a single overflow
+	// series per measurement.
+	SeriesLimitAction string
+}
+
+// limitDecision is the result of checking a metric against the limits.
+type limitDecision int
+
+const (
+	limitAccept    limitDecision = iota // pass the metric unchanged
+	limitRate                           // drop, over the metric limit
+	limitSeries                         // drop, over the series limit
+	limitAggregate                      // merge into the overflow series
+)
+
+// inputLimiter enforces the metric rate and cardinality limits of an input.
+type inputLimiter struct {
+	sync.Mutex
+	LimitConfig
+
+	metrics     int
+	series      map[uint64]bool
+	windowStart time.Time
+
+	now func() time.Time
+}
+
+func newInputLimiter(config LimitConfig) *inputLimiter {
+	if config.SeriesWindow == 0 {
+		config.SeriesWindow = DEFAULT_SERIES_WINDOW
+	}
+	if config.SeriesLimitAction == "" {
+		config.SeriesLimitAction = SeriesLimitDrop
+	}
+
+	return &inputLimiter{
+		LimitConfig: config,
+		series:      make(map[uint64]bool),
+		now:         time.Now,
+	}
+}
+
+// enabled returns true if any limit is set.
+func (l *inputLimiter) enabled() bool {
+	return l.MetricLimit > 0 || l.SeriesLimit > 0
+}
+
+// reset starts a new gather interval for the metric limit.
+func (l *inputLimiter) reset() {
+	l.Lock()
+	l.metrics = 0
+	l.Unlock()
+}
+
+// check counts the metric against the limits and returns what should be done
+// with it.
+func (l *inputLimiter) check(metric telegraf.Metric) limitDecision {
+	l.Lock()
+	defer l.Unlock()
+
+	if l.MetricLimit > 0 {
+		if l.metrics >= l.MetricLimit {
+			return limitRate
+		}
+		l.metrics++
+	}
+
+	if l.SeriesLimit > 0 {
+		now := l.now()
+		if now.Sub(l.windowStart) >= l.SeriesWindow {
+			l.series = make(map[uint64]bool)
+			l.windowStart = now
+		}
+
+		id := metric.HashID()
+		if !l.series[id] {
+			if len(l.series) >= l.SeriesLimit {
+				if l.SeriesLimitAction == SeriesLimitAggregate {
+					return limitAggregate
+				}
+				return limitSeries
+			}
+			l.series[id] = true
+		}
+	}
+	return limitAccept
+}
+
+// seriesCount returns the number of distinct series in the current window.
+func (l *inputLimiter) seriesCount() int {
+	l.Lock()
+	defer l.Unlock()
+	return len(l.series)
+}
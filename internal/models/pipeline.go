@@ -0,0 +1,13 @@
+package models
+
+// DefaultPipeline is the name of the pipeline of plugins without a pipeline
+// setting.  Its plugins have an empty Pipeline in their config.
+const DefaultPipeline = "default"
+
+// PipelineName returns the name of a pipeline for display.
+func PipelineName(pipeline string) string {
+	if pipeline == "" {
+		return DefaultPipeline
+	}
+	return pipeline
+}
@@ -0,0 +1,160 @@
+package models
+
+import (
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+)
+
+const (
+	// Default time an output stays open once its circuit breaker has tripped.
+	DEFAULT_RETRY_MAX_BACKOFF = time.Minute
+)
+
+// Circuit breaker states, reported in the circuit_state field.
+const (
+	CircuitClosed int64 = iota
+	CircuitHalfOpen
+	CircuitOpen
+)
+
+// retryDecision is the result of asking the retry policy whether an output
+// may be written to.
+type retryDecision int
+
+const (
+	retryAllow retryDecision = iota // write the full buffer
+	retryProbe                      // write a single batch to test the output
+	retrySkip                       // do not write
+)
+
+// RetryConfig configures how an output is retried after a failed write.
+type RetryConfig struct {
+	// InitialBackoff is the time to wait after the first failure; it is
+	// doubled with each consecutive failure.  Zero disables backoff.
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the time between attempts and is the time the circuit
+	// breaker stays open before probing the output.
+	MaxBackoff time.Duration
+
+	// Jitter adds a random amount of up to Jitter to each backoff.
+	Jitter time.Duration
+
+	// MaxFailures is the number of consecutive failures after which the
+	// circuit breaker trips open.  Zero disables the circuit breaker.
+	MaxFailures int
+}
+
+// retryPolicy tracks consecutive write failures of an output to implement
+// exponential backoff and a circuit breaker.
+type retryPolicy struct {
+	sync.Mutex
+	RetryConfig
+
+	failures int
+	state    int64
+	next     time.Time // earliest time the next write may be attempted
+
+	now func() time.Time
+}
+
+func newRetryPolicy(config RetryConfig) *retryPolicy {
+	if config.MaxBackoff < config.InitialBackoff {
+		config.MaxBackoff = config.InitialBackoff
+	}
+	if config.MaxFailures > 0 && config.MaxBackoff == 0 {
+		config.MaxBackoff = DEFAULT_RETRY_MAX_BACKOFF
+	}
+
+	return &retryPolicy{
+		RetryConfig: config,
+		state:       CircuitClosed,
+		now:         time.Now,
+	}
+}
+
+// decide returns whether a write may be attempted now.
+func (r *retryPolicy) decide() retryDecision {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.now().Before(r.next) {
+		return retrySkip
+	}
+
+	switch r.state {
+	case CircuitOpen:
+		r.state = CircuitHalfOpen
+		return retryProbe
+	case CircuitHalfOpen:
+		return retryProbe
+	default:
+		return retryAllow
+	}
+}
+
+// success records a successful write and returns the previous state.
+func (r *retryPolicy) success() int64 {
+	r.Lock()
+	defer r.Unlock()
+
+	prev := r.state
+	r.failures = 0
+	r.state = CircuitClosed
+	r.next = time.Time{}
+	return prev
+}
+
+// failure records a failed write and returns the previous and the new state.
+func (r *retryPolicy) failure() (int64, int64) {
+	r.Lock()
+	defer r.Unlock()
+
+	prev := r.state
+	r.failures++
+	if r.state == CircuitHalfOpen ||
+		(r.MaxFailures > 0 && r.failures >= r.MaxFailures) {
+		r.state = CircuitOpen
+	}
+
+	r.next = r.now().Add(r.backoff() + internal.RandomDuration(r.Jitter))
+	return prev, r.state
+}
+
+// backoff returns the time to wait before the next attempt.
+func (r *retryPolicy) backoff() time.Duration {
+	if r.state == CircuitOpen {
+		return r.MaxBackoff
+	}
+	if r.InitialBackoff == 0 {
+		return 0
+	}
+
+	backoff := r.InitialBackoff
+	for i := 1; i < r.failures && backoff < r.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > r.MaxBackoff {
+		backoff = r.MaxBackoff
+	}
+	return backoff
+}
+
+// retryAfter returns the time until the next write may be attempted.
+func (r *retryPolicy) retryAfter() time.Duration {
+	r.Lock()
+	defer r.Unlock()
+
+	return r.next.Sub(r.now())
+}
+
+// consecutiveFailures returns the number of failed writes since the last
+// successful one.
+func (r *retryPolicy) consecutiveFailures() int {
+	r.Lock()
+	defer r.Unlock()
+
+	return r.failures
+}
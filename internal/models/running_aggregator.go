@@ -65,6 +65,9 @@ type AggregatorConfig struct {
 	MeasurementSuffix string
 	Tags              map[string]string
 	Filter            Filter
+
+	// Pipeline whose metrics are aggregated.
+	Pipeline string
 }
 
 func (r *RunningAggregator) Name() string {
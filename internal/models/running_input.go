@@ -14,24 +14,51 @@ type RunningInput struct {
 	Config *InputConfig
 
 	defaultTags map[string]string
-
-	MetricsGathered selfstat.Stat
-	GatherTime      selfstat.Stat
+	limiter     *inputLimiter
+
+	MetricsGathered         selfstat.Stat
+	GatherTime              selfstat.Stat
+	MetricsRateLimited      selfstat.Stat
+	MetricsSeriesLimited    selfstat.Stat
+	MetricsSeriesAggregated selfstat.Stat
+	Series                  selfstat.Stat
 }
 
 func NewRunningInput(input telegraf.Input, config *InputConfig) *RunningInput {
+	tags := map[string]string{"input": config.Name}
 	return &RunningInput{
-		Input:  input,
-		Config: config,
+		Input:   input,
+		Config:  config,
+		limiter: newInputLimiter(config.Limit),
 		MetricsGathered: selfstat.Register(
 			"gather",
 			"metrics_gathered",
-			map[string]string{"input": config.Name},
+			tags,
 		),
 		GatherTime: selfstat.RegisterTiming(
 			"gather",
 			"gather_time_ns",
-			map[string]string{"input": config.Name},
+			tags,
+		),
+		MetricsRateLimited: selfstat.Register(
+			"gather",
+			"metrics_rate_limited",
+			tags,
+		),
+		MetricsSeriesLimited: selfstat.Register(
+			"gather",
+			"metrics_series_limited",
+			tags,
+		),
+		MetricsSeriesAggregated: selfstat.Register(
+			"gather",
+			"metrics_series_aggregated",
+			tags,
+		),
+		Series: selfstat.Register(
+			"gather",
+			"series",
+			tags,
 		),
 	}
 }
@@ -46,6 +73,12 @@ type InputConfig struct {
 	MeasurementSuffix string
 	Tags              map[string]string
 	Filter            Filter
+
+	// Pipeline receiving the metrics of the input.
+	Pipeline string
+
+	// Limit caps the number of metrics and series emitted by the input.
+	Limit LimitConfig
 }
 
 func (r *RunningInput) Name() string {
@@ -86,12 +119,50 @@ func (r *RunningInput) MakeMetric(metric telegraf.Metric) telegraf.Metric {
 		return nil
 	}
 
+	if r.limiter.enabled() {
+		switch r.limiter.check(m) {
+		case limitRate:
+			r.MetricsRateLimited.Incr(1)
+			m.Drop()
+			return nil
+		case limitSeries:
+			r.MetricsSeriesLimited.Incr(1)
+			m.Drop()
+			return nil
+		case limitAggregate:
+			r.MetricsSeriesAggregated.Incr(1)
+			r.mergeOverflow(m)
+		}
+		r.Series.Set(int64(r.limiter.seriesCount()))
+	}
+
 	r.MetricsGathered.Incr(1)
 	GlobalMetricsGathered.Incr(1)
 	return m
 }
 
+// mergeOverflow merges a metric of a series over the series limit into the
+// overflow series of its measurement, by removing all tags apart from the
+// tags added by the configuration.
+func (r *RunningInput) mergeOverflow(metric telegraf.Metric) {
+	var remove []string
+	for _, tag := range metric.TagList() {
+		if _, ok := r.Config.Tags[tag.Key]; ok {
+			continue
+		}
+		if _, ok := r.defaultTags[tag.Key]; ok {
+			continue
+		}
+		remove = append(remove, tag.Key)
+	}
+	for _, key := range remove {
+		metric.RemoveTag(key)
+	}
+	metric.AddTag(SeriesOverflowTag, "true")
+}
+
 func (r *RunningInput) Gather(acc telegraf.Accumulator) error {
+	r.limiter.reset()
 	start := time.Now()
 	err := r.Input.Gather(acc)
 	elapsed := time.Since(start)
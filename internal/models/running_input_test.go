@@ -256,6 +256,97 @@ func TestMakeMetricNameSuffix(t *testing.T) {
 	require.Equal(t, expected, m)
 }
 
+func TestMakeMetricMetricLimit(t *testing.T) {
+	ri := NewRunningInput(&testInput{}, &InputConfig{
+		Name:  "TestMetricLimit",
+		Limit: LimitConfig{MetricLimit: 2},
+	})
+
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		m, err := metric.New("cpu", map[string]string{},
+			map[string]interface{}{"value": i}, now)
+		require.NoError(t, err)
+		if i < 2 {
+			require.NotNil(t, ri.MakeMetric(m))
+		} else {
+			require.Nil(t, ri.MakeMetric(m))
+		}
+	}
+	require.Equal(t, int64(1), ri.MetricsRateLimited.Get())
+
+	// The limit applies per gather interval.
+	require.NoError(t, ri.Gather(&testutil.Accumulator{}))
+	m, err := metric.New("cpu", map[string]string{},
+		map[string]interface{}{"value": 42}, now)
+	require.NoError(t, err)
+	require.NotNil(t, ri.MakeMetric(m))
+}
+
+func TestMakeMetricSeriesLimit(t *testing.T) {
+	ri := NewRunningInput(&testInput{}, &InputConfig{
+		Name:  "TestSeriesLimit",
+		Limit: LimitConfig{SeriesLimit: 2},
+	})
+
+	now := time.Now()
+	for _, host := range []string{"a", "b", "a", "c"} {
+		m, err := metric.New("cpu", map[string]string{"host": host},
+			map[string]interface{}{"value": 42}, now)
+		require.NoError(t, err)
+		if host == "c" {
+			require.Nil(t, ri.MakeMetric(m))
+		} else {
+			require.NotNil(t, ri.MakeMetric(m))
+		}
+	}
+	require.Equal(t, int64(1), ri.MetricsSeriesLimited.Get())
+	require.Equal(t, int64(2), ri.Series.Get())
+
+	// Series are forgotten at the end of the window.
+	ri.limiter.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	m, err := metric.New("cpu", map[string]string{"host": "c"},
+		map[string]interface{}{"value": 42}, now)
+	require.NoError(t, err)
+	require.NotNil(t, ri.MakeMetric(m))
+	require.Equal(t, int64(1), ri.Series.Get())
+}
+
+func TestMakeMetricSeriesLimitAggregate(t *testing.T) {
+	ri := NewRunningInput(&testInput{}, &InputConfig{
+		Name: "TestSeriesLimitAggregate",
+		Tags: map[string]string{"team": "web"},
+		Limit: LimitConfig{
+			SeriesLimit:       1,
+			SeriesLimitAction: SeriesLimitAggregate,
+		},
+	})
+	ri.SetDefaultTags(map[string]string{"dc": "east"})
+
+	now := time.Now()
+	m, err := metric.New("cpu", map[string]string{"host": "a"},
+		map[string]interface{}{"value": 42}, now)
+	require.NoError(t, err)
+	require.NotNil(t, ri.MakeMetric(m))
+
+	m, err = metric.New("cpu", map[string]string{"host": "b"},
+		map[string]interface{}{"value": 42}, now)
+	require.NoError(t, err)
+	actual := ri.MakeMetric(m)
+
+	expected, err := metric.New("cpu",
+		map[string]string{
+			"dc":              "east",
+			"team":            "web",
+			"series_overflow": "true",
+		},
+		map[string]interface{}{"value": 42},
+		now)
+	require.NoError(t, err)
+	testutil.RequireMetricEqual(t, expected, actual)
+	require.Equal(t, int64(1), ri.MetricsSeriesAggregated.Get())
+}
+
 type testInput struct{}
 
 func (t *testInput) Description() string                   { return "" }
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"fmt"
 	"log"
 	"sync"
 	"sync/atomic"
@@ -16,6 +17,10 @@ const (
 
 	// Default number of metrics kept. It should be a multiple of batch size.
 	DEFAULT_METRIC_BUFFER_LIMIT = 10000
+
+	// Buffer strategies selectable with the buffer_strategy option.
+	BufferStrategyMemory = "memory"
+	BufferStrategyDisk   = "disk"
 )
 
 // OutputConfig containing name and filter
@@ -26,6 +31,14 @@ type OutputConfig struct {
 	FlushInterval     time.Duration
 	MetricBufferLimit int
 	MetricBatchSize   int
+
+	BufferStrategy  string
+	BufferDirectory string
+
+	Retry RetryConfig
+
+	// Pipeline whose metrics are written.
+	Pipeline string
 }
 
 // RunningOutput contains the output configuration
@@ -40,12 +53,20 @@ type RunningOutput struct {
 	MetricBufferLimit int
 	MetricBatchSize   int
 
-	MetricsFiltered selfstat.Stat
-	WriteTime       selfstat.Stat
+	MetricsFiltered     selfstat.Stat
+	WriteTime           selfstat.Stat
+	WriteErrors         selfstat.Stat
+	ConsecutiveFailures selfstat.Stat
+	CircuitState        selfstat.Stat
 
 	BatchReady chan time.Time
 
-	buffer *Buffer
+	buffer MetricBuffer
+	retry  *retryPolicy
+
+	errMutex      sync.Mutex
+	lastError     error
+	lastErrorTime time.Time
 
 	aggMutex sync.Mutex
 }
@@ -87,6 +108,22 @@ func NewRunningOutput(
 			"write_time_ns",
 			map[string]string{"output": name},
 		),
+		WriteErrors: selfstat.Register(
+			"write",
+			"errors",
+			map[string]string{"output": name},
+		),
+		ConsecutiveFailures: selfstat.Register(
+			"write",
+			"consecutive_failures",
+			map[string]string{"output": name},
+		),
+		CircuitState: selfstat.Register(
+			"write",
+			"circuit_state",
+			map[string]string{"output": name},
+		),
+		retry: newRetryPolicy(conf.Retry),
 	}
 
 	return ro
@@ -98,11 +135,33 @@ func (ro *RunningOutput) metricFiltered(metric telegraf.Metric) {
 }
 
 func (ro *RunningOutput) Init() error {
+	err := ro.InitPlugin()
+	if err != nil {
+		return err
+	}
+	return ro.OpenBuffer()
+}
+
+// InitPlugin runs the Init function of the output plugin.
+func (ro *RunningOutput) InitPlugin() error {
 	if p, ok := ro.Output.(telegraf.Initializer); ok {
-		err := p.Init()
+		return p.Init()
+	}
+	return nil
+}
+
+// OpenBuffer opens the disk buffer of the output, it is also used to reopen
+// the buffer after the output was closed.
+func (ro *RunningOutput) OpenBuffer() error {
+	// The disk buffer is opened here instead of in NewRunningOutput so that
+	// errors can be reported; it replaces the still empty memory buffer.
+	if ro.Config.BufferStrategy == BufferStrategyDisk {
+		buffer, err := NewDiskBuffer(ro.Name, ro.Config.BufferDirectory,
+			ro.MetricBufferLimit)
 		if err != nil {
-			return err
+			return fmt.Errorf("could not open disk buffer: %v", err)
 		}
+		ro.buffer = buffer
 	}
 	return nil
 }
@@ -145,6 +204,33 @@ func (ro *RunningOutput) AddMetric(metric telegraf.Metric) {
 // Write writes all metrics to the output, stopping when all have been sent on
 // or error.
 func (ro *RunningOutput) Write() error {
+	ro.pushAggregated()
+
+	switch ro.retry.decide() {
+	case retrySkip:
+		log.Printf("D! [outputs.%s] Backing off, next write attempt in %s",
+			ro.Name, ro.retry.retryAfter())
+		return nil
+	case retryProbe:
+		ro.CircuitState.Set(CircuitHalfOpen)
+		log.Printf("I! [outputs.%s] Circuit breaker half-open, probing output", ro.Name)
+		return ro.writeBatch()
+	}
+
+	return ro.writeAll()
+}
+
+// WriteFinal writes all metrics to the output like Write, ignoring any retry
+// backoff or open circuit breaker.  It is used for the last write before the
+// output is closed, when skipping the write would lose the buffered metrics.
+func (ro *RunningOutput) WriteFinal() error {
+	ro.pushAggregated()
+
+	return ro.writeAll()
+}
+
+// pushAggregated moves the metrics of an aggregating output to the buffer.
+func (ro *RunningOutput) pushAggregated() {
 	if output, ok := ro.Output.(telegraf.AggregatingOutput); ok {
 		ro.aggMutex.Lock()
 		metrics := output.Push()
@@ -154,7 +240,9 @@ func (ro *RunningOutput) Write() error {
 	}
 
 	atomic.StoreInt64(&ro.newMetricsCount, 0)
+}
 
+func (ro *RunningOutput) writeAll() error {
 	// Only process the metrics in the buffer now.  Metrics added while we are
 	// writing will be sent on the next call.
 	nBuffer := ro.buffer.Len()
@@ -177,6 +265,17 @@ func (ro *RunningOutput) Write() error {
 
 // WriteBatch writes a single batch of metrics to the output.
 func (ro *RunningOutput) WriteBatch() error {
+	switch ro.retry.decide() {
+	case retrySkip:
+		return nil
+	case retryProbe:
+		ro.CircuitState.Set(CircuitHalfOpen)
+	}
+
+	return ro.writeBatch()
+}
+
+func (ro *RunningOutput) writeBatch() error {
 	batch := ro.buffer.Batch(ro.MetricBatchSize)
 	if len(batch) == 0 {
 		return nil
@@ -197,6 +296,20 @@ func (ro *RunningOutput) Close() {
 	if err != nil {
 		log.Printf("E! [outputs.%s] Error closing output: %v", ro.Name, err)
 	}
+
+	err = ro.buffer.Close()
+	if err != nil {
+		log.Printf("E! [outputs.%s] Error closing buffer: %v", ro.Name, err)
+	}
+}
+
+// Release closes the buffer of an output that was never connected, such as
+// an output added by a reload that failed.
+func (ro *RunningOutput) Release() {
+	err := ro.buffer.Close()
+	if err != nil {
+		log.Printf("E! [outputs.%s] Error closing buffer: %v", ro.Name, err)
+	}
 }
 
 func (ro *RunningOutput) write(metrics []telegraf.Metric) error {
@@ -212,11 +325,59 @@ func (ro *RunningOutput) write(metrics []telegraf.Metric) error {
 	elapsed := time.Since(start)
 	ro.WriteTime.Incr(elapsed.Nanoseconds())
 
-	if err == nil {
-		log.Printf("D! [outputs.%s] wrote batch of %d metrics in %s\n",
-			ro.Name, len(metrics), elapsed)
+	if err != nil {
+		ro.writeFailed(err)
+		return err
+	}
+
+	log.Printf("D! [outputs.%s] wrote batch of %d metrics in %s\n",
+		ro.Name, len(metrics), elapsed)
+	ro.writeSucceeded()
+	return nil
+}
+
+// writeFailed updates the retry policy after a failed write.
+func (ro *RunningOutput) writeFailed(err error) {
+	ro.WriteErrors.Incr(1)
+
+	ro.errMutex.Lock()
+	ro.lastError = err
+	ro.lastErrorTime = time.Now()
+	ro.errMutex.Unlock()
+
+	prev, state := ro.retry.failure()
+	ro.CircuitState.Set(state)
+	ro.ConsecutiveFailures.Set(int64(ro.retry.consecutiveFailures()))
+
+	if state == CircuitOpen && prev != CircuitOpen {
+		log.Printf("E! [outputs.%s] Circuit breaker open after %d consecutive failures, next write attempt in %s",
+			ro.Name, ro.retry.consecutiveFailures(), ro.retry.retryAfter())
+	} else if ro.retry.InitialBackoff > 0 && state == CircuitClosed {
+		log.Printf("D! [outputs.%s] Write failed, next write attempt in %s",
+			ro.Name, ro.retry.retryAfter())
 	}
-	return err
+}
+
+// writeSucceeded resets the retry policy after a successful write.
+func (ro *RunningOutput) writeSucceeded() {
+	if prev := ro.retry.success(); prev != CircuitClosed {
+		log.Printf("I! [outputs.%s] Circuit breaker closed", ro.Name)
+	}
+	ro.CircuitState.Set(CircuitClosed)
+	ro.ConsecutiveFailures.Set(0)
+}
+
+// LastError returns the time and error of the most recent failed write, the
+// error is nil if no write has failed.
+func (ro *RunningOutput) LastError() (time.Time, error) {
+	ro.errMutex.Lock()
+	defer ro.errMutex.Unlock()
+	return ro.lastErrorTime, ro.lastError
+}
+
+// BufferLength returns the number of metrics in the buffer.
+func (ro *RunningOutput) BufferLength() int {
+	return ro.buffer.Len()
 }
 
 func (ro *RunningOutput) LogBufferStatus() {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/testutil"
@@ -412,6 +413,152 @@ func TestRunningOutputWriteFailOrder3(t *testing.T) {
 	assert.Equal(t, expected, m.Metrics())
 }
 
+func TestRunningOutputRetryBackoff(t *testing.T) {
+	conf := &OutputConfig{
+		Filter: Filter{},
+		Retry: RetryConfig{
+			InitialBackoff: time.Minute,
+			MaxBackoff:     time.Hour,
+		},
+	}
+
+	m := &mockOutput{}
+	m.failWrite = true
+	ro := NewRunningOutput("test", m, conf, 1000, 10000)
+	now := time.Unix(0, 0)
+	ro.retry.now = func() time.Time { return now }
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+
+	require.Error(t, ro.Write())
+	require.Equal(t, int64(1), ro.ConsecutiveFailures.Get())
+
+	// Writes are skipped until the backoff has elapsed.
+	m.failWrite = false
+	require.NoError(t, ro.Write())
+	assert.Len(t, m.Metrics(), 0)
+
+	// Backoff is doubled after the second failure.
+	now = now.Add(time.Minute)
+	m.failWrite = true
+	require.Error(t, ro.Write())
+	require.Equal(t, 2*time.Minute, ro.retry.retryAfter())
+
+	now = now.Add(2 * time.Minute)
+	m.failWrite = false
+	require.NoError(t, ro.Write())
+	assert.Len(t, m.Metrics(), 5)
+	require.Equal(t, int64(0), ro.ConsecutiveFailures.Get())
+}
+
+func TestRunningOutputWriteFinalIgnoresBackoff(t *testing.T) {
+	conf := &OutputConfig{
+		Filter: Filter{},
+		Retry: RetryConfig{
+			MaxBackoff:  time.Hour,
+			MaxFailures: 1,
+		},
+	}
+
+	m := &mockOutput{}
+	m.failWrite = true
+	ro := NewRunningOutput("test", m, conf, 1000, 10000)
+	now := time.Unix(0, 0)
+	ro.retry.now = func() time.Time { return now }
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+
+	require.Error(t, ro.Write())
+	require.Equal(t, CircuitOpen, ro.CircuitState.Get())
+
+	m.failWrite = false
+	require.NoError(t, ro.Write())
+	assert.Len(t, m.Metrics(), 0)
+
+	require.NoError(t, ro.WriteFinal())
+	assert.Len(t, m.Metrics(), 5)
+	require.Equal(t, CircuitClosed, ro.CircuitState.Get())
+}
+
+func TestRunningOutputCircuitBreaker(t *testing.T) {
+	conf := &OutputConfig{
+		Filter: Filter{},
+		Retry: RetryConfig{
+			MaxBackoff:  time.Minute,
+			MaxFailures: 2,
+		},
+	}
+
+	m := &mockOutput{}
+	m.failWrite = true
+	ro := NewRunningOutput("test", m, conf, 4, 12)
+	ro.WriteErrors.Set(0)
+	now := time.Unix(0, 0)
+	ro.retry.now = func() time.Time { return now }
+
+	for _, metric := range first5 {
+		ro.AddMetric(metric)
+	}
+	for _, metric := range next5 {
+		ro.AddMetric(metric)
+	}
+
+	require.Error(t, ro.Write())
+	require.Equal(t, CircuitClosed, ro.CircuitState.Get())
+	require.Error(t, ro.Write())
+	require.Equal(t, CircuitOpen, ro.CircuitState.Get())
+	require.Equal(t, int64(2), ro.WriteErrors.Get())
+
+	// While open nothing is written.
+	m.failWrite = false
+	require.NoError(t, ro.Write())
+	require.NoError(t, ro.WriteBatch())
+	assert.Len(t, m.Metrics(), 0)
+
+	// A failed probe opens the circuit again.
+	now = now.Add(time.Minute)
+	m.failWrite = true
+	require.Error(t, ro.Write())
+	require.Equal(t, CircuitOpen, ro.CircuitState.Get())
+
+	// A successful probe writes a single batch and closes the circuit.
+	now = now.Add(time.Minute)
+	m.failWrite = false
+	require.NoError(t, ro.Write())
+	assert.Len(t, m.Metrics(), 4)
+	require.Equal(t, CircuitClosed, ro.CircuitState.Get())
+
+	require.NoError(t, ro.Write())
+	assert.Len(t, m.Metrics(), 10)
+}
+
+func TestRunningOutputNewKeepsStats(t *testing.T) {
+	conf := &OutputConfig{
+		Filter: Filter{},
+		Retry: RetryConfig{
+			MaxBackoff:  time.Minute,
+			MaxFailures: 1,
+		},
+	}
+
+	m := &mockOutput{}
+	m.failWrite = true
+	ro := NewRunningOutput("test", m, conf, 1000, 10000)
+	ro.AddMetric(first5[0])
+	require.Error(t, ro.Write())
+	require.Equal(t, CircuitOpen, ro.CircuitState.Get())
+
+	// Creating an output with the same name, as on a reload or when the
+	// configuration is checked, does not reset the stats of the running one.
+	NewRunningOutput("test", &mockOutput{}, conf, 1000, 10000)
+	require.Equal(t, CircuitOpen, ro.CircuitState.Get())
+	require.Equal(t, int64(1), ro.ConsecutiveFailures.Get())
+}
+
 type mockOutput struct {
 	sync.Mutex
 
@@ -12,6 +12,8 @@ type RunningProcessor struct {
 	sync.Mutex
 	Processor telegraf.Processor
 	Config    *ProcessorConfig
+
+	stopped bool
 }
 
 type RunningProcessors []*RunningProcessor
@@ -25,6 +27,9 @@ type ProcessorConfig struct {
 	Name   string
 	Order  int64
 	Filter Filter
+
+	// Pipeline whose metrics are processed.
+	Pipeline string
 }
 
 func (rp *RunningProcessor) metricFiltered(metric telegraf.Metric) {
@@ -50,10 +55,38 @@ func (rp *RunningProcessor) Init() error {
 	return nil
 }
 
+// Start starts a streaming processor, the metrics it emits are added to acc.
+func (rp *RunningProcessor) Start(acc telegraf.Accumulator) error {
+	if p, ok := rp.Processor.(telegraf.StreamingProcessor); ok {
+		return p.Start(acc)
+	}
+	return nil
+}
+
+// Stop stops a streaming processor, it waits for a call to Apply in progress.
+// Metrics applied to a stopped processor are passed on unmodified.
+func (rp *RunningProcessor) Stop() {
+	rp.Lock()
+	defer rp.Unlock()
+
+	if rp.stopped {
+		return
+	}
+	rp.stopped = true
+
+	if p, ok := rp.Processor.(telegraf.StreamingProcessor); ok {
+		p.Stop()
+	}
+}
+
 func (rp *RunningProcessor) Apply(in ...telegraf.Metric) []telegraf.Metric {
 	rp.Lock()
 	defer rp.Unlock()
 
+	if rp.stopped {
+		return in
+	}
+
 	ret := []telegraf.Metric{}
 
 	for _, metric := range in {
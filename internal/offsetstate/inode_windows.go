@@ -0,0 +1,11 @@
+// +build windows
+
+package offsetstate
+
+import "os"
+
+// inode returns zero as inode numbers are not available on Windows, rotation
+// is only detected by the fingerprint.
+func inode(info os.FileInfo) uint64 {
+	return 0
+}
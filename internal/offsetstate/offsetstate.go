@@ -0,0 +1,217 @@
+// Package offsetstate persists the read offsets of tailed files in a state
+// file, so that reading can resume where it stopped after a restart.
+//
+// Along with the offset, the inode and a fingerprint of the beginning of the
+// file are recorded, to detect files that were rotated or truncated while
+// they were not read.
+package offsetstate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// fingerprintSize is the maximum number of bytes at the beginning of a file
+// used for its fingerprint.
+const fingerprintSize = 1024
+
+// Entry is the saved state of a file.
+type Entry struct {
+	// Offset is the position up to which the file was read.
+	Offset int64 `json:"offset"`
+
+	// Inode is the inode number of the file, zero if not supported.
+	Inode uint64 `json:"inode,omitempty"`
+
+	// Fingerprint is the hash of the beginning of the file, up to the offset.
+	Fingerprint string `json:"fingerprint"`
+}
+
+type stateFile struct {
+	Files map[string]Entry `json:"files"`
+}
+
+// State is the set of offsets stored in a state file.
+type State struct {
+	sync.Mutex
+	path    string
+	entries map[string]Entry
+}
+
+var (
+	states      = make(map[string]*State)
+	statesMutex sync.Mutex
+)
+
+// Open returns the state stored in the file at path.  The state is shared by
+// all plugins using the same path, and loaded only once per process so that
+// offsets recorded before a reload are kept.  A missing file is not an error.
+func Open(path string) (*State, error) {
+	statesMutex.Lock()
+	defer statesMutex.Unlock()
+
+	if s, ok := states[path]; ok {
+		return s, nil
+	}
+
+	s, err := load(path)
+	if err != nil {
+		return nil, err
+	}
+	states[path] = s
+	return s, nil
+}
+
+func load(path string) (*State, error) {
+	s := &State{
+		path:    path,
+		entries: make(map[string]Entry),
+	}
+
+	buf, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var sf stateFile
+	if err := json.Unmarshal(buf, &sf); err != nil {
+		return nil, fmt.Errorf("invalid offsets file %s: %v", path, err)
+	}
+	for file, entry := range sf.Files {
+		s.entries[file] = entry
+	}
+	return s, nil
+}
+
+// Resume returns the offset to resume reading the file at path from.  The
+// saved offset is returned if the file is unchanged up to it, the beginning
+// of the file if it was rotated or truncated.  It returns false if there is
+// no saved offset for the file.
+func (s *State) Resume(path string) (int64, bool) {
+	s.Lock()
+	entry, ok := s.entries[path]
+	s.Unlock()
+	if !ok {
+		return 0, false
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, false
+	}
+	if ino := inode(info); ino != 0 && entry.Inode != 0 && ino != entry.Inode {
+		log.Printf("D! [offsetstate] File %s was rotated, reading from the beginning", path)
+		return 0, true
+	}
+	if info.Size() < entry.Offset {
+		log.Printf("D! [offsetstate] File %s was truncated, reading from the beginning", path)
+		return 0, true
+	}
+	fp, err := fingerprint(path, entry.Offset)
+	if err != nil || fp != entry.Fingerprint {
+		log.Printf("D! [offsetstate] File %s was replaced, reading from the beginning", path)
+		return 0, true
+	}
+	return entry.Offset, true
+}
+
+// Record sets the offset of the file at path.  The offset is only saved to
+// the state file by Save.
+func (s *State) Record(path string, offset int64) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	fp, err := fingerprint(path, offset)
+	if err != nil {
+		return err
+	}
+
+	s.Lock()
+	s.entries[path] = Entry{
+		Offset:      offset,
+		Inode:       inode(info),
+		Fingerprint: fp,
+	}
+	s.Unlock()
+	return nil
+}
+
+// Save writes the offsets to the state file.  The file is replaced
+// atomically, so that it is never left partially written.  The entries of
+// files that no longer exist are removed.
+func (s *State) Save() error {
+	s.Lock()
+	defer s.Unlock()
+
+	for path := range s.entries {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			delete(s.entries, path)
+		}
+	}
+
+	buf, err := json.Marshal(stateFile{Files: s.entries})
+	if err != nil {
+		return err
+	}
+	return writeFile(s.path, buf)
+}
+
+// writeFile writes the file to a temporary file in the same directory and
+// renames it over the destination.
+func writeFile(path string, buf []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(buf); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// fingerprint returns the hash of the beginning of the file, up to the offset
+// and at most fingerprintSize bytes.
+func fingerprint(path string, offset int64) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	size := offset
+	if size > fingerprintSize {
+		size = fingerprintSize
+	}
+
+	h := sha256.New()
+	n, err := io.CopyN(h, f, size)
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	if n != size {
+		return "", fmt.Errorf("file %s is shorter than %d bytes", path, size)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
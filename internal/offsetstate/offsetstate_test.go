@@ -0,0 +1,121 @@
+package offsetstate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "offsetstate")
+	require.NoError(t, err)
+	return dir
+}
+
+func writeLog(t *testing.T, path string, content string) {
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	logFile := filepath.Join(dir, "app.log")
+	goneFile := filepath.Join(dir, "gone.log")
+	writeLog(t, logFile, "line 1\nline 2\n")
+	writeLog(t, goneFile, "line 1\n")
+
+	stateFile := filepath.Join(dir, "offsets.json")
+	s, err := load(stateFile)
+	require.NoError(t, err)
+	require.NoError(t, s.Record(logFile, 7))
+	require.NoError(t, s.Record(goneFile, 7))
+	require.NoError(t, os.Remove(goneFile))
+	require.NoError(t, s.Save())
+
+	s, err = load(stateFile)
+	require.NoError(t, err)
+	offset, ok := s.Resume(logFile)
+	require.True(t, ok)
+	require.Equal(t, int64(7), offset)
+
+	_, ok = s.entries[goneFile]
+	require.False(t, ok)
+
+	// Only the state file is left, the temporary files are removed.
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+}
+
+func TestResume(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	logFile := filepath.Join(dir, "app.log")
+	tests := []struct {
+		name   string
+		modify func()
+		offset int64
+	}{
+		{
+			name:   "appended",
+			modify: func() { writeLog(t, logFile, "line 1\nline 2\nline 3\n") },
+			offset: 7,
+		},
+		{
+			name:   "truncated",
+			modify: func() { writeLog(t, logFile, "") },
+			offset: 0,
+		},
+		{
+			name:   "replaced in place",
+			modify: func() { writeLog(t, logFile, "other 1\nother 2\n") },
+			offset: 0,
+		},
+		{
+			name: "rotated",
+			modify: func() {
+				require.NoError(t, os.Rename(logFile, logFile+".1"))
+				writeLog(t, logFile, "line 1\nline 2\n")
+			},
+			offset: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeLog(t, logFile, "line 1\nline 2\n")
+			s, err := load(filepath.Join(dir, "offsets.json"))
+			require.NoError(t, err)
+			require.NoError(t, s.Record(logFile, 7))
+
+			tt.modify()
+			offset, ok := s.Resume(logFile)
+			require.True(t, ok)
+			require.Equal(t, tt.offset, offset)
+		})
+	}
+}
+
+func TestResumeUnknownFile(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	s, err := load(filepath.Join(dir, "offsets.json"))
+	require.NoError(t, err)
+	_, ok := s.Resume(filepath.Join(dir, "app.log"))
+	require.False(t, ok)
+}
+
+func TestLoadInvalid(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	stateFile := filepath.Join(dir, "offsets.json")
+	writeLog(t, stateFile, "{")
+	_, err := load(stateFile)
+	require.Error(t, err)
+}
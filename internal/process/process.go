@@ -0,0 +1,290 @@
+// Package process runs long-lived child processes, restarting them with a
+// backoff when they exit.
+package process
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+)
+
+// ErrNotRunning is returned when writing to or signaling a process that has
+// exited and was not restarted yet.
+var ErrNotRunning = errors.New("process is not running")
+
+// MaxLineSize is the maximum length of a line read by ReadLines.
+const MaxLineSize = bufio.MaxScanTokenSize
+
+// stopTimeout is how long a process may take to exit once its stdin is
+// closed before it is killed.
+const stopTimeout = 5 * time.Second
+
+// Process is a child process that is restarted when it exits.
+type Process struct {
+	// Name is the name of the plugin running the process, such as
+	// "inputs.execd", used in the log messages.
+	Name string
+
+	// ReadStdoutFn and ReadStderrFn are called with the output of every
+	// instance of the process, they must read until the reader returns an
+	// error.  The output is discarded if they are nil.
+	ReadStdoutFn func(io.Reader)
+	ReadStderrFn func(io.Reader)
+
+	// RestartDelay is the delay before the process is restarted, it is
+	// doubled every time the process exits, up to MaxRestartDelay.  The delay
+	// is reset once the process ran for longer than MaxRestartDelay.
+	RestartDelay    time.Duration
+	MaxRestartDelay time.Duration
+
+	name string
+	args []string
+
+	mu    sync.Mutex
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New returns the process running the command, the first element is the
+// program and the others are its arguments.
+func New(command []string) (*Process, error) {
+	if len(command) == 0 {
+		return nil, errors.New("no command specified")
+	}
+	return &Process{
+		RestartDelay:    10 * time.Second,
+		MaxRestartDelay: 5 * time.Minute,
+		name:            command[0],
+		args:            command[1:],
+	}, nil
+}
+
+// Start starts the process, it returns an error if the process cannot be
+// started the first time.  Afterwards the process is restarted in the
+// background until Stop is called.
+func (p *Process) Start() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+
+	done, err := p.start()
+	if err != nil {
+		cancel()
+		return err
+	}
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		p.run(ctx, done)
+	}()
+	return nil
+}
+
+// Stop closes the stdin of the process and waits for it to exit, the process
+// is killed if it does not exit in time.
+func (p *Process) Stop() {
+	if p.cancel != nil {
+		p.cancel()
+	}
+	p.wg.Wait()
+}
+
+// Write writes to the stdin of the running process.
+func (p *Process) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	stdin := p.stdin
+	p.mu.Unlock()
+
+	if stdin == nil {
+		return 0, ErrNotRunning
+	}
+	return stdin.Write(b)
+}
+
+// Signal sends the signal to the running process.
+func (p *Process) Signal(sig os.Signal) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cmd == nil {
+		return ErrNotRunning
+	}
+	return p.cmd.Process.Signal(sig)
+}
+
+// start starts an instance of the process, the returned channel receives
+// the result of the process once it exited and its output was read.
+func (p *Process) start() (<-chan error, error) {
+	cmd := exec.Command(p.name, p.args...)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("error opening stdin pipe: %v", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("error opening stdout pipe: %v", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, fmt.Errorf("error opening stderr pipe: %v", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("error starting process %s: %v", p.name, err)
+	}
+	log.Printf("I! [%s] Started process %s with pid %d", p.Name, p.name, cmd.Process.Pid)
+
+	p.mu.Lock()
+	p.cmd = cmd
+	p.stdin = stdin
+	p.mu.Unlock()
+
+	// The output must be read completely before waiting for the process,
+	// Wait closes the pipes.
+	var readers sync.WaitGroup
+	readers.Add(2)
+	go func() {
+		defer readers.Done()
+		read(stdout, p.ReadStdoutFn)
+	}()
+	go func() {
+		defer readers.Done()
+		read(stderr, p.ReadStderrFn)
+	}()
+
+	done := make(chan error, 1)
+	go func() {
+		readers.Wait()
+		err := cmd.Wait()
+
+		p.mu.Lock()
+		p.cmd = nil
+		p.stdin = nil
+		p.mu.Unlock()
+
+		done <- err
+	}()
+
+	return done, nil
+}
+
+// run restarts the process whenever it exits, until the context is done.
+func (p *Process) run(ctx context.Context, done <-chan error) {
+	delay := p.RestartDelay
+	started := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			p.stop(done)
+			return
+		case err := <-done:
+			if err != nil {
+				log.Printf("E! [%s] Process %s exited: %v", p.Name, p.name, err)
+			} else {
+				log.Printf("E! [%s] Process %s exited", p.Name, p.name)
+			}
+		}
+
+		if time.Since(started) > p.MaxRestartDelay {
+			delay = p.RestartDelay
+		}
+
+		for {
+			log.Printf("I! [%s] Restarting process %s in %s", p.Name, p.name, delay)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+
+			delay *= 2
+			if delay > p.MaxRestartDelay {
+				delay = p.MaxRestartDelay
+			}
+
+			var err error
+			started = time.Now()
+			done, err = p.start()
+			if err == nil {
+				break
+			}
+			log.Printf("E! [%s] %v", p.Name, err)
+		}
+	}
+}
+
+// stop closes the stdin of the running process and waits for it to exit.
+func (p *Process) stop(done <-chan error) {
+	p.mu.Lock()
+	if p.stdin != nil {
+		p.stdin.Close()
+	}
+	p.mu.Unlock()
+
+	select {
+	case <-done:
+		return
+	case <-time.After(stopTimeout):
+	}
+
+	p.mu.Lock()
+	if p.cmd != nil {
+		log.Printf("W! [%s] Killing process %s, it did not exit in time", p.Name, p.name)
+		p.cmd.Process.Kill()
+	}
+	p.mu.Unlock()
+	<-done
+}
+
+func read(r io.Reader, fn func(io.Reader)) {
+	if fn == nil {
+		fn = func(r io.Reader) { io.Copy(ioutil.Discard, r) }
+	}
+	fn(r)
+}
+
+// ReadLines calls lineFn with each line read from r, without the line
+// ending, until r returns an error.  The line is only valid until lineFn
+// returns.  Lines longer than MaxLineSize are skipped and reported to errFn
+// with bufio.ErrTooLong, so that a single long line does not stop the output
+// of the process from being read.  Errors of r other than io.EOF are
+// reported to errFn as well.
+func ReadLines(r io.Reader, lineFn func([]byte), errFn func(error)) {
+	reader := bufio.NewReaderSize(r, MaxLineSize)
+	for {
+		line, isPrefix, err := reader.ReadLine()
+		if err != nil {
+			if err != io.EOF {
+				errFn(err)
+			}
+			return
+		}
+		if !isPrefix {
+			lineFn(line)
+			continue
+		}
+
+		errFn(bufio.ErrTooLong)
+		for isPrefix {
+			_, isPrefix, err = reader.ReadLine()
+			if err != nil {
+				if err != io.EOF {
+					errFn(err)
+				}
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// +build !windows
+
+package process
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func readLines(lines chan<- string) func(io.Reader) {
+	return func(r io.Reader) {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			// Lines are dropped once the test stopped reading.
+			select {
+			case lines <- scanner.Text():
+			default:
+			}
+		}
+	}
+}
+
+func TestNewNoCommand(t *testing.T) {
+	_, err := New(nil)
+	require.EqualError(t, err, "no command specified")
+}
+
+func TestWrite(t *testing.T) {
+	p, err := New([]string{"cat"})
+	require.NoError(t, err)
+
+	lines := make(chan string, 10)
+	p.ReadStdoutFn = readLines(lines)
+	require.NoError(t, p.Start())
+
+	_, err = p.Write([]byte("hello\n"))
+	require.NoError(t, err)
+	require.Equal(t, "hello", <-lines)
+
+	// cat exits once its stdin is closed
+	p.Stop()
+	_, err = p.Write([]byte("hello\n"))
+	require.Equal(t, ErrNotRunning, err)
+}
+
+func TestRestart(t *testing.T) {
+	p, err := New([]string{"sh", "-c", "echo started; echo error >&2"})
+	require.NoError(t, err)
+	p.RestartDelay = time.Millisecond
+	p.MaxRestartDelay = 10 * time.Millisecond
+
+	lines := make(chan string, 10)
+	errors := make(chan string, 10)
+	p.ReadStdoutFn = readLines(lines)
+	p.ReadStderrFn = readLines(errors)
+	require.NoError(t, p.Start())
+	defer p.Stop()
+
+	for i := 0; i < 3; i++ {
+		require.Equal(t, "started", <-lines)
+		require.Equal(t, "error", <-errors)
+	}
+}
+
+func TestStartError(t *testing.T) {
+	p, err := New([]string{"/nonexistent/command"})
+	require.NoError(t, err)
+	require.Error(t, p.Start())
+}
+
+func TestReadLines(t *testing.T) {
+	long := strings.Repeat("a", MaxLineSize+1)
+	r := strings.NewReader("first\r\n" + long + "\nsecond\n" + long + "\nthird")
+
+	var lines []string
+	var errs []error
+	ReadLines(r,
+		func(line []byte) { lines = append(lines, string(line)) },
+		func(err error) { errs = append(errs, err) })
+
+	// The long lines are skipped and reading continues after them.
+	require.Equal(t, []string{"first", "second", "third"}, lines)
+	require.Equal(t, []error{bufio.ErrTooLong, bufio.ErrTooLong}, errs)
+}
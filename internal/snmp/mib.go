@@ -0,0 +1,415 @@
+// Package snmp loads MIB modules to translate between the names and the
+// numeric OIDs of SNMP objects, without the net-snmp tools.
+package snmp
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultMibPath is the directory the MIB modules are loaded from by default.
+var DefaultMibPath = []string{"/usr/share/snmp/mibs"}
+
+// maxDepth bounds the references followed while resolving a name, so that
+// circular definitions cannot recurse forever.
+const maxDepth = 64
+
+// Node is an object of the OID tree.
+type Node struct {
+	// Name is the name of the object, and Module the name of the module
+	// defining it.  The Module is empty for the ASN.1 roots.
+	Name   string
+	Module string
+
+	Number uint32
+	Parent *Node
+
+	// Kind is the macro or type of the definition, such as OBJECT-TYPE.
+	Kind string
+
+	// Syntax is the name of the type of an OBJECT-TYPE, such as
+	// "PhysAddress" or "SEQUENCE OF IfEntry".
+	Syntax string
+
+	// Access is the MAX-ACCESS, or ACCESS for SMIv1, of an OBJECT-TYPE.
+	Access string
+
+	// Index and Augments are the clauses of a table entry.
+	Index    []string
+	Augments string
+
+	children map[uint32]*Node
+	defined  bool
+}
+
+// OID returns the numeric OID of the node, with a leading dot.
+func (n *Node) OID() string {
+	var parts []string
+	for ; n.Parent != nil; n = n.Parent {
+		parts = append(parts, strconv.FormatUint(uint64(n.Number), 10))
+	}
+	var b strings.Builder
+	for i := len(parts) - 1; i >= 0; i-- {
+		b.WriteString(".")
+		b.WriteString(parts[i])
+	}
+	return b.String()
+}
+
+// Child returns the child node with the given sub-identifier.
+func (n *Node) Child(number uint32) *Node {
+	return n.children[number]
+}
+
+// Children returns the child nodes ordered by sub-identifier.
+func (n *Node) Children() []*Node {
+	children := make([]*Node, 0, len(n.children))
+	for _, c := range n.children {
+		children = append(children, c)
+	}
+	sort.Slice(children, func(i, j int) bool {
+		return children[i].Number < children[j].Number
+	})
+	return children
+}
+
+func (n *Node) child(number uint32) *Node {
+	c, ok := n.children[number]
+	if !ok {
+		c = &Node{Number: number, Parent: n}
+		if n.children == nil {
+			n.children = make(map[uint32]*Node)
+		}
+		n.children[number] = c
+	}
+	return c
+}
+
+// MibTree is the OID tree of a set of MIB modules.
+type MibTree struct {
+	root    *Node
+	modules map[string]*module
+
+	// names indexes the nodes by module and name, byName by name only.
+	names  map[string]map[string]*Node
+	byName map[string]*Node
+}
+
+// wellKnownNodes are the nodes of the SMI, available even if the modules
+// defining them are not loaded.
+var wellKnownNodes = []struct {
+	name   string
+	module string
+	parent string
+	number uint32
+}{
+	{"ccitt", "", "", 0},
+	{"iso", "", "", 1},
+	{"joint-iso-ccitt", "", "", 2},
+	{"org", "SNMPv2-SMI", "iso", 3},
+	{"dod", "SNMPv2-SMI", "org", 6},
+	{"internet", "SNMPv2-SMI", "dod", 1},
+	{"directory", "SNMPv2-SMI", "internet", 1},
+	{"mgmt", "SNMPv2-SMI", "internet", 2},
+	{"mib-2", "SNMPv2-SMI", "mgmt", 1},
+	{"transmission", "SNMPv2-SMI", "mib-2", 10},
+	{"experimental", "SNMPv2-SMI", "internet", 3},
+	{"private", "SNMPv2-SMI", "internet", 4},
+	{"enterprises", "SNMPv2-SMI", "private", 1},
+	{"security", "SNMPv2-SMI", "internet", 5},
+	{"snmpV2", "SNMPv2-SMI", "internet", 6},
+	{"snmpDomains", "SNMPv2-SMI", "snmpV2", 1},
+	{"snmpProxys", "SNMPv2-SMI", "snmpV2", 2},
+	{"snmpModules", "SNMPv2-SMI", "snmpV2", 3},
+}
+
+func newMibTree() *MibTree {
+	t := &MibTree{
+		root:    &Node{},
+		modules: make(map[string]*module),
+		names:   make(map[string]map[string]*Node),
+		byName:  make(map[string]*Node),
+	}
+	for _, wk := range wellKnownNodes {
+		parent := t.root
+		if wk.parent != "" {
+			parent = t.byName[wk.parent]
+		}
+		n := parent.child(wk.number)
+		n.Name, n.Module = wk.name, wk.module
+		t.byName[wk.name] = n
+	}
+	return t
+}
+
+// LoadMibs loads the MIB modules of the files found in the directories,
+// recursively.  Files that cannot be parsed are logged and skipped, as are
+// the directories that do not exist.
+func LoadMibs(paths []string) (*MibTree, error) {
+	t := newMibTree()
+	for _, path := range paths {
+		err := filepath.Walk(path, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+			if err := t.loadFile(file); err != nil {
+				log.Printf("W! [snmp] Skipping MIB file %s: %v", file, err)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	t.build()
+	return t, nil
+}
+
+func (t *MibTree) loadFile(file string) error {
+	src, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	modules, err := parseModules(file, string(src))
+	if err != nil {
+		return err
+	}
+	for _, m := range modules {
+		if other, ok := t.modules[m.name]; ok {
+			log.Printf("D! [snmp] Module %s of %s already loaded from %s", m.name, file, other.file)
+			continue
+		}
+		t.modules[m.name] = m
+	}
+	return nil
+}
+
+// build resolves the objects of all modules into the tree.
+func (t *MibTree) build() {
+	names := make([]string, 0, len(t.modules))
+	for name := range t.modules {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		m := t.modules[name]
+		for _, def := range m.order {
+			if t.resolve(m, def.name, 0) == nil {
+				log.Printf("D! [snmp] Could not resolve %s::%s", m.name, def.name)
+			}
+		}
+	}
+}
+
+// resolve returns the node of the object named in the scope of the module,
+// defined in the module or imported from another one.
+func (t *MibTree) resolve(m *module, name string, depth int) *Node {
+	if depth > maxDepth {
+		return nil
+	}
+	if n, ok := t.names[m.name][name]; ok {
+		return n
+	}
+
+	def, ok := m.objects[name]
+	if !ok {
+		if from, ok := m.imports[name]; ok {
+			if im, ok := t.modules[from]; ok {
+				if n := t.resolve(im, name, depth+1); n != nil {
+					return n
+				}
+			}
+		}
+		return t.byName[name]
+	}
+
+	node := t.root
+	for i, c := range def.oid {
+		if !c.hasNumber {
+			// Only the first component can be a reference to another
+			// object, such as the parent in { ifEntry 1 }.
+			if i != 0 {
+				return nil
+			}
+			node = t.resolve(m, c.name, depth+1)
+			if node == nil {
+				return nil
+			}
+			continue
+		}
+		if c.number < 0 || c.number > 1<<32-1 {
+			return nil
+		}
+		node = node.child(uint32(c.number))
+		if c.name != "" && node.Name == "" {
+			node.Name, node.Module = c.name, m.name
+		}
+	}
+	if node == t.root {
+		return nil
+	}
+
+	// The first definition of an OID names the node, later definitions from
+	// other modules can only be found by their own names.
+	if !node.defined {
+		node.defined = true
+		node.Name = def.name
+		node.Module = m.name
+		node.Kind = def.kind
+		node.Syntax = def.syntax
+		node.Access = def.access
+		node.Index = def.index
+		node.Augments = def.augments
+	}
+
+	if t.names[m.name] == nil {
+		t.names[m.name] = make(map[string]*Node)
+	}
+	t.names[m.name][name] = node
+	if _, ok := t.byName[name]; !ok {
+		t.byName[name] = node
+	}
+	return node
+}
+
+// Lookup finds the deepest node of the OID, which can be numeric such as
+// ".1.3.6.1.2.1.2.2.1.2.1", contain names such as ".iso.3.6" or
+// "ifDescr.1", or be qualified by the module as in "IF-MIB::ifDescr.1".  It
+// returns the node and the sub-identifiers of the OID following it.  If no
+// node of the OID is known, the root node is returned with the complete OID
+// as suffix.
+func (t *MibTree) Lookup(oid string) (*Node, []uint32, error) {
+	var node *Node
+	rest := oid
+	if i := strings.Index(oid, "::"); i >= 0 {
+		module := oid[:i]
+		rest = oid[i+2:]
+		name := rest
+		if j := strings.IndexByte(rest, '.'); j >= 0 {
+			name, rest = rest[:j], rest[j:]
+		} else {
+			rest = ""
+		}
+		n, ok := t.names[module][name]
+		if !ok {
+			if _, ok := t.modules[module]; !ok {
+				return nil, nil, fmt.Errorf("unknown module %s", module)
+			}
+			return nil, nil, fmt.Errorf("unknown object %s::%s", module, name)
+		}
+		node = n
+	}
+
+	rest = strings.TrimPrefix(rest, ".")
+	var suffix []uint32
+	if rest != "" {
+		for i, part := range strings.Split(rest, ".") {
+			number, err := strconv.ParseUint(part, 10, 32)
+			isNumber := err == nil
+
+			switch {
+			case len(suffix) > 0 || node != nil && isNumber && node.Child(uint32(number)) == nil:
+				// Past the known nodes, the components must be numeric.
+				if !isNumber {
+					return nil, nil, fmt.Errorf("invalid OID %s", oid)
+				}
+				suffix = append(suffix, uint32(number))
+			case isNumber:
+				if node == nil {
+					node = t.root
+				}
+				if child := node.Child(uint32(number)); child != nil {
+					node = child
+				} else {
+					suffix = append(suffix, uint32(number))
+				}
+			case i == 0 && node == nil:
+				n, ok := t.byName[part]
+				if !ok {
+					return nil, nil, fmt.Errorf("unknown object %s", part)
+				}
+				node = n
+			default:
+				child := node.childByName(part)
+				if child == nil {
+					return nil, nil, fmt.Errorf("unknown object %s in %s", part, oid)
+				}
+				node = child
+			}
+		}
+	}
+	if node == nil {
+		return nil, nil, fmt.Errorf("invalid OID %q", oid)
+	}
+	return node, suffix, nil
+}
+
+func (n *Node) childByName(name string) *Node {
+	for _, c := range n.children {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// Conventions returns the chain of types of the syntax of the node, such as
+// ["PhysAddress", "OCTET STRING"], following the textual conventions and type
+// assignments through the imports of the modules.
+func (t *MibTree) Conventions(n *Node) []string {
+	var chain []string
+	name, m := n.Syntax, t.modules[n.Module]
+	for i := 0; name != "" && i < maxDepth; i++ {
+		chain = append(chain, name)
+		if m == nil {
+			break
+		}
+
+		def, ok := m.types[name]
+		if !ok {
+			from, ok := m.imports[name]
+			if !ok {
+				break
+			}
+			m = t.modules[from]
+			if m == nil {
+				break
+			}
+			if def, ok = m.types[name]; !ok {
+				break
+			}
+		}
+		name = def.syntax
+	}
+	return chain
+}
+
+// TableIndex returns the names of the INDEX objects of a table entry,
+// following the AUGMENTS clause if the entry extends another table.
+func (t *MibTree) TableIndex(entry *Node) []string {
+	if entry.Augments == "" {
+		return entry.Index
+	}
+	m, ok := t.modules[entry.Module]
+	if !ok {
+		return nil
+	}
+	augmented := t.resolve(m, entry.Augments, 0)
+	if augmented == nil {
+		return nil
+	}
+	return augmented.Index
+}
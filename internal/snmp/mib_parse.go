@@ -0,0 +1,576 @@
+package snmp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenWord
+	tokenNumber
+	tokenString
+	tokenSymbol
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	line int
+}
+
+// lexer splits the source of a MIB file into tokens, skipping the comments.
+type lexer struct {
+	src  string
+	pos  int
+	line int
+}
+
+func isWordChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '-' || c == '_'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func (l *lexer) next() token {
+	l.skipSpaceAndComments()
+	if l.pos >= len(l.src) {
+		return token{kind: tokenEOF, line: l.line}
+	}
+
+	start, line := l.pos, l.line
+	c := l.src[l.pos]
+	switch {
+	case c == '"':
+		// Strings may span lines, a quote is escaped by doubling it.
+		l.pos++
+		for l.pos < len(l.src) {
+			if l.src[l.pos] == '"' {
+				if l.pos+1 < len(l.src) && l.src[l.pos+1] == '"' {
+					l.pos += 2
+					continue
+				}
+				break
+			}
+			if l.src[l.pos] == '\n' {
+				l.line++
+			}
+			l.pos++
+		}
+		l.pos++
+		if l.pos > len(l.src) {
+			l.pos = len(l.src)
+		}
+		return token{kind: tokenString, text: l.src[start:l.pos], line: line}
+	case c == '\'':
+		// Binary and hexadecimal strings such as '00'H.
+		l.pos++
+		for l.pos < len(l.src) && l.src[l.pos] != '\'' {
+			l.pos++
+		}
+		l.pos++
+		for l.pos < len(l.src) && isWordChar(l.src[l.pos]) {
+			l.pos++
+		}
+		if l.pos > len(l.src) {
+			l.pos = len(l.src)
+		}
+		return token{kind: tokenString, text: l.src[start:l.pos], line: line}
+	case isDigit(c) || c == '-' && l.pos+1 < len(l.src) && isDigit(l.src[l.pos+1]):
+		l.pos++
+		for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			l.pos++
+		}
+		return token{kind: tokenNumber, text: l.src[start:l.pos], line: line}
+	case isWordChar(c):
+		for l.pos < len(l.src) && isWordChar(l.src[l.pos]) {
+			if strings.HasPrefix(l.src[l.pos:], "--") {
+				break
+			}
+			l.pos++
+		}
+		return token{kind: tokenWord, text: l.src[start:l.pos], line: line}
+	case strings.HasPrefix(l.src[l.pos:], "::="):
+		l.pos += 3
+	case strings.HasPrefix(l.src[l.pos:], ".."):
+		l.pos += 2
+	default:
+		l.pos++
+	}
+	return token{kind: tokenSymbol, text: l.src[start:l.pos], line: line}
+}
+
+// skipSpaceAndComments skips the white space and the comments, which start
+// with -- and end at the end of the line or at the next --.
+func (l *lexer) skipSpaceAndComments() {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\n':
+			l.line++
+			l.pos++
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f':
+			l.pos++
+		case strings.HasPrefix(l.src[l.pos:], "--"):
+			l.pos += 2
+			for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+				if strings.HasPrefix(l.src[l.pos:], "--") {
+					l.pos += 2
+					break
+				}
+				l.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// oidComponent is a component of an OID value, such as "internet",
+// "mib-2(1)" or "1".
+type oidComponent struct {
+	name   string
+	number int64
+	// hasNumber is false for a component only referencing a name.
+	hasNumber bool
+}
+
+// objectDef is an assignment of an OID value, like the OBJECT-TYPE and
+// MODULE-IDENTITY macros or OBJECT IDENTIFIER values.
+type objectDef struct {
+	name     string
+	kind     string
+	oid      []oidComponent
+	syntax   string
+	access   string
+	index    []string
+	augments string
+}
+
+// typeDef is a type assignment, like a TEXTUAL-CONVENTION.
+type typeDef struct {
+	name     string
+	syntax   string
+	textConv bool
+}
+
+// module is a parsed MIB module.
+type module struct {
+	name    string
+	file    string
+	imports map[string]string
+	objects map[string]*objectDef
+	order   []*objectDef
+	types   map[string]*typeDef
+}
+
+type parser struct {
+	lex  lexer
+	tok  token
+	file string
+}
+
+// parseModules parses the modules of a MIB file.
+func parseModules(file, src string) ([]*module, error) {
+	p := &parser{lex: lexer{src: src, line: 1}, file: file}
+	p.advance()
+
+	var modules []*module
+	for p.tok.kind != tokenEOF {
+		m, err := p.parseModule()
+		if err != nil {
+			return nil, err
+		}
+		modules = append(modules, m)
+	}
+	return modules, nil
+}
+
+func (p *parser) advance() {
+	p.tok = p.lex.next()
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("%s:%d: %s", p.file, p.tok.line, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) is(text string) bool {
+	return p.tok.kind != tokenString && p.tok.text == text
+}
+
+func (p *parser) expect(text string) error {
+	if !p.is(text) {
+		return p.errorf("expected %q, got %q", text, p.tok.text)
+	}
+	p.advance()
+	return nil
+}
+
+func (p *parser) word() (string, error) {
+	if p.tok.kind != tokenWord {
+		return "", p.errorf("expected identifier, got %q", p.tok.text)
+	}
+	text := p.tok.text
+	p.advance()
+	return text, nil
+}
+
+// skipBalanced skips a group starting at the current open token up to the
+// matching close token.
+func (p *parser) skipBalanced(open, close string) error {
+	depth := 0
+	for {
+		switch {
+		case p.tok.kind == tokenEOF:
+			return p.errorf("unexpected end of file, missing %q", close)
+		case p.is(open):
+			depth++
+		case p.is(close):
+			depth--
+		}
+		p.advance()
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+func (p *parser) parseModule() (*module, error) {
+	name, err := p.word()
+	if err != nil {
+		return nil, err
+	}
+	m := &module{
+		name:    name,
+		file:    p.file,
+		imports: make(map[string]string),
+		objects: make(map[string]*objectDef),
+		types:   make(map[string]*typeDef),
+	}
+
+	// The header may contain an OID and tagging options up to BEGIN.
+	for !p.is("::=") {
+		if p.tok.kind == tokenEOF {
+			return nil, p.errorf("unexpected end of file in module header")
+		}
+		p.advance()
+	}
+	p.advance()
+	if err := p.expect("BEGIN"); err != nil {
+		return nil, err
+	}
+
+	for !p.is("END") {
+		if p.tok.kind == tokenEOF {
+			return nil, p.errorf("unexpected end of file, missing END of module %s", name)
+		}
+		if err := p.parseStatement(m); err != nil {
+			return nil, err
+		}
+	}
+	p.advance()
+	return m, nil
+}
+
+func (p *parser) parseStatement(m *module) error {
+	switch {
+	case p.is("IMPORTS"):
+		p.advance()
+		return p.parseImports(m)
+	case p.is("EXPORTS"):
+		for !p.is(";") && p.tok.kind != tokenEOF {
+			p.advance()
+		}
+		p.advance()
+		return nil
+	}
+
+	name, err := p.word()
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case p.is("MACRO"):
+		// Macro definitions of the SMI modules are skipped.
+		for !p.is("END") {
+			if p.tok.kind == tokenEOF {
+				return p.errorf("unexpected end of file in macro %s", name)
+			}
+			p.advance()
+		}
+		p.advance()
+		return nil
+	case p.is("::="):
+		p.advance()
+		return p.parseTypeAssignment(m, name)
+	default:
+		return p.parseValueAssignment(m, name)
+	}
+}
+
+func (p *parser) parseImports(m *module) error {
+	var symbols []string
+	for !p.is(";") {
+		switch {
+		case p.tok.kind == tokenEOF:
+			return p.errorf("unexpected end of file in imports")
+		case p.is("FROM"):
+			p.advance()
+			from, err := p.word()
+			if err != nil {
+				return err
+			}
+			for _, symbol := range symbols {
+				m.imports[symbol] = from
+			}
+			symbols = symbols[:0]
+		case p.tok.kind == tokenWord:
+			symbols = append(symbols, p.tok.text)
+			p.advance()
+		default:
+			p.advance()
+		}
+	}
+	p.advance()
+	return nil
+}
+
+func (p *parser) parseTypeAssignment(m *module, name string) error {
+	// Some modules assign OID values without a type, like name ::= { 1 3 }.
+	if p.is("{") {
+		oid, err := p.parseOID()
+		if err != nil {
+			return err
+		}
+		m.addObject(&objectDef{name: name, kind: "OBJECT IDENTIFIER", oid: oid})
+		return nil
+	}
+
+	def := &typeDef{name: name}
+	if p.is("TEXTUAL-CONVENTION") {
+		def.textConv = true
+		for !p.is("SYNTAX") {
+			if p.tok.kind == tokenEOF {
+				return p.errorf("unexpected end of file in textual convention %s", name)
+			}
+			p.advance()
+		}
+		p.advance()
+	}
+
+	syntax, err := p.parseSyntax()
+	if err != nil {
+		return err
+	}
+	def.syntax = syntax
+	m.types[name] = def
+	return nil
+}
+
+// parseSyntax parses a type, returning its name: the referenced type, or the
+// builtin type such as "OCTET STRING" or "SEQUENCE OF IfEntry".  Named
+// numbers and constraints are skipped.
+func (p *parser) parseSyntax() (string, error) {
+	if p.is("[") {
+		if err := p.skipBalanced("[", "]"); err != nil {
+			return "", err
+		}
+	}
+	if p.is("IMPLICIT") || p.is("EXPLICIT") {
+		p.advance()
+	}
+
+	name, err := p.word()
+	if err != nil {
+		return "", err
+	}
+	switch name {
+	case "OCTET", "BIT":
+		if err := p.expect("STRING"); err != nil {
+			return "", err
+		}
+		name += " STRING"
+	case "OBJECT":
+		if err := p.expect("IDENTIFIER"); err != nil {
+			return "", err
+		}
+		name += " IDENTIFIER"
+	case "SEQUENCE":
+		if p.is("OF") {
+			p.advance()
+			entry, err := p.word()
+			if err != nil {
+				return "", err
+			}
+			return "SEQUENCE OF " + entry, nil
+		}
+		if p.is("{") {
+			return name, p.skipBalanced("{", "}")
+		}
+	case "CHOICE":
+		if p.is("{") {
+			return name, p.skipBalanced("{", "}")
+		}
+	}
+
+	if p.is("{") {
+		if err := p.skipBalanced("{", "}"); err != nil {
+			return "", err
+		}
+	}
+	if p.is("(") {
+		if err := p.skipBalanced("(", ")"); err != nil {
+			return "", err
+		}
+	}
+	return name, nil
+}
+
+func (p *parser) parseValueAssignment(m *module, name string) error {
+	def := &objectDef{name: name, kind: p.tok.text}
+	if p.is("OBJECT") {
+		p.advance()
+		if err := p.expect("IDENTIFIER"); err != nil {
+			return err
+		}
+		def.kind = "OBJECT IDENTIFIER"
+	}
+
+	for !p.is("::=") {
+		switch {
+		case p.tok.kind == tokenEOF:
+			return p.errorf("unexpected end of file in definition of %s", name)
+		case p.is("SYNTAX"):
+			p.advance()
+			syntax, err := p.parseSyntax()
+			if err != nil {
+				return err
+			}
+			if def.syntax == "" {
+				def.syntax = syntax
+			}
+		case p.is("ACCESS") || p.is("MAX-ACCESS"):
+			p.advance()
+			if def.access == "" {
+				def.access = p.tok.text
+			}
+			p.advance()
+		case p.is("INDEX"):
+			p.advance()
+			index, err := p.parseNameList()
+			if err != nil {
+				return err
+			}
+			def.index = index
+		case p.is("AUGMENTS"):
+			p.advance()
+			augments, err := p.parseNameList()
+			if err != nil {
+				return err
+			}
+			if len(augments) > 0 {
+				def.augments = augments[0]
+			}
+		case p.is("{"):
+			if err := p.skipBalanced("{", "}"); err != nil {
+				return err
+			}
+		case p.is("("):
+			if err := p.skipBalanced("(", ")"); err != nil {
+				return err
+			}
+		default:
+			p.advance()
+		}
+	}
+	p.advance()
+
+	// Values other than OIDs, such as the trap numbers of the TRAP-TYPE
+	// macro, are not part of the tree.
+	if !p.is("{") {
+		p.advance()
+		return nil
+	}
+	oid, err := p.parseOID()
+	if err != nil {
+		return err
+	}
+	def.oid = oid
+	m.addObject(def)
+	return nil
+}
+
+func (m *module) addObject(def *objectDef) {
+	if _, ok := m.objects[def.name]; !ok {
+		m.order = append(m.order, def)
+	}
+	m.objects[def.name] = def
+}
+
+// parseNameList parses a list of names in braces, such as an INDEX clause.
+func (p *parser) parseNameList() ([]string, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var names []string
+	for !p.is("}") {
+		switch {
+		case p.tok.kind == tokenEOF:
+			return nil, p.errorf("unexpected end of file in list")
+		case p.is("IMPLIED"):
+		case p.tok.kind == tokenWord:
+			names = append(names, p.tok.text)
+		}
+		p.advance()
+	}
+	p.advance()
+	return names, nil
+}
+
+// parseOID parses an OID value such as { iso org(3) dod(6) 1 }.
+func (p *parser) parseOID() ([]oidComponent, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var oid []oidComponent
+	for !p.is("}") {
+		switch p.tok.kind {
+		case tokenEOF:
+			return nil, p.errorf("unexpected end of file in OID")
+		case tokenNumber:
+			n, err := strconv.ParseInt(p.tok.text, 10, 64)
+			if err != nil {
+				return nil, p.errorf("invalid OID component %q", p.tok.text)
+			}
+			oid = append(oid, oidComponent{number: n, hasNumber: true})
+			p.advance()
+		case tokenWord:
+			c := oidComponent{name: p.tok.text}
+			p.advance()
+			if p.is("(") {
+				p.advance()
+				n, err := strconv.ParseInt(p.tok.text, 10, 64)
+				if err != nil {
+					return nil, p.errorf("invalid OID component %q", p.tok.text)
+				}
+				c.number, c.hasNumber = n, true
+				p.advance()
+				if err := p.expect(")"); err != nil {
+					return nil, err
+				}
+			}
+			oid = append(oid, c)
+		default:
+			return nil, p.errorf("unexpected %q in OID", p.tok.text)
+		}
+	}
+	p.advance()
+	return oid, nil
+}
@@ -0,0 +1,110 @@
+package snmp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func loadTestMibs(t *testing.T) *MibTree {
+	tree, err := LoadMibs([]string{"testdata", "testdata/missing"})
+	require.NoError(t, err)
+	return tree
+}
+
+func TestLookup(t *testing.T) {
+	tree := loadTestMibs(t)
+
+	tests := []struct {
+		oid    string
+		name   string
+		module string
+		num    string
+		suffix []uint32
+	}{
+		{"TEST-MIB::testName", "testName", "TEST-MIB", ".1.3.6.1.4.1.99999.2.1.1", nil},
+		{"TEST-MIB::testPortName.1.2", "testPortName", "TEST-MIB", ".1.3.6.1.4.1.99999.2.1.2.1.2", []uint32{1, 2}},
+		{".1.3.6.1.4.1.99999.2.1.2.1.3.7", "testPortAddress", "TEST-MIB", ".1.3.6.1.4.1.99999.2.1.2.1.3", []uint32{7}},
+		{"1.3.6.1.4.1.99999.1", "testTcModule", "TEST-TC-MIB", ".1.3.6.1.4.1.99999.1", nil},
+		{"testPortOctets.3", "testPortOctets", "TEST-MIB", ".1.3.6.1.4.1.99999.2.1.2.1.4", []uint32{3}},
+		{".iso.3.6.1.4.1.99999", "test", "TEST-MIB", ".1.3.6.1.4.1.99999", nil},
+		{".iso.org.dod", "dod", "SNMPv2-SMI", ".1.3.6", nil},
+		{".1.2.3", "iso", "", ".1", []uint32{2, 3}},
+		{".999", "", "", "", []uint32{999}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.oid, func(t *testing.T) {
+			node, suffix, err := tree.Lookup(tt.oid)
+			require.NoError(t, err)
+			require.Equal(t, tt.name, node.Name)
+			require.Equal(t, tt.module, node.Module)
+			require.Equal(t, tt.num, node.OID())
+			require.Equal(t, tt.suffix, suffix)
+		})
+	}
+}
+
+func TestLookupError(t *testing.T) {
+	tree := loadTestMibs(t)
+
+	for _, oid := range []string{
+		"TEST-MIB::missing",
+		"MISSING-MIB::testName",
+		"INVALID-MIB::invalid",
+		"missing.1",
+		"TEST-MIB::testName.1.x",
+		".1.3.missing",
+	} {
+		t.Run(oid, func(t *testing.T) {
+			_, _, err := tree.Lookup(oid)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestConventions(t *testing.T) {
+	tree := loadTestMibs(t)
+
+	tests := []struct {
+		oid   string
+		chain []string
+	}{
+		{"TEST-MIB::testName", []string{"DisplayString", "OCTET STRING"}},
+		{"TEST-MIB::testPortAddress", []string{"PortAddress", "MacAddress", "OCTET STRING"}},
+		{"TEST-MIB::testPortOctets", []string{"Counter64", "INTEGER"}},
+		{"TEST-MIB::testPortSpeed", []string{"Integer32"}},
+		{"TEST-MIB::testPortTable", []string{"SEQUENCE OF TestPortEntry"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.oid, func(t *testing.T) {
+			node, _, err := tree.Lookup(tt.oid)
+			require.NoError(t, err)
+			require.Equal(t, tt.chain, tree.Conventions(node))
+		})
+	}
+}
+
+func TestTable(t *testing.T) {
+	tree := loadTestMibs(t)
+
+	table, _, err := tree.Lookup("TEST-MIB::testPortTable")
+	require.NoError(t, err)
+	entry := table.Child(1)
+	require.Equal(t, "testPortEntry", entry.Name)
+	require.Equal(t, []string{"testPortSlot", "testPortName"}, tree.TableIndex(entry))
+
+	var columns []string
+	for _, c := range entry.Children() {
+		columns = append(columns, c.Name+" "+c.Access)
+	}
+	require.Equal(t, []string{
+		"testPortSlot not-accessible",
+		"testPortName read-only",
+		"testPortAddress read-only",
+		"testPortOctets read-only",
+	}, columns)
+
+	ext, _, err := tree.Lookup("TEST-MIB::testPortExtEntry")
+	require.NoError(t, err)
+	require.Equal(t, []string{"testPortSlot", "testPortName"}, tree.TableIndex(ext))
+}
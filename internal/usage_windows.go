@@ -11,6 +11,10 @@ Usage:
 The commands & flags are:
 
   config              print out full sample configuration to stdout
+  config check        build all plugins of the configuration without running
+                      them and report every problem found
+  secret set <store> <key>
+                      store the secret read from stdin in a secret store
   version             print the version to stdout
 
   --aggregator-filter <filter>   filter the aggregators to enable, separator is :
@@ -35,6 +39,7 @@ The commands & flags are:
                                  inputs to complete in test mode
   --usage <plugin>               print usage for a plugin, ie, 'telegraf --usage mysql'
   --version                      display the version and exit
+  --watch-config                 reload the configuration when the config files change
 
   --console                      run as console application (windows only)
   --service <service>            operate on the service (windows only)
@@ -49,6 +54,12 @@ Examples:
   # generate config with only cpu input & influxdb output plugins defined
   telegraf --input-filter cpu --output-filter influxdb config
 
+  # check a telegraf config file for errors:
+  telegraf --config telegraf.conf config check
+
+  # store a secret in the secret store with the id "vault":
+  telegraf --config telegraf.conf secret set vault http_password < password.txt
+
   # run a single telegraf collection, outputing metrics to stdout
   telegraf --config telegraf.conf --test
 
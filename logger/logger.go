@@ -6,6 +6,8 @@ import (
 	"log"
 	"os"
 	"regexp"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf/internal"
@@ -15,6 +17,46 @@ import (
 
 var prefixRegex = regexp.MustCompile("^[DIWE]!")
 
+var (
+	secretsMu sync.RWMutex
+	secrets   map[string]bool
+	redactor  *strings.Replacer
+)
+
+// RedactSecret replaces all further occurrences of secret in the log output
+// with "****".
+func RedactSecret(secret string) {
+	if secret == "" {
+		return
+	}
+
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	if secrets[secret] {
+		return
+	}
+	if secrets == nil {
+		secrets = make(map[string]bool)
+	}
+	secrets[secret] = true
+
+	var oldnew []string
+	for s := range secrets {
+		oldnew = append(oldnew, s, "****")
+	}
+	redactor = strings.NewReplacer(oldnew...)
+}
+
+// Redact replaces the secrets registered with RedactSecret in s.
+func Redact(s string) string {
+	secretsMu.RLock()
+	defer secretsMu.RUnlock()
+	if redactor == nil {
+		return s
+	}
+	return redactor.Replace(s)
+}
+
 // newTelegrafWriter returns a logging-wrapped writer.
 func newTelegrafWriter(w io.Writer) io.Writer {
 	return &telegrafLog{
@@ -47,6 +89,8 @@ type telegrafLog struct {
 }
 
 func (t *telegrafLog) Write(b []byte) (n int, err error) {
+	b = []byte(Redact(string(b)))
+
 	var line []byte
 	if !prefixRegex.Match(b) {
 		line = append([]byte(time.Now().UTC().Format(time.RFC3339)+" I! "), b...)
@@ -72,6 +72,22 @@ func TestAddDefaultLogLevel(t *testing.T) {
 	assert.Equal(t, f[19:], []byte("Z I! TEST\n"))
 }
 
+func TestRedactSecret(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "")
+	assert.NoError(t, err)
+	defer func() { os.Remove(tmpfile.Name()) }()
+	config := createBasicLogConfig(tmpfile.Name())
+	config.Debug = true
+	SetupLogging(config)
+	RedactSecret("hunter2")
+	log.Printf("D! password=hunter2")
+
+	f, err := ioutil.ReadFile(tmpfile.Name())
+	assert.NoError(t, err)
+	assert.Equal(t, f[19:], []byte("Z D! password=****\n"))
+	assert.Equal(t, "dsn=**** other", Redact("dsn=hunter2 other"))
+}
+
 func TestWriteToTruncatedFile(t *testing.T) {
 	tmpfile, err := ioutil.TempFile("", "")
 	assert.NoError(t, err)
@@ -3,6 +3,7 @@ package basicstats
 import (
 	"log"
 	"math"
+	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/plugins/aggregators"
@@ -16,13 +17,20 @@ type BasicStats struct {
 }
 
 type configuredStats struct {
-	count    bool
-	min      bool
-	max      bool
-	mean     bool
-	variance bool
-	stdev    bool
-	sum      bool
+	count      bool
+	min        bool
+	max        bool
+	mean       bool
+	variance   bool
+	stdev      bool
+	sum        bool
+	first      bool
+	last       bool
+	diff       bool
+	nonNegDiff bool
+	rate       bool
+	nonNegRate bool
+	interval   bool
 }
 
 func NewBasicStats() *BasicStats {
@@ -44,6 +52,11 @@ type basicstats struct {
 	sum   float64
 	mean  float64
 	M2    float64 //intermedia value for variance/stdev
+	first float64
+	last  float64
+	//timestamps of the first and last values for diff/rate/interval
+	firstTime time.Time
+	lastTime  time.Time
 }
 
 var sampleConfig = `
@@ -55,6 +68,10 @@ var sampleConfig = `
 
   ## Configures which basic stats to push as fields
   # stats = ["count", "min", "max", "mean", "stdev", "s2", "sum"]
+  ##
+  ## Additional stats based on the first and last value of the period:
+  ## "first", "last", "diff", "non_negative_diff", "rate",
+  ## "non_negative_rate" and "interval"
 `
 
 func (m *BasicStats) SampleConfig() string {
@@ -76,14 +93,7 @@ func (m *BasicStats) Add(in telegraf.Metric) {
 		}
 		for _, field := range in.FieldList() {
 			if fv, ok := convert(field.Value); ok {
-				a.fields[field.Key] = basicstats{
-					count: 1,
-					min:   fv,
-					max:   fv,
-					mean:  fv,
-					sum:   fv,
-					M2:    0.0,
-				}
+				a.fields[field.Key] = newBasicstats(fv, in.Time())
 			}
 		}
 		m.cache[id] = a
@@ -92,14 +102,7 @@ func (m *BasicStats) Add(in telegraf.Metric) {
 			if fv, ok := convert(field.Value); ok {
 				if _, ok := m.cache[id].fields[field.Key]; !ok {
 					// hit an uncached field of a cached metric
-					m.cache[id].fields[field.Key] = basicstats{
-						count: 1,
-						min:   fv,
-						max:   fv,
-						mean:  fv,
-						sum:   fv,
-						M2:    0.0,
-					}
+					m.cache[id].fields[field.Key] = newBasicstats(fv, in.Time())
 					continue
 				}
 
@@ -127,6 +130,15 @@ func (m *BasicStats) Add(in telegraf.Metric) {
 				}
 				//sum compute
 				tmp.sum += fv
+				//first/last compute, ordered by the metric time
+				if in.Time().Before(tmp.firstTime) {
+					tmp.first = fv
+					tmp.firstTime = in.Time()
+				}
+				if !in.Time().Before(tmp.lastTime) {
+					tmp.last = fv
+					tmp.lastTime = in.Time()
+				}
 				//store final data
 				m.cache[id].fields[field.Key] = tmp
 			}
@@ -169,6 +181,36 @@ func (m *BasicStats) Push(acc telegraf.Accumulator) {
 				}
 			}
 			//if count == 1 StdDev = infinite => so I won't send data
+
+			if config.first {
+				fields[k+"_first"] = v.first
+			}
+			if config.last {
+				fields[k+"_last"] = v.last
+			}
+
+			//diff/rate/interval need two values at different times
+			interval := v.lastTime.Sub(v.firstTime)
+			if interval > 0 {
+				diff := v.last - v.first
+				rate := diff / interval.Seconds()
+
+				if config.diff {
+					fields[k+"_diff"] = diff
+				}
+				if config.nonNegDiff && diff >= 0 {
+					fields[k+"_non_negative_diff"] = diff
+				}
+				if config.rate {
+					fields[k+"_rate"] = rate
+				}
+				if config.nonNegRate && diff >= 0 {
+					fields[k+"_non_negative_rate"] = rate
+				}
+				if config.interval {
+					fields[k+"_interval"] = interval.Nanoseconds()
+				}
+			}
 		}
 
 		if len(fields) > 0 {
@@ -199,6 +241,20 @@ func parseStats(names []string) *configuredStats {
 			parsed.stdev = true
 		case "sum":
 			parsed.sum = true
+		case "first":
+			parsed.first = true
+		case "last":
+			parsed.last = true
+		case "diff":
+			parsed.diff = true
+		case "non_negative_diff":
+			parsed.nonNegDiff = true
+		case "rate":
+			parsed.rate = true
+		case "non_negative_rate":
+			parsed.nonNegRate = true
+		case "interval":
+			parsed.interval = true
 
 		default:
 			log.Printf("W! Unrecognized basic stat '%s', ignoring", name)
@@ -237,6 +293,21 @@ func getConfiguredStats(m *BasicStats) *configuredStats {
 	return m.statsConfig
 }
 
+func newBasicstats(fv float64, tm time.Time) basicstats {
+	return basicstats{
+		count:     1,
+		min:       fv,
+		max:       fv,
+		mean:      fv,
+		sum:       fv,
+		M2:        0.0,
+		first:     fv,
+		last:      fv,
+		firstTime: tm,
+		lastTime:  tm,
+	}
+}
+
 func (m *BasicStats) Reset() {
 	m.cache = make(map[uint64]aggregate)
 }
@@ -478,6 +478,75 @@ func TestBasicStatsWithAllStats(t *testing.T) {
 	acc.AssertContainsTaggedFields(t, "m1", expectedFields, expectedTags)
 }
 
+// Test only aggregating first and last
+func TestBasicStatsWithFirstAndLast(t *testing.T) {
+	aggregator := NewBasicStats()
+	aggregator.Stats = []string{"first", "last"}
+
+	now := time.Unix(100, 0)
+	aggregator.Add(testutil.MustMetric("m1",
+		map[string]string{"foo": "bar"},
+		map[string]interface{}{"a": int64(1)},
+		now))
+	aggregator.Add(testutil.MustMetric("m1",
+		map[string]string{"foo": "bar"},
+		map[string]interface{}{"a": int64(3)},
+		now.Add(20*time.Second)))
+	// Metrics are ordered by their time, not by the order they are added.
+	aggregator.Add(testutil.MustMetric("m1",
+		map[string]string{"foo": "bar"},
+		map[string]interface{}{"a": int64(2)},
+		now.Add(10*time.Second)))
+
+	acc := testutil.Accumulator{}
+	aggregator.Push(&acc)
+
+	expectedFields := map[string]interface{}{
+		"a_first": float64(1),
+		"a_last":  float64(3),
+	}
+	expectedTags := map[string]string{
+		"foo": "bar",
+	}
+	acc.AssertContainsTaggedFields(t, "m1", expectedFields, expectedTags)
+}
+
+// Test aggregating the diff, rate and interval between the first and last
+// value
+func TestBasicStatsWithDiffRateAndInterval(t *testing.T) {
+	aggregator := NewBasicStats()
+	aggregator.Stats = []string{"diff", "non_negative_diff", "rate",
+		"non_negative_rate", "interval"}
+
+	now := time.Unix(100, 0)
+	aggregator.Add(testutil.MustMetric("m1",
+		map[string]string{"foo": "bar"},
+		map[string]interface{}{"a": int64(10), "b": float64(10), "c": int64(5)},
+		now))
+	aggregator.Add(testutil.MustMetric("m1",
+		map[string]string{"foo": "bar"},
+		map[string]interface{}{"a": int64(30), "b": float64(4)},
+		now.Add(10*time.Second)))
+
+	acc := testutil.Accumulator{}
+	aggregator.Push(&acc)
+
+	expectedFields := map[string]interface{}{
+		"a_diff":              float64(20),
+		"a_non_negative_diff": float64(20),
+		"a_rate":              float64(2),
+		"a_non_negative_rate": float64(2),
+		"a_interval":          int64(10 * time.Second),
+		"b_diff":              float64(-6),
+		"b_rate":              float64(-0.6),
+		"b_interval":          int64(10 * time.Second),
+	}
+	expectedTags := map[string]string{
+		"foo": "bar",
+	}
+	acc.AssertContainsTaggedFields(t, "m1", expectedFields, expectedTags)
+}
+
 // Test that if an empty array is passed, no points are pushed
 func TestBasicStatsWithNoStats(t *testing.T) {
 
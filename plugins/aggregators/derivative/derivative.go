@@ -0,0 +1,202 @@
+package derivative
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+)
+
+type Derivative struct {
+	Stats       []string `toml:"stats"`
+	MaxRollOver uint     `toml:"max_roll_over"`
+
+	rate  bool
+	delta bool
+	cache map[uint64]*aggregate
+}
+
+type aggregate struct {
+	name   string
+	tags   map[string]string
+	fields map[string]*counter
+
+	// rollOver is the number of periods the aggregate has been rolled over
+	// without receiving a new sample.
+	rollOver uint
+	updated  bool
+}
+
+type sample struct {
+	value float64
+	time  time.Time
+}
+
+// counter tracks the increase of a field between the first and last sample
+// of the period.
+type counter struct {
+	first sample
+	last  sample
+	delta float64
+}
+
+var sampleConfig = `
+  ## The period on which to flush & clear the aggregator.
+  period = "30s"
+  ## If true, the original metric will be dropped by the
+  ## aggregator and will not get sent to the output plugins.
+  drop_original = false
+
+  ## Configures which stats to push as fields
+  # stats = ["rate", "delta"]
+
+  ## Number of periods the last sample of a period is rolled over as the
+  ## first sample of the next period, so that the increase between periods
+  ## is not lost.  If no new sample is received the series is forgotten after
+  ## this many periods.  With the default of 0 only the samples within a
+  ## period are used.
+  # max_roll_over = 0
+`
+
+func NewDerivative() *Derivative {
+	return &Derivative{
+		rate:  true,
+		delta: true,
+		cache: make(map[uint64]*aggregate),
+	}
+}
+
+func (d *Derivative) SampleConfig() string {
+	return sampleConfig
+}
+
+func (d *Derivative) Description() string {
+	return "Calculate the rate and delta of counters between the first and last sample of each period."
+}
+
+func (d *Derivative) Init() error {
+	if d.Stats == nil {
+		return nil
+	}
+
+	d.rate, d.delta = false, false
+	for _, name := range d.Stats {
+		switch name {
+		case "rate":
+			d.rate = true
+		case "delta":
+			d.delta = true
+		default:
+			return fmt.Errorf("unrecognized stat %q", name)
+		}
+	}
+	return nil
+}
+
+func (d *Derivative) Add(in telegraf.Metric) {
+	id := in.HashID()
+	a, ok := d.cache[id]
+	if !ok {
+		a = &aggregate{
+			name:   in.Name(),
+			tags:   in.Tags(),
+			fields: make(map[string]*counter),
+		}
+		d.cache[id] = a
+	}
+	a.updated = true
+
+	for _, field := range in.FieldList() {
+		value, ok := convert(field.Value)
+		if !ok {
+			continue
+		}
+		s := sample{value: value, time: in.Time()}
+
+		c, ok := a.fields[field.Key]
+		if !ok {
+			a.fields[field.Key] = &counter{first: s, last: s}
+			continue
+		}
+
+		// Samples are expected in order, older samples are ignored.
+		if !s.time.After(c.last.time) {
+			continue
+		}
+
+		// A decreasing value is a counter reset, the counter is assumed to
+		// have restarted from zero.
+		increase := s.value - c.last.value
+		if increase < 0 {
+			increase = s.value
+		}
+		c.delta += increase
+		c.last = s
+	}
+}
+
+func (d *Derivative) Push(acc telegraf.Accumulator) {
+	for _, a := range d.cache {
+		fields := map[string]interface{}{}
+		for k, c := range a.fields {
+			elapsed := c.last.time.Sub(c.first.time)
+			if elapsed <= 0 {
+				continue
+			}
+			if d.rate {
+				fields[k+"_rate"] = c.delta / elapsed.Seconds()
+			}
+			if d.delta {
+				fields[k+"_delta"] = c.delta
+			}
+		}
+		if len(fields) > 0 {
+			acc.AddFields(a.name, fields, a.tags)
+		}
+	}
+}
+
+func (d *Derivative) Reset() {
+	if d.MaxRollOver == 0 {
+		d.cache = make(map[uint64]*aggregate)
+		return
+	}
+
+	for id, a := range d.cache {
+		if a.updated {
+			a.rollOver = 0
+		} else {
+			a.rollOver++
+		}
+		if a.rollOver >= d.MaxRollOver {
+			delete(d.cache, id)
+			continue
+		}
+
+		a.updated = false
+		for _, c := range a.fields {
+			c.first = c.last
+			c.delta = 0
+		}
+	}
+}
+
+func convert(in interface{}) (float64, bool) {
+	switch v := in.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+func init() {
+	aggregators.Add("derivative", func() telegraf.Aggregator {
+		return NewDerivative()
+	})
+}
@@ -0,0 +1,151 @@
+package derivative
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+var start = time.Unix(1530939936, 0)
+
+func newCounter(seconds int, fields map[string]interface{}) telegraf.Metric {
+	return testutil.MustMetric("net",
+		map[string]string{"interface": "eth0"},
+		fields,
+		start.Add(time.Duration(seconds)*time.Second))
+}
+
+func TestRateAndDelta(t *testing.T) {
+	acc := testutil.Accumulator{}
+	d := NewDerivative()
+	require.NoError(t, d.Init())
+
+	d.Add(newCounter(0, map[string]interface{}{"bytes_recv": int64(100), "name": "eth0"}))
+	d.Add(newCounter(10, map[string]interface{}{"bytes_recv": int64(300)}))
+	d.Add(newCounter(20, map[string]interface{}{"bytes_recv": int64(600)}))
+	d.Push(&acc)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("net",
+			map[string]string{"interface": "eth0"},
+			map[string]interface{}{
+				"bytes_recv_rate":  25.0,
+				"bytes_recv_delta": 500.0,
+			},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
+
+func TestCounterReset(t *testing.T) {
+	acc := testutil.Accumulator{}
+	d := NewDerivative()
+	require.NoError(t, d.Init())
+
+	d.Add(newCounter(0, map[string]interface{}{"packets": uint64(100)}))
+	d.Add(newCounter(10, map[string]interface{}{"packets": uint64(150)}))
+	d.Add(newCounter(20, map[string]interface{}{"packets": uint64(30)}))
+	d.Push(&acc)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("net",
+			map[string]string{"interface": "eth0"},
+			map[string]interface{}{
+				"packets_rate":  4.0,
+				"packets_delta": 80.0,
+			},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
+
+func TestSingleSample(t *testing.T) {
+	acc := testutil.Accumulator{}
+	d := NewDerivative()
+	require.NoError(t, d.Init())
+
+	d.Add(newCounter(0, map[string]interface{}{"bytes_recv": int64(100)}))
+	d.Push(&acc)
+	require.Len(t, acc.GetTelegrafMetrics(), 0)
+}
+
+func TestStats(t *testing.T) {
+	acc := testutil.Accumulator{}
+	d := NewDerivative()
+	d.Stats = []string{"rate"}
+	require.NoError(t, d.Init())
+
+	d.Add(newCounter(0, map[string]interface{}{"bytes_recv": 100.0}))
+	d.Add(newCounter(10, map[string]interface{}{"bytes_recv": 200.0}))
+	d.Push(&acc)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("net",
+			map[string]string{"interface": "eth0"},
+			map[string]interface{}{
+				"bytes_recv_rate": 10.0,
+			},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+
+	d.Stats = []string{"rate", "integral"}
+	require.EqualError(t, d.Init(), `unrecognized stat "integral"`)
+}
+
+func TestReset(t *testing.T) {
+	acc := testutil.Accumulator{}
+	d := NewDerivative()
+	require.NoError(t, d.Init())
+
+	d.Add(newCounter(0, map[string]interface{}{"bytes_recv": int64(100)}))
+	d.Add(newCounter(10, map[string]interface{}{"bytes_recv": int64(200)}))
+	d.Reset()
+	d.Add(newCounter(20, map[string]interface{}{"bytes_recv": int64(400)}))
+	d.Push(&acc)
+	require.Len(t, acc.GetTelegrafMetrics(), 0)
+}
+
+func TestMaxRollOver(t *testing.T) {
+	acc := testutil.Accumulator{}
+	d := NewDerivative()
+	d.MaxRollOver = 2
+	require.NoError(t, d.Init())
+
+	d.Add(newCounter(0, map[string]interface{}{"bytes_recv": int64(100)}))
+	d.Add(newCounter(10, map[string]interface{}{"bytes_recv": int64(200)}))
+	d.Push(&acc)
+	d.Reset()
+
+	// The last sample of the previous period is the first of this period.
+	d.Add(newCounter(20, map[string]interface{}{"bytes_recv": int64(400)}))
+	d.Push(&acc)
+	d.Reset()
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("net",
+			map[string]string{"interface": "eth0"},
+			map[string]interface{}{
+				"bytes_recv_rate":  10.0,
+				"bytes_recv_delta": 100.0,
+			},
+			time.Unix(0, 0)),
+		testutil.MustMetric("net",
+			map[string]string{"interface": "eth0"},
+			map[string]interface{}{
+				"bytes_recv_rate":  20.0,
+				"bytes_recv_delta": 200.0,
+			},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+
+	// Without new samples the series is forgotten after max_roll_over periods.
+	d.Reset()
+	require.Len(t, d.cache, 1)
+	d.Reset()
+	require.Len(t, d.cache, 0)
+}
@@ -0,0 +1,68 @@
+package merge
+
+import (
+	"log"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+)
+
+const (
+	description  = "Merge metrics into multifield metrics by series key"
+	sampleConfig = `
+  ## If true, the original metric will be dropped by the
+  ## aggregator and will not get sent to the output plugins.
+  drop_original = true
+`
+)
+
+type Merge struct {
+	grouper *metric.SeriesGrouper
+}
+
+func NewMerge() *Merge {
+	m := &Merge{}
+	m.Reset()
+	return m
+}
+
+func (m *Merge) SampleConfig() string {
+	return sampleConfig
+}
+
+func (m *Merge) Description() string {
+	return description
+}
+
+func (m *Merge) Add(in telegraf.Metric) {
+	tags := in.Tags()
+	for _, field := range in.FieldList() {
+		err := m.grouper.Add(in.Name(), tags, in.Time(), field.Key, field.Value)
+		if err != nil {
+			log.Printf("E! [aggregators.merge] Could not merge field %q of %s: %v",
+				field.Key, in.Name(), err)
+		}
+	}
+}
+
+func (m *Merge) Push(acc telegraf.Accumulator) {
+	// Always use nanosecond precision to avoid rounding metrics that were
+	// produced at a precision higher than the agent default.
+	acc.SetPrecision(time.Nanosecond)
+
+	for _, metric := range m.grouper.Metrics() {
+		acc.AddMetric(metric)
+	}
+}
+
+func (m *Merge) Reset() {
+	m.grouper = metric.NewSeriesGrouper()
+}
+
+func init() {
+	aggregators.Add("merge", func() telegraf.Aggregator {
+		return NewMerge()
+	})
+}
@@ -0,0 +1,125 @@
+package merge
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestSimple(t *testing.T) {
+	acc := testutil.Accumulator{}
+	m := NewMerge()
+
+	m.Add(testutil.MustMetric("cpu",
+		map[string]string{"cpu": "cpu0"},
+		map[string]interface{}{"time_idle": 42},
+		time.Unix(0, 0)))
+	m.Add(testutil.MustMetric("cpu",
+		map[string]string{"cpu": "cpu0"},
+		map[string]interface{}{"time_guest": 42},
+		time.Unix(0, 0)))
+	m.Push(&acc)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"cpu": "cpu0"},
+			map[string]interface{}{
+				"time_idle":  42,
+				"time_guest": 42,
+			},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestNanosecondPrecision(t *testing.T) {
+	acc := testutil.Accumulator{}
+	m := NewMerge()
+
+	m.Add(testutil.MustMetric("cpu",
+		map[string]string{"cpu": "cpu0"},
+		map[string]interface{}{"time_idle": 42},
+		time.Unix(0, 1)))
+	m.Add(testutil.MustMetric("cpu",
+		map[string]string{"cpu": "cpu0"},
+		map[string]interface{}{"time_guest": 42},
+		time.Unix(0, 1)))
+	acc.SetPrecision(time.Second)
+	m.Push(&acc)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"cpu": "cpu0"},
+			map[string]interface{}{
+				"time_idle":  42,
+				"time_guest": 42,
+			},
+			time.Unix(0, 1)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestSeriesAndTime(t *testing.T) {
+	acc := testutil.Accumulator{}
+	m := NewMerge()
+
+	m.Add(testutil.MustMetric("cpu",
+		map[string]string{"cpu": "cpu0"},
+		map[string]interface{}{"time_idle": 42},
+		time.Unix(0, 0)))
+	m.Add(testutil.MustMetric("cpu",
+		map[string]string{"cpu": "cpu1"},
+		map[string]interface{}{"time_idle": 43},
+		time.Unix(0, 0)))
+	m.Add(testutil.MustMetric("cpu",
+		map[string]string{"cpu": "cpu0"},
+		map[string]interface{}{"time_guest": 44},
+		time.Unix(1, 0)))
+	m.Add(testutil.MustMetric("cpu",
+		map[string]string{"cpu": "cpu1"},
+		map[string]interface{}{"time_guest": 45},
+		time.Unix(0, 0)))
+	m.Push(&acc)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"cpu": "cpu0"},
+			map[string]interface{}{"time_idle": 42},
+			time.Unix(0, 0)),
+		testutil.MustMetric("cpu",
+			map[string]string{"cpu": "cpu1"},
+			map[string]interface{}{"time_idle": 43, "time_guest": 45},
+			time.Unix(0, 0)),
+		testutil.MustMetric("cpu",
+			map[string]string{"cpu": "cpu0"},
+			map[string]interface{}{"time_guest": 44},
+			time.Unix(1, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestReset(t *testing.T) {
+	acc := testutil.Accumulator{}
+	m := NewMerge()
+
+	m.Add(testutil.MustMetric("cpu",
+		map[string]string{"cpu": "cpu0"},
+		map[string]interface{}{"time_idle": 42},
+		time.Unix(0, 0)))
+	m.Reset()
+	m.Add(testutil.MustMetric("cpu",
+		map[string]string{"cpu": "cpu0"},
+		map[string]interface{}{"time_guest": 42},
+		time.Unix(0, 0)))
+	m.Push(&acc)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"cpu": "cpu0"},
+			map[string]interface{}{"time_guest": 42},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
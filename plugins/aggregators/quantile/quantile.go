@@ -0,0 +1,154 @@
+package quantile
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/aggregators"
+)
+
+type Quantile struct {
+	Quantiles        []float64 `toml:"quantiles"`
+	RelativeAccuracy float64   `toml:"relative_accuracy"`
+	MaxBins          int       `toml:"max_bins"`
+
+	suffixes []string
+	cache    map[uint64]aggregate
+}
+
+type aggregate struct {
+	name   string
+	tags   map[string]string
+	fields map[string]*sketch
+}
+
+var sampleConfig = `
+  ## The period on which to flush & clear the aggregator.
+  period = "30s"
+  ## If true, the original metric will be dropped by the
+  ## aggregator and will not get sent to the output plugins.
+  drop_original = false
+
+  ## Quantiles to output in the range [0,1]
+  # quantiles = [0.25, 0.5, 0.75]
+
+  ## Relative accuracy of the quantiles, each quantile is within this
+  ## relative error of the exact value.
+  # relative_accuracy = 0.01
+
+  ## Maximum number of bins per series and field, bounding the memory used.
+  ## When exceeded the bins of the values closest to zero are merged,
+  ## reducing the accuracy of the lowest quantiles only.
+  # max_bins = 2048
+`
+
+func NewQuantile() *Quantile {
+	return &Quantile{
+		Quantiles:        []float64{0.25, 0.5, 0.75},
+		RelativeAccuracy: 0.01,
+		MaxBins:          2048,
+		cache:            make(map[uint64]aggregate),
+	}
+}
+
+func (q *Quantile) SampleConfig() string {
+	return sampleConfig
+}
+
+func (q *Quantile) Description() string {
+	return "Keep the aggregate quantiles of each metric passing through."
+}
+
+func (q *Quantile) Init() error {
+	if q.RelativeAccuracy <= 0 || q.RelativeAccuracy >= 1 {
+		return fmt.Errorf("relative_accuracy must be between 0 and 1, got %v", q.RelativeAccuracy)
+	}
+	if q.MaxBins < 1 {
+		return errors.New("max_bins must be at least 1")
+	}
+
+	q.suffixes = make([]string, 0, len(q.Quantiles))
+	for _, quantile := range q.Quantiles {
+		if quantile < 0 || quantile > 1 {
+			return fmt.Errorf("quantile %v is not in the range [0,1]", quantile)
+		}
+		q.suffixes = append(q.suffixes, suffix(quantile))
+	}
+	return nil
+}
+
+// suffix returns the field suffix of a quantile, the percentile prefixed by
+// "_p" such as "_p95" or "_p99_9".
+func suffix(quantile float64) string {
+	percentile := math.Round(quantile*1e6) / 1e4
+	return "_p" + strings.Replace(strconv.FormatFloat(percentile, 'f', -1, 64), ".", "_", 1)
+}
+
+func (q *Quantile) Add(in telegraf.Metric) {
+	id := in.HashID()
+	a, ok := q.cache[id]
+	if !ok {
+		a = aggregate{
+			name:   in.Name(),
+			tags:   in.Tags(),
+			fields: make(map[string]*sketch),
+		}
+		q.cache[id] = a
+	}
+
+	for _, field := range in.FieldList() {
+		value, ok := convert(field.Value)
+		if !ok {
+			continue
+		}
+		s, ok := a.fields[field.Key]
+		if !ok {
+			s = newSketch(q.RelativeAccuracy, q.MaxBins)
+			a.fields[field.Key] = s
+		}
+		s.Add(value)
+	}
+}
+
+func (q *Quantile) Push(acc telegraf.Accumulator) {
+	for _, a := range q.cache {
+		fields := map[string]interface{}{}
+		for k, s := range a.fields {
+			for i, quantile := range q.Quantiles {
+				if v, ok := s.Quantile(quantile); ok {
+					fields[k+q.suffixes[i]] = v
+				}
+			}
+		}
+		if len(fields) > 0 {
+			acc.AddFields(a.name, fields, a.tags)
+		}
+	}
+}
+
+func (q *Quantile) Reset() {
+	q.cache = make(map[uint64]aggregate)
+}
+
+func convert(in interface{}) (float64, bool) {
+	switch v := in.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
+
+func init() {
+	aggregators.Add("quantile", func() telegraf.Aggregator {
+		return NewQuantile()
+	})
+}
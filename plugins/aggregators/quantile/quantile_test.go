@@ -0,0 +1,108 @@
+package quantile
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuantile(t *testing.T) {
+	acc := testutil.Accumulator{}
+	q := NewQuantile()
+	q.Quantiles = []float64{0, 0.5, 0.95, 1}
+	require.NoError(t, q.Init())
+
+	for i := 1; i <= 100; i++ {
+		q.Add(testutil.MustMetric("response",
+			map[string]string{"path": "/"},
+			map[string]interface{}{"time": int64(i), "status": "ok"},
+			time.Unix(int64(i), 0)))
+	}
+	q.Push(&acc)
+
+	metrics := acc.GetTelegrafMetrics()
+	require.Len(t, metrics, 1)
+	require.Equal(t, "response", metrics[0].Name())
+	require.Equal(t, map[string]string{"path": "/"}, metrics[0].Tags())
+
+	fields := metrics[0].Fields()
+	require.Len(t, fields, 4)
+	require.InEpsilon(t, 1.0, fields["time_p0"], 0.01)
+	require.InEpsilon(t, 50.0, fields["time_p50"], 0.01)
+	require.InEpsilon(t, 95.0, fields["time_p95"], 0.01)
+	require.InEpsilon(t, 100.0, fields["time_p100"], 0.01)
+}
+
+func TestQuantileReset(t *testing.T) {
+	acc := testutil.Accumulator{}
+	q := NewQuantile()
+	require.NoError(t, q.Init())
+
+	q.Add(testutil.MustMetric("cpu", map[string]string{},
+		map[string]interface{}{"usage": 42.0}, time.Unix(0, 0)))
+	q.Reset()
+	q.Push(&acc)
+	require.Len(t, acc.GetTelegrafMetrics(), 0)
+}
+
+func TestQuantileSeries(t *testing.T) {
+	acc := testutil.Accumulator{}
+	q := NewQuantile()
+	q.Quantiles = []float64{0.5}
+	require.NoError(t, q.Init())
+
+	q.Add(testutil.MustMetric("cpu", map[string]string{"cpu": "cpu0"},
+		map[string]interface{}{"usage": 10.0}, time.Unix(0, 0)))
+	q.Add(testutil.MustMetric("cpu", map[string]string{"cpu": "cpu1"},
+		map[string]interface{}{"usage": 20.0}, time.Unix(0, 0)))
+	q.Push(&acc)
+
+	metrics := acc.GetTelegrafMetrics()
+	require.Len(t, metrics, 2)
+	for _, m := range metrics {
+		expected := map[string]float64{"cpu0": 10.0, "cpu1": 20.0}[m.Tags()["cpu"]]
+		value, ok := m.GetField("usage_p50")
+		require.True(t, ok)
+		require.InEpsilon(t, expected, value, 0.01)
+	}
+}
+
+func TestInit(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(q *Quantile)
+		err   string
+	}{
+		{
+			name:  "quantile out of range",
+			setup: func(q *Quantile) { q.Quantiles = []float64{0.5, 95} },
+			err:   "quantile 95 is not in the range [0,1]",
+		},
+		{
+			name:  "invalid relative accuracy",
+			setup: func(q *Quantile) { q.RelativeAccuracy = 1 },
+			err:   "relative_accuracy must be between 0 and 1, got 1",
+		},
+		{
+			name:  "invalid max bins",
+			setup: func(q *Quantile) { q.MaxBins = 0 },
+			err:   "max_bins must be at least 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := NewQuantile()
+			tt.setup(q)
+			require.EqualError(t, q.Init(), tt.err)
+		})
+	}
+}
+
+func TestSuffix(t *testing.T) {
+	require.Equal(t, "_p50", suffix(0.5))
+	require.Equal(t, "_p7", suffix(0.07))
+	require.Equal(t, "_p99_9", suffix(0.999))
+	require.Equal(t, "_p0", suffix(0))
+}
@@ -0,0 +1,143 @@
+package quantile
+
+import (
+	"math"
+	"sort"
+)
+
+// minIndexableValue is the smallest absolute value stored in a bin, smaller
+// values are counted as zero.
+const minIndexableValue = 1e-9
+
+// sketch is a DDSketch, a streaming quantile sketch with relative accuracy
+// guarantees.  Values are counted in logarithmically sized bins so that any
+// value of a bin is within the relative accuracy of the value representing
+// the bin.  A quantile q returned by the sketch is therefore within
+// relativeAccuracy of the exact quantile, the value of rank q*(n-1) of the
+// sorted values, as long as no bins were collapsed.
+//
+// At most maxBins bins are kept for the positive and for the negative values.
+// When the limit is exceeded the bins of the values closest to zero are
+// collapsed, so only the quantiles of these values lose accuracy.
+//
+// Sketches with the same relative accuracy can be merged.
+type sketch struct {
+	gamma     float64
+	logGamma  float64
+	maxBins   int
+	positive  map[int]uint64
+	negative  map[int]uint64
+	zeroCount uint64
+	count     uint64
+}
+
+func newSketch(relativeAccuracy float64, maxBins int) *sketch {
+	gamma := (1 + relativeAccuracy) / (1 - relativeAccuracy)
+	return &sketch{
+		gamma:    gamma,
+		logGamma: math.Log(gamma),
+		maxBins:  maxBins,
+		positive: make(map[int]uint64),
+		negative: make(map[int]uint64),
+	}
+}
+
+// index returns the bin of a positive value.
+func (s *sketch) index(v float64) int {
+	return int(math.Ceil(math.Log(v) / s.logGamma))
+}
+
+// value returns the value representing a bin, the value with the same
+// relative distance to both bounds of the bin.
+func (s *sketch) value(index int) float64 {
+	return 2 * math.Pow(s.gamma, float64(index)) / (s.gamma + 1)
+}
+
+// Add counts a value in the sketch.
+func (s *sketch) Add(v float64) {
+	switch {
+	case v > minIndexableValue:
+		s.positive[s.index(v)]++
+		s.collapse(s.positive)
+	case v < -minIndexableValue:
+		s.negative[s.index(-v)]++
+		s.collapse(s.negative)
+	default:
+		s.zeroCount++
+	}
+	s.count++
+}
+
+// Merge adds the values counted by another sketch, created with the same
+// relative accuracy.
+func (s *sketch) Merge(o *sketch) {
+	for index, count := range o.positive {
+		s.positive[index] += count
+	}
+	for index, count := range o.negative {
+		s.negative[index] += count
+	}
+	s.collapse(s.positive)
+	s.collapse(s.negative)
+	s.zeroCount += o.zeroCount
+	s.count += o.count
+}
+
+// collapse merges the lowest bins of the store into the lowest remaining bin
+// while there are more than maxBins bins.
+func (s *sketch) collapse(bins map[int]uint64) {
+	if len(bins) <= s.maxBins {
+		return
+	}
+
+	indexes := sortedIndexes(bins)
+	excess := len(indexes) - s.maxBins
+	target := indexes[excess]
+	for _, index := range indexes[:excess] {
+		bins[target] += bins[index]
+		delete(bins, index)
+	}
+}
+
+// Quantile returns the value of the quantile q in [0, 1], or false if no
+// value was added.
+func (s *sketch) Quantile(q float64) (float64, bool) {
+	if s.count == 0 {
+		return 0, false
+	}
+
+	rank := uint64(q * float64(s.count-1))
+	var n uint64
+
+	// Negative values in ascending order, from the largest absolute value.
+	indexes := sortedIndexes(s.negative)
+	for i := len(indexes) - 1; i >= 0; i-- {
+		n += s.negative[indexes[i]]
+		if n > rank {
+			return -s.value(indexes[i]), true
+		}
+	}
+
+	n += s.zeroCount
+	if n > rank {
+		return 0, true
+	}
+
+	indexes = sortedIndexes(s.positive)
+	for _, index := range indexes {
+		n += s.positive[index]
+		if n > rank {
+			return s.value(index), true
+		}
+	}
+	return s.value(indexes[len(indexes)-1]), true
+}
+
+func sortedIndexes(bins map[int]uint64) []int {
+	indexes := make([]int, 0, len(bins))
+	for index := range bins {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+	return indexes
+}
@@ -0,0 +1,105 @@
+package quantile
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testQuantiles = []float64{0, 0.01, 0.1, 0.25, 0.5, 0.75, 0.9, 0.95, 0.99, 0.999, 1}
+
+// exactQuantile returns the value of rank q*(n-1) of the sorted values.
+func exactQuantile(sorted []float64, q float64) float64 {
+	return sorted[int(q*float64(len(sorted)-1))]
+}
+
+// requireAccuracy checks that every quantile of the sketch is within the
+// relative accuracy of the exact quantile.
+func requireAccuracy(t *testing.T, s *sketch, values []float64, relativeAccuracy float64) {
+	t.Helper()
+
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	for _, q := range testQuantiles {
+		expected := exactQuantile(sorted, q)
+		actual, ok := s.Quantile(q)
+		require.True(t, ok)
+		require.InDelta(t, expected, actual, relativeAccuracy*math.Abs(expected)+1e-9,
+			"quantile %v", q)
+	}
+}
+
+func TestSketchAccuracy(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+	distributions := map[string]func() float64{
+		"uniform":     func() float64 { return rng.Float64() * 1000 },
+		"normal":      func() float64 { return rng.NormFloat64()*100 + 20 },
+		"exponential": func() float64 { return rng.ExpFloat64() * 1e6 },
+		"integers":    func() float64 { return float64(rng.Intn(100) - 50) },
+	}
+
+	for name, next := range distributions {
+		for _, relativeAccuracy := range []float64{0.01, 0.05} {
+			s := newSketch(relativeAccuracy, 2048)
+			values := make([]float64, 10000)
+			for i := range values {
+				values[i] = next()
+				s.Add(values[i])
+			}
+			t.Run(fmt.Sprintf("%s %v", name, relativeAccuracy), func(t *testing.T) {
+				requireAccuracy(t, s, values, relativeAccuracy)
+			})
+		}
+	}
+}
+
+func TestSketchMerge(t *testing.T) {
+	rng := rand.New(rand.NewSource(42))
+	s1 := newSketch(0.01, 2048)
+	s2 := newSketch(0.01, 2048)
+
+	values := make([]float64, 2000)
+	for i := range values {
+		values[i] = rng.NormFloat64() * 100
+		if i%2 == 0 {
+			s1.Add(values[i])
+		} else {
+			s2.Add(values[i])
+		}
+	}
+
+	s1.Merge(s2)
+	require.Equal(t, uint64(len(values)), s1.count)
+	requireAccuracy(t, s1, values, 0.01)
+}
+
+func TestSketchMaxBins(t *testing.T) {
+	s := newSketch(0.01, 10)
+	var values []float64
+	for i := 1; i <= 1000; i++ {
+		values = append(values, float64(i))
+		s.Add(float64(i))
+	}
+	require.Len(t, s.positive, 10)
+
+	// The highest quantiles keep their accuracy.
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	for _, q := range []float64{0.99, 1} {
+		actual, ok := s.Quantile(q)
+		require.True(t, ok)
+		expected := exactQuantile(sorted, q)
+		require.InDelta(t, expected, actual, 0.01*expected)
+	}
+}
+
+func TestSketchEmpty(t *testing.T) {
+	s := newSketch(0.01, 2048)
+	_, ok := s.Quantile(0.5)
+	require.False(t, ok)
+}
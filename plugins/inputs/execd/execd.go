@@ -0,0 +1,135 @@
+package execd
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/process"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+const sampleConfig = `
+  ## Program to run as daemon, and its arguments.
+  command = ["telegraf-smartctl", "-d", "/dev/sda"]
+
+  ## Define how the process is signaled on each collection interval.
+  ## Valid values are:
+  ##   "none"    : Do not signal anything.
+  ##               The process must output metrics by itself.
+  ##   "STDIN"   : Send a newline on STDIN.
+  ##   "SIGHUP"  : Send a HUP signal. Not available on Windows.
+  ##   "SIGUSR1" : Send a USR1 signal. Not available on Windows.
+  ##   "SIGUSR2" : Send a USR2 signal. Not available on Windows.
+  signal = "none"
+
+  ## Delay before the process is restarted after an unexpected termination.
+  ## The delay is doubled on every restart up to the max_restart_delay.
+  restart_delay = "10s"
+  # max_restart_delay = "5m"
+
+  ## Data format to consume.
+  ## Each data format has its own unique set of configuration options, read
+  ## more about them here:
+  ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_INPUT.md
+  data_format = "influx"
+`
+
+type Execd struct {
+	Command         []string          `toml:"command"`
+	Signal          string            `toml:"signal"`
+	RestartDelay    internal.Duration `toml:"restart_delay"`
+	MaxRestartDelay internal.Duration `toml:"max_restart_delay"`
+
+	acc     telegraf.Accumulator
+	parser  parsers.Parser
+	process *process.Process
+}
+
+func NewExecd() *Execd {
+	return &Execd{
+		Signal:          "none",
+		RestartDelay:    internal.Duration{Duration: 10 * time.Second},
+		MaxRestartDelay: internal.Duration{Duration: 5 * time.Minute},
+	}
+}
+
+func (e *Execd) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *Execd) Description() string {
+	return "Run executable as long-running input plugin"
+}
+
+func (e *Execd) SetParser(parser parsers.Parser) {
+	e.parser = parser
+}
+
+func (e *Execd) Start(acc telegraf.Accumulator) error {
+	if err := checkSignal(e.Signal); err != nil {
+		return err
+	}
+
+	p, err := process.New(e.Command)
+	if err != nil {
+		return err
+	}
+	p.Name = "inputs.execd"
+	p.RestartDelay = e.RestartDelay.Duration
+	p.MaxRestartDelay = e.MaxRestartDelay.Duration
+	p.ReadStdoutFn = e.readStdout
+	p.ReadStderrFn = e.readStderr
+
+	e.acc = acc
+	e.process = p
+	return p.Start()
+}
+
+func (e *Execd) Stop() {
+	e.process.Stop()
+}
+
+func (e *Execd) Gather(acc telegraf.Accumulator) error {
+	if strings.ToUpper(e.Signal) == "STDIN" {
+		if _, err := e.process.Write([]byte{'\n'}); err != nil {
+			return fmt.Errorf("error writing to stdin: %v", err)
+		}
+		return nil
+	}
+	return e.sendSignal()
+}
+
+func (e *Execd) readStdout(r io.Reader) {
+	process.ReadLines(r, func(line []byte) {
+		metrics, err := e.parser.Parse(line)
+		if err != nil {
+			e.acc.AddError(fmt.Errorf("parse error: %v", err))
+			return
+		}
+		for _, m := range metrics {
+			e.acc.AddMetric(m)
+		}
+	}, func(err error) {
+		e.acc.AddError(fmt.Errorf("error reading stdout: %v", err))
+	})
+}
+
+func (e *Execd) readStderr(r io.Reader) {
+	process.ReadLines(r, func(line []byte) {
+		log.Printf("E! [inputs.execd] stderr: %q", line)
+	}, func(err error) {
+		e.acc.AddError(fmt.Errorf("error reading stderr: %v", err))
+	})
+}
+
+func init() {
+	inputs.Add("execd", func() telegraf.Input {
+		return NewExecd()
+	})
+}
@@ -0,0 +1,33 @@
+// +build !windows
+
+package execd
+
+import (
+	"fmt"
+	"strings"
+	"syscall"
+)
+
+func checkSignal(signal string) error {
+	switch strings.ToUpper(signal) {
+	case "", "NONE", "STDIN", "SIGHUP", "SIGUSR1", "SIGUSR2":
+		return nil
+	}
+	return fmt.Errorf("invalid signal %q", signal)
+}
+
+func (e *Execd) sendSignal() error {
+	var err error
+	switch strings.ToUpper(e.Signal) {
+	case "SIGHUP":
+		err = e.process.Signal(syscall.SIGHUP)
+	case "SIGUSR1":
+		err = e.process.Signal(syscall.SIGUSR1)
+	case "SIGUSR2":
+		err = e.process.Signal(syscall.SIGUSR2)
+	}
+	if err != nil {
+		return fmt.Errorf("error signaling process: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package execd
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+var counter = flag.Bool("counter", false,
+	"if true, act like the counter program instead of running the tests")
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if *counter {
+		runCounterProgram()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runCounterProgram writes a metric with the number of lines read from stdin
+// for every line.
+func runCounterProgram() {
+	i := 0
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		i++
+		fmt.Printf("counter count=%di\n", i)
+	}
+}
+
+func newCounter(t *testing.T) *Execd {
+	parser, err := parsers.NewInfluxParser()
+	require.NoError(t, err)
+
+	e := NewExecd()
+	e.Command = []string{os.Args[0], "-counter"}
+	e.Signal = "STDIN"
+	e.SetParser(parser)
+	return e
+}
+
+func TestSignalStdin(t *testing.T) {
+	e := newCounter(t)
+
+	var acc testutil.Accumulator
+	require.NoError(t, e.Start(&acc))
+	defer e.Stop()
+
+	require.NoError(t, e.Gather(&acc))
+	acc.Wait(1)
+	require.NoError(t, e.Gather(&acc))
+	acc.Wait(2)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("counter",
+			map[string]string{},
+			map[string]interface{}{"count": int64(1)},
+			time.Unix(0, 0)),
+		testutil.MustMetric("counter",
+			map[string]string{},
+			map[string]interface{}{"count": int64(2)},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics(), testutil.IgnoreTime())
+}
+
+func TestRestart(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test on windows; sh is not available")
+	}
+
+	parser, err := parsers.NewInfluxParser()
+	require.NoError(t, err)
+
+	e := NewExecd()
+	e.Command = []string{"sh", "-c", "echo 'started value=1i'; echo oops >&2"}
+	e.RestartDelay.Duration = time.Millisecond
+	e.MaxRestartDelay.Duration = 10 * time.Millisecond
+	e.SetParser(parser)
+
+	var acc testutil.Accumulator
+	require.NoError(t, e.Start(&acc))
+	acc.Wait(3)
+	e.Stop()
+
+	for _, m := range acc.GetTelegrafMetrics()[:3] {
+		require.Equal(t, "started", m.Name())
+	}
+}
+
+func TestParseError(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test on windows; sh is not available")
+	}
+
+	parser, err := parsers.NewInfluxParser()
+	require.NoError(t, err)
+
+	e := NewExecd()
+	e.Command = []string{"sh", "-c", "echo invalid; read line"}
+	e.SetParser(parser)
+
+	var acc testutil.Accumulator
+	require.NoError(t, e.Start(&acc))
+	defer e.Stop()
+
+	acc.WaitError(1)
+	require.Contains(t, acc.FirstError().Error(), "parse error")
+}
+
+func TestLongLine(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test on windows; sh is not available")
+	}
+
+	parser, err := parsers.NewInfluxParser()
+	require.NoError(t, err)
+
+	// The line is longer than the line buffer and the pipe buffer, the
+	// process can only exit if its output is read completely.  Only the long
+	// line is skipped, the following lines are still parsed.
+	e := NewExecd()
+	e.Command = []string{"sh", "-c", "head -c 1000000 /dev/zero | tr '\\0' a; echo; echo 'cpu value=42'"}
+	e.SetParser(parser)
+
+	var acc testutil.Accumulator
+	require.NoError(t, e.Start(&acc))
+
+	acc.Wait(1)
+	require.Equal(t, "error reading stdout: bufio.Scanner: token too long", acc.FirstError().Error())
+	acc.AssertContainsFields(t, "cpu", map[string]interface{}{"value": 42.0})
+
+	done := make(chan struct{})
+	go func() {
+		e.Stop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("process blocked writing its output")
+	}
+}
+
+func TestInvalidSignal(t *testing.T) {
+	e := NewExecd()
+	e.Command = []string{"true"}
+	e.Signal = "SIGFOO"
+
+	var acc testutil.Accumulator
+	require.Error(t, e.Start(&acc))
+}
+
+func TestNoCommand(t *testing.T) {
+	e := NewExecd()
+
+	var acc testutil.Accumulator
+	require.EqualError(t, e.Start(&acc), "no command specified")
+}
@@ -0,0 +1,22 @@
+// +build windows
+
+package execd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// checkSignal only allows signaling the process by its stdin, signals are
+// not supported on Windows.
+func checkSignal(signal string) error {
+	switch strings.ToUpper(signal) {
+	case "", "NONE", "STDIN":
+		return nil
+	}
+	return fmt.Errorf("invalid signal %q, signals are not supported on Windows", signal)
+}
+
+func (e *Execd) sendSignal() error {
+	return nil
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal/globpath"
+	"github.com/influxdata/telegraf/internal/offsetstate"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
 	// Parsers
@@ -47,9 +48,11 @@ type LogParserPlugin struct {
 	Files         []string
 	FromBeginning bool
 	WatchMethod   string
+	OffsetsFile   string `toml:"offsets_file"`
 
 	tailers map[string]*tail.Tail
 	offsets map[string]int64
+	state   *offsetstate.State
 	lines   chan logEntry
 	done    chan struct{}
 	wg      sync.WaitGroup
@@ -92,6 +95,12 @@ const sampleConfig = `
   ## Method used to watch for file updates.  Can be either "inotify" or "poll".
   # watch_method = "inotify"
 
+  ## File to save the offsets of the files in, so that reading resumes where
+  ## it stopped after a restart.  Files that were rotated or truncated in the
+  ## meantime are read from the beginning.  The offsets are saved every
+  ## interval and when telegraf stops.
+  # offsets_file = "/var/lib/telegraf/logparser_offsets.json"
+
   ## Parse logstash-style "grok" patterns:
   [inputs.logparser.grok]
     ## This is a list of patterns to check the given log file(s) for.
@@ -143,6 +152,8 @@ func (l *LogParserPlugin) Gather(acc telegraf.Accumulator) error {
 	l.Lock()
 	defer l.Unlock()
 
+	l.saveOffsets()
+
 	// always start from the beginning of files that appear while we're running
 	return l.tailNewfiles(true)
 }
@@ -180,6 +191,13 @@ func (l *LogParserPlugin) Start(acc telegraf.Accumulator) error {
 		return err
 	}
 
+	if l.OffsetsFile != "" {
+		l.state, err = offsetstate.Open(l.OffsetsFile)
+		if err != nil {
+			return err
+		}
+	}
+
 	l.wg.Add(1)
 	go l.parser()
 
@@ -219,18 +237,22 @@ func (l *LogParserPlugin) tailNewfiles(fromBeginning bool) error {
 			}
 
 			var seek *tail.SeekInfo
-			if !fromBeginning {
-				if offset, ok := l.offsets[file]; ok {
-					log.Printf("D! [inputs.tail] using offset %d for file: %v", offset, file)
-					seek = &tail.SeekInfo{
-						Whence: 0,
-						Offset: offset,
-					}
-				} else {
-					seek = &tail.SeekInfo{
-						Whence: 2,
-						Offset: 0,
-					}
+			if offset, ok := l.offsets[file]; ok && !fromBeginning {
+				log.Printf("D! [inputs.tail] using offset %d for file: %v", offset, file)
+				seek = &tail.SeekInfo{
+					Whence: 0,
+					Offset: offset,
+				}
+			} else if offset, ok := l.savedOffset(file); ok {
+				log.Printf("D! [inputs.logparser] using saved offset %d for file: %v", offset, file)
+				seek = &tail.SeekInfo{
+					Whence: 0,
+					Offset: offset,
+				}
+			} else if !fromBeginning {
+				seek = &tail.SeekInfo{
+					Whence: 2,
+					Offset: 0,
 				}
 			}
 
@@ -337,6 +359,10 @@ func (l *LogParserPlugin) Stop() {
 				l.acc.AddError(fmt.Errorf("error recording offset for file %s", t.Filename))
 			}
 		}
+	}
+	l.saveOffsets()
+
+	for _, t := range l.tailers {
 		err := t.Stop()
 
 		//message for a stopped tailer
@@ -357,6 +383,36 @@ func (l *LogParserPlugin) Stop() {
 	offsetsMutex.Unlock()
 }
 
+// savedOffset returns the offset saved in the offsets file for the file.
+func (l *LogParserPlugin) savedOffset(file string) (int64, bool) {
+	if l.state == nil {
+		return 0, false
+	}
+	return l.state.Resume(file)
+}
+
+// saveOffsets writes the current offsets of the tailed files to the offsets
+// file.  Assumes l's lock is held!
+func (l *LogParserPlugin) saveOffsets() {
+	if l.state == nil {
+		return
+	}
+
+	for _, t := range l.tailers {
+		offset, err := t.Tell()
+		if err != nil {
+			l.acc.AddError(fmt.Errorf("error recording offset for file %s", t.Filename))
+			continue
+		}
+		if err := l.state.Record(t.Filename, offset); err != nil {
+			l.acc.AddError(fmt.Errorf("error recording offset for file %s: %v", t.Filename, err))
+		}
+	}
+	if err := l.state.Save(); err != nil {
+		l.acc.AddError(fmt.Errorf("error saving offsets to %s: %v", l.OffsetsFile, err))
+	}
+}
+
 func init() {
 	inputs.Add("logparser", func() telegraf.Input {
 		return NewLogParser()
@@ -36,6 +36,14 @@ const sampleConfig = `
   ## The GETBULK max-repetitions parameter
   max_repetitions = 10
 
+  ## Translator looking up the OIDs and tables in the MIBs, "builtin" parses
+  ## the MIB files found in the path, "netsnmp" runs the snmptranslate and
+  ## snmptable commands of net-snmp.
+  # translator = "builtin"
+
+  ## Directories searched recursively for MIB files by the builtin translator.
+  # path = ["/usr/share/snmp/mibs"]
+
   ## SNMPv3 auth parameters
   #sec_name = "myuser"
   #auth_protocol = "md5"      # Values: "MD5", "SHA", ""
@@ -137,6 +145,11 @@ type Snmp struct {
 	EngineBoots  uint32
 	EngineTime   uint32
 
+	// Values: "builtin", "netsnmp". Default: "builtin"
+	Translator string `toml:"translator"`
+	// Directories of the MIB files of the builtin translator.
+	Path []string `toml:"path"`
+
 	Tables []Table `toml:"table"`
 
 	// Name & Fields are the elements of a Table.
@@ -156,14 +169,19 @@ func (s *Snmp) init() error {
 
 	s.connectionCache = make([]snmpConnection, len(s.Agents))
 
+	tr, err := s.getTranslator()
+	if err != nil {
+		return err
+	}
+
 	for i := range s.Tables {
-		if err := s.Tables[i].init(); err != nil {
+		if err := s.Tables[i].init(tr); err != nil {
 			return Errorf(err, "initializing table %s", s.Tables[i].Name)
 		}
 	}
 
 	for i := range s.Fields {
-		if err := s.Fields[i].init(); err != nil {
+		if err := s.Fields[i].init(tr); err != nil {
 			return Errorf(err, "initializing field %s", s.Fields[i].Name)
 		}
 	}
@@ -195,18 +213,18 @@ type Table struct {
 }
 
 // init() builds & initializes the nested fields.
-func (t *Table) init() error {
+func (t *Table) init(tr translator) error {
 	if t.initialized {
 		return nil
 	}
 
-	if err := t.initBuild(); err != nil {
+	if err := t.initBuild(tr); err != nil {
 		return err
 	}
 
 	// initialize all the nested fields
 	for i := range t.Fields {
-		if err := t.Fields[i].init(); err != nil {
+		if err := t.Fields[i].init(tr); err != nil {
 			return Errorf(err, "initializing field %s", t.Fields[i].Name)
 		}
 	}
@@ -216,14 +234,14 @@ func (t *Table) init() error {
 }
 
 // initBuild initializes the table if it has an OID configured. If so, the
-// net-snmp tools will be used to look up the OID and auto-populate the table's
+// translator will be used to look up the OID and auto-populate the table's
 // fields.
-func (t *Table) initBuild() error {
+func (t *Table) initBuild(tr translator) error {
 	if t.Oid == "" {
 		return nil
 	}
 
-	_, _, oidText, fields, err := snmpTable(t.Oid)
+	_, _, oidText, fields, err := tr.table(t.Oid)
 	if err != nil {
 		return err
 	}
@@ -272,12 +290,12 @@ type Field struct {
 }
 
 // init() converts OID names to numbers, and sets the .Name attribute if unset.
-func (f *Field) init() error {
+func (f *Field) init(tr translator) error {
 	if f.initialized {
 		return nil
 	}
 
-	_, oidNum, oidText, conversion, err := snmpTranslate(f.Oid)
+	_, oidNum, oidText, conversion, err := tr.translate(f.Oid)
 	if err != nil {
 		return Errorf(err, "translating")
 	}
@@ -344,6 +362,7 @@ func init() {
 			Timeout:        internal.Duration{Duration: 5 * time.Second},
 			Version:        2,
 			Community:      "public",
+			Translator:     "builtin",
 		}
 	})
 }
@@ -662,7 +681,7 @@ func (s *Snmp) getConnection(idx int) (snmpConnection, error) {
 		}
 	}
 
-	gs.MaxRepetitions = s.MaxRepetitions
+	gs.MaxRepetitions = uint32(s.MaxRepetitions)
 
 	if s.Version == 3 {
 		gs.ContextName = s.ContextName
@@ -1016,12 +1035,7 @@ func snmpTranslateCall(oid string) (mibName string, oidNum string, oidText strin
 
 		if strings.HasPrefix(line, "  -- TEXTUAL CONVENTION ") {
 			tc := strings.TrimPrefix(line, "  -- TEXTUAL CONVENTION ")
-			switch tc {
-			case "MacAddress", "PhysAddress":
-				conversion = "hwaddr"
-			case "InetAddressIPv4", "InetAddressIPv6", "InetAddress", "IPSIpAddress":
-				conversion = "ipaddr"
-			}
+			conversion = tcConversion(tc)
 		} else if strings.HasPrefix(line, "::= { ") {
 			objs := strings.TrimPrefix(line, "::= { ")
 			objs = strings.TrimSuffix(objs, " }")
@@ -0,0 +1,185 @@
+package snmp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/influxdata/telegraf/internal/snmp"
+)
+
+// translator looks up OIDs and tables in the MIBs.
+type translator interface {
+	translate(oid string) (mibName string, oidNum string, oidText string, conversion string, err error)
+	table(oid string) (mibName string, oidNum string, oidText string, fields []Field, err error)
+}
+
+func (s *Snmp) getTranslator() (translator, error) {
+	switch s.Translator {
+	case "netsnmp":
+		return netsnmpTranslator{}, nil
+	case "", "builtin":
+		path := s.Path
+		if len(path) == 0 {
+			path = snmp.DefaultMibPath
+		}
+		return getMibTranslator(path)
+	default:
+		return nil, fmt.Errorf("invalid translator %q", s.Translator)
+	}
+}
+
+// netsnmpTranslator runs the snmptranslate and snmptable commands of
+// net-snmp.
+type netsnmpTranslator struct{}
+
+func (netsnmpTranslator) translate(oid string) (string, string, string, string, error) {
+	return snmpTranslate(oid)
+}
+
+func (netsnmpTranslator) table(oid string) (string, string, string, []Field, error) {
+	return snmpTable(oid)
+}
+
+// mibTranslator looks up the OIDs in the MIB files loaded by the
+// internal/snmp package.  The lookups are cached like the ones of net-snmp.
+type mibTranslator struct {
+	tree *snmp.MibTree
+
+	sync.Mutex
+	translateCache map[string]snmpTranslateCache
+	tableCache     map[string]snmpTableCache
+}
+
+var mibTranslators map[string]*mibTranslator
+var mibTranslatorsLock sync.Mutex
+
+// getMibTranslator returns the translator for the MIB files in the paths.
+// The files are only loaded once for all plugins using the same paths.
+func getMibTranslator(paths []string) (*mibTranslator, error) {
+	mibTranslatorsLock.Lock()
+	defer mibTranslatorsLock.Unlock()
+
+	if mibTranslators == nil {
+		mibTranslators = map[string]*mibTranslator{}
+	}
+
+	key := strings.Join(paths, "\x00")
+	if mt, ok := mibTranslators[key]; ok {
+		return mt, nil
+	}
+
+	tree, err := snmp.LoadMibs(paths)
+	if err != nil {
+		return nil, Errorf(err, "loading MIBs")
+	}
+	mt := &mibTranslator{
+		tree:           tree,
+		translateCache: map[string]snmpTranslateCache{},
+		tableCache:     map[string]snmpTableCache{},
+	}
+	mibTranslators[key] = mt
+	return mt, nil
+}
+
+func (mt *mibTranslator) translate(oid string) (string, string, string, string, error) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	stc, ok := mt.translateCache[oid]
+	if !ok {
+		stc.mibName, stc.oidNum, stc.oidText, stc.conversion, stc.err = mt.translateCall(oid)
+		mt.translateCache[oid] = stc
+	}
+	return stc.mibName, stc.oidNum, stc.oidText, stc.conversion, stc.err
+}
+
+func (mt *mibTranslator) translateCall(oid string) (mibName string, oidNum string, oidText string, conversion string, err error) {
+	node, suffix, err := mt.tree.Lookup(oid)
+	if err != nil {
+		return "", "", "", "", err
+	}
+
+	var b strings.Builder
+	b.WriteString(node.OID())
+	for _, n := range suffix {
+		b.WriteString(".")
+		b.WriteString(strconv.FormatUint(uint64(n), 10))
+	}
+	oidNum = b.String()
+
+	if node.Module == "" {
+		// Not found in any MIB, like net-snmp the numeric OID is used as text.
+		return "", oidNum, oidNum, "", nil
+	}
+
+	mibName = node.Module
+	oidText = node.Name + strings.TrimPrefix(oidNum, node.OID())
+
+	for _, tc := range mt.tree.Conventions(node) {
+		if conversion = tcConversion(tc); conversion != "" {
+			break
+		}
+	}
+
+	return mibName, oidNum, oidText, conversion, nil
+}
+
+func (mt *mibTranslator) table(oid string) (string, string, string, []Field, error) {
+	mt.Lock()
+	defer mt.Unlock()
+
+	stc, ok := mt.tableCache[oid]
+	if !ok {
+		stc.mibName, stc.oidNum, stc.oidText, stc.fields, stc.err = mt.tableCall(oid)
+		mt.tableCache[oid] = stc
+	}
+	return stc.mibName, stc.oidNum, stc.oidText, stc.fields, stc.err
+}
+
+func (mt *mibTranslator) tableCall(oid string) (mibName string, oidNum string, oidText string, fields []Field, err error) {
+	node, suffix, err := mt.tree.Lookup(oid)
+	if err != nil {
+		return "", "", "", nil, Errorf(err, "translating")
+	}
+	if len(suffix) > 0 || node.Module == "" {
+		return "", "", "", nil, fmt.Errorf("could not find any columns in table")
+	}
+
+	// As with net-snmp, the entry is assumed to be the first child of the
+	// table.
+	entry := node.Child(1)
+	if entry == nil {
+		return "", "", "", nil, fmt.Errorf("could not find any columns in table")
+	}
+
+	tagOids := map[string]struct{}{}
+	for _, index := range mt.tree.TableIndex(entry) {
+		tagOids[index] = struct{}{}
+	}
+
+	for _, col := range entry.Children() {
+		if col.Name == "" || col.Access == "not-accessible" {
+			continue
+		}
+		_, isTag := tagOids[col.Name]
+		fields = append(fields, Field{Name: col.Name, Oid: col.Module + "::" + col.Name, IsTag: isTag})
+	}
+	if len(fields) == 0 {
+		return "", "", "", nil, fmt.Errorf("could not find any columns in table")
+	}
+
+	return node.Module, node.OID(), node.Name, fields, nil
+}
+
+// tcConversion returns the conversion of the values of a textual convention.
+func tcConversion(tc string) string {
+	switch tc {
+	case "MacAddress", "PhysAddress":
+		return "hwaddr"
+	case "InetAddressIPv4", "InetAddressIPv6", "InetAddress", "IPSIpAddress":
+		return "ipaddr"
+	}
+	return ""
+}
@@ -476,7 +476,7 @@ func (h *Host) SNMPMap(
 			oid_next := oid_asked
 			need_more_requests := true
 			// Set max repetition
-			maxRepetition := uint8(32)
+			maxRepetition := uint32(32)
 			// Launch requests
 			for need_more_requests {
 				// Launch request
@@ -667,7 +667,7 @@ func (h *Host) SNMPBulk(acc telegraf.Accumulator, initNode Node) error {
 		// Launch requests
 		for need_more_requests {
 			// Launch request
-			result, err3 := snmpClient.GetBulk([]string{oid}, 0, maxRepetition)
+			result, err3 := snmpClient.GetBulk([]string{oid}, 0, uint32(maxRepetition))
 			if err3 != nil {
 				return err3
 			}
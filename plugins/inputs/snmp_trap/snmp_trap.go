@@ -0,0 +1,339 @@
+package snmp_trap
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal/snmp"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/soniah/gosnmp"
+)
+
+const (
+	// snmpTrapOID is the varbind holding the OID of a SNMPv2 trap.
+	snmpTrapOID = ".1.3.6.1.6.3.1.1.4.1.0"
+	// snmpTraps is the prefix of the OIDs of the generic SNMPv1 traps.
+	snmpTraps = ".1.3.6.1.6.3.1.1.5."
+	// sysUpTimeInstance holds the uptime of the agent sending a trap.
+	sysUpTimeInstance = ".1.3.6.1.2.1.1.3.0"
+
+	// enterpriseSpecific is the generic trap of SNMPv1 specific traps.
+	enterpriseSpecific = 6
+)
+
+var sampleConfig = `
+  ## Transport, local address, and port to listen on.  Transport must
+  ## be "udp://".  Omit local address to listen on all interfaces.
+  ##   example: "udp://127.0.0.1:1234"
+  # service_address = "udp://:162"
+
+  ## Directories searched recursively for the MIB files used to translate
+  ## the OIDs to names.
+  # path = ["/usr/share/snmp/mibs"]
+
+  ## SNMP version; one of "1", "2c" or "3".  SNMPv1 and SNMPv2c traps are
+  ## received with any version, with version "3" the options below are used
+  ## to authenticate and decrypt SNMPv3 traps.
+  # version = "2c"
+
+  ## SNMPv3 authentication and encryption options.
+  ##
+  ## Security Name.
+  # sec_name = "myuser"
+  ## Authentication protocol; one of "MD5", "SHA" or "".
+  # auth_protocol = "MD5"
+  ## Authentication password.
+  # auth_password = "pass"
+  ## Security Level; one of "noAuthNoPriv", "authNoPriv", or "authPriv".
+  # sec_level = "authNoPriv"
+  ## Privacy protocol used for encrypted messages; one of "DES", "AES" or "".
+  # priv_protocol = ""
+  ## Privacy password used for encrypted messages.
+  # priv_password = ""
+`
+
+type mibEntry struct {
+	mibName string
+	oidText string
+}
+
+type SnmpTrap struct {
+	ServiceAddress string   `toml:"service_address"`
+	Path           []string `toml:"path"`
+	Version        string   `toml:"version"`
+
+	// SNMPv3 security parameters
+	SecName      string `toml:"sec_name"`
+	AuthProtocol string `toml:"auth_protocol"`
+	AuthPassword string `toml:"auth_password"`
+	SecLevel     string `toml:"sec_level"`
+	PrivProtocol string `toml:"priv_protocol"`
+	PrivPassword string `toml:"priv_password"`
+
+	acc      telegraf.Accumulator
+	listener *gosnmp.TrapListener
+	tree     *snmp.MibTree
+	cache    map[string]mibEntry
+	now      func() time.Time
+}
+
+func NewSnmpTrap() *SnmpTrap {
+	return &SnmpTrap{
+		ServiceAddress: "udp://:162",
+		Path:           snmp.DefaultMibPath,
+		Version:        "2c",
+		now:            time.Now,
+	}
+}
+
+func (s *SnmpTrap) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *SnmpTrap) Description() string {
+	return "Receive SNMP traps"
+}
+
+func (s *SnmpTrap) Gather(_ telegraf.Accumulator) error {
+	return nil
+}
+
+func (s *SnmpTrap) Start(acc telegraf.Accumulator) error {
+	u, err := url.Parse(s.ServiceAddress)
+	if err != nil {
+		return fmt.Errorf("invalid service address %q: %v", s.ServiceAddress, err)
+	}
+	if u.Scheme != "udp" {
+		return fmt.Errorf("unsupported transport %q in service address", u.Scheme)
+	}
+
+	params, err := s.params()
+	if err != nil {
+		return err
+	}
+
+	s.tree, err = snmp.LoadMibs(s.Path)
+	if err != nil {
+		return err
+	}
+
+	s.acc = acc
+	s.cache = make(map[string]mibEntry)
+	s.listener = gosnmp.NewTrapListener()
+	s.listener.OnNewTrap = s.handle
+	s.listener.Params = params
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- s.listener.Listen(u.Host)
+	}()
+
+	select {
+	case <-s.listener.Listening():
+		log.Printf("I! [inputs.snmp_trap] Listening on %s", s.ServiceAddress)
+	case err := <-errCh:
+		return err
+	}
+	return nil
+}
+
+func (s *SnmpTrap) Stop() {
+	s.listener.Close()
+}
+
+// params returns the gosnmp parameters used to decode the traps.
+func (s *SnmpTrap) params() (*gosnmp.GoSNMP, error) {
+	params := &gosnmp.GoSNMP{}
+
+	switch s.Version {
+	case "1":
+		params.Version = gosnmp.Version1
+	case "2c", "":
+		params.Version = gosnmp.Version2c
+	case "3":
+		params.Version = gosnmp.Version3
+	default:
+		return nil, fmt.Errorf("invalid version %q", s.Version)
+	}
+
+	if params.Version != gosnmp.Version3 {
+		return params, nil
+	}
+
+	sp := &gosnmp.UsmSecurityParameters{}
+	params.SecurityParameters = sp
+	params.SecurityModel = gosnmp.UserSecurityModel
+
+	switch strings.ToLower(s.SecLevel) {
+	case "noauthnopriv", "":
+		params.MsgFlags = gosnmp.NoAuthNoPriv
+	case "authnopriv":
+		params.MsgFlags = gosnmp.AuthNoPriv
+	case "authpriv":
+		params.MsgFlags = gosnmp.AuthPriv
+	default:
+		return nil, fmt.Errorf("invalid sec_level %q", s.SecLevel)
+	}
+
+	sp.UserName = s.SecName
+
+	switch strings.ToLower(s.AuthProtocol) {
+	case "md5":
+		sp.AuthenticationProtocol = gosnmp.MD5
+	case "sha":
+		sp.AuthenticationProtocol = gosnmp.SHA
+	case "":
+		sp.AuthenticationProtocol = gosnmp.NoAuth
+	default:
+		return nil, fmt.Errorf("invalid auth_protocol %q", s.AuthProtocol)
+	}
+
+	sp.AuthenticationPassphrase = s.AuthPassword
+
+	switch strings.ToLower(s.PrivProtocol) {
+	case "des":
+		sp.PrivacyProtocol = gosnmp.DES
+	case "aes":
+		sp.PrivacyProtocol = gosnmp.AES
+	case "":
+		sp.PrivacyProtocol = gosnmp.NoPriv
+	default:
+		return nil, fmt.Errorf("invalid priv_protocol %q", s.PrivProtocol)
+	}
+
+	sp.PrivacyPassphrase = s.PrivPassword
+
+	return params, nil
+}
+
+// handle converts a trap to a metric, it is called by the listener for every
+// trap received.
+func (s *SnmpTrap) handle(packet *gosnmp.SnmpPacket, addr *net.UDPAddr) {
+	tm := s.now()
+	tags := map[string]string{
+		"source":  addr.IP.String(),
+		"version": versionName(packet.Version),
+	}
+	fields := map[string]interface{}{}
+
+	var trapOID string
+	if packet.Version == gosnmp.Version1 {
+		trapOID = v1TrapOID(packet)
+		tags["agent_address"] = packet.AgentAddress
+
+		e := s.lookup(sysUpTimeInstance)
+		fields[e.oidText] = uint64(packet.Timestamp)
+	}
+
+	for _, v := range packet.Variables {
+		name := normalizeOID(v.Name)
+		if name == snmpTrapOID {
+			if oid, ok := v.Value.(string); ok {
+				trapOID = normalizeOID(oid)
+			}
+			continue
+		}
+
+		value, ok := s.value(v)
+		if !ok {
+			continue
+		}
+		e := s.lookup(name)
+		fields[e.oidText] = value
+	}
+
+	if trapOID == "" {
+		s.acc.AddError(fmt.Errorf("trap from %s without trap OID", addr.IP))
+		return
+	}
+
+	e := s.lookup(trapOID)
+	tags["oid"] = trapOID
+	tags["name"] = e.oidText
+	if e.mibName != "" {
+		tags["mib"] = e.mibName
+	}
+
+	s.acc.AddFields("snmp_trap", fields, tags, tm)
+}
+
+// value returns the value of a varbind as a field value.
+func (s *SnmpTrap) value(v gosnmp.SnmpPDU) (interface{}, bool) {
+	switch v.Type {
+	case gosnmp.Null, gosnmp.NoSuchObject, gosnmp.NoSuchInstance, gosnmp.EndOfMibView:
+		return nil, false
+	case gosnmp.OctetString:
+		if b, ok := v.Value.([]byte); ok {
+			return string(b), true
+		}
+	case gosnmp.ObjectIdentifier:
+		if oid, ok := v.Value.(string); ok {
+			e := s.lookup(normalizeOID(oid))
+			if e.mibName == "" {
+				return e.oidText, true
+			}
+			return e.mibName + "::" + e.oidText, true
+		}
+	}
+	return v.Value, v.Value != nil
+}
+
+// lookup translates the numeric OID to its name in the MIBs, the numeric
+// OID is used as name if the OID is not found.
+func (s *SnmpTrap) lookup(oid string) mibEntry {
+	if e, ok := s.cache[oid]; ok {
+		return e
+	}
+
+	e := mibEntry{oidText: oid}
+	node, suffix, err := s.tree.Lookup(oid)
+	if err == nil && node.Module != "" {
+		e.mibName = node.Module
+		e.oidText = node.Name
+		for _, n := range suffix {
+			e.oidText += "." + strconv.FormatUint(uint64(n), 10)
+		}
+	}
+	s.cache[oid] = e
+	return e
+}
+
+// v1TrapOID returns the OID of a SNMPv1 trap as it is translated to SNMPv2
+// by RFC 3584.
+func v1TrapOID(packet *gosnmp.SnmpPacket) string {
+	if packet.GenericTrap == enterpriseSpecific {
+		return normalizeOID(packet.Enterprise) + ".0." + strconv.Itoa(packet.SpecificTrap)
+	}
+	return snmpTraps + strconv.Itoa(packet.GenericTrap+1)
+}
+
+func normalizeOID(oid string) string {
+	if strings.HasPrefix(oid, ".") {
+		return oid
+	}
+	return "." + oid
+}
+
+func versionName(v gosnmp.SnmpVersion) string {
+	switch v {
+	case gosnmp.Version1:
+		return "1"
+	case gosnmp.Version2c:
+		return "2c"
+	case gosnmp.Version3:
+		return "3"
+	}
+	return "unknown"
+}
+
+func init() {
+	inputs.Add("snmp_trap", func() telegraf.Input {
+		return NewSnmpTrap()
+	})
+}
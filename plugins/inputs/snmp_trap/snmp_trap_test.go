@@ -0,0 +1,282 @@
+package snmp_trap
+
+import (
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/soniah/gosnmp"
+	"github.com/stretchr/testify/require"
+)
+
+// freePort returns a UDP port that is likely to be available.
+func freePort(t *testing.T) uint16 {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+	return uint16(conn.LocalAddr().(*net.UDPAddr).Port)
+}
+
+func newTestSnmpTrap(port uint16, now time.Time) *SnmpTrap {
+	s := NewSnmpTrap()
+	s.ServiceAddress = "udp://127.0.0.1:" + strconv.Itoa(int(port))
+	s.Path = []string{"testdata"}
+	s.now = func() time.Time { return now }
+	return s
+}
+
+func sendTrap(t *testing.T, gs *gosnmp.GoSNMP, trap gosnmp.SnmpTrap) {
+	require.NoError(t, gs.Connect())
+	defer gs.Conn.Close()
+
+	_, err := gs.SendTrap(trap)
+	require.NoError(t, err)
+}
+
+func TestReceiveTrap(t *testing.T) {
+	now := time.Unix(42, 0)
+	tests := []struct {
+		name     string
+		version  gosnmp.SnmpVersion
+		trap     gosnmp.SnmpTrap
+		expected telegraf.Metric
+	}{
+		{
+			name:    "v2c",
+			version: gosnmp.Version2c,
+			trap: gosnmp.SnmpTrap{
+				Variables: []gosnmp.SnmpPDU{
+					{Name: ".1.3.6.1.2.1.1.3.0", Type: gosnmp.TimeTicks, Value: uint32(100)},
+					{Name: ".1.3.6.1.6.3.1.1.4.1.0", Type: gosnmp.ObjectIdentifier, Value: ".1.3.6.1.4.1.99999.2.1"},
+					{Name: ".1.3.6.1.4.1.99999.1.1.0", Type: gosnmp.Integer, Value: 7},
+					{Name: ".1.3.6.1.4.1.99999.1.2.0", Type: gosnmp.OctetString, Value: "up"},
+					{Name: ".1.3.6.1.4.1.99999.1.3", Type: gosnmp.ObjectIdentifier, Value: ".1.3.6.1.6.3.1.1.5.1"},
+				},
+			},
+			expected: testutil.MustMetric("snmp_trap",
+				map[string]string{
+					"source":  "127.0.0.1",
+					"version": "2c",
+					"oid":     ".1.3.6.1.4.1.99999.2.1",
+					"name":    "testTrap",
+					"mib":     "TEST-TRAP-MIB",
+				},
+				map[string]interface{}{
+					"sysUpTimeInstance": uint64(100),
+					"testTrapValue.0":   int64(7),
+					"testTrapText.0":    "up",
+					"testTrapObjects.3": "SNMPv2-MIB::coldStart",
+				},
+				now),
+		},
+		{
+			name:    "v1 generic",
+			version: gosnmp.Version1,
+			trap: gosnmp.SnmpTrap{
+				Variables: []gosnmp.SnmpPDU{
+					{Name: ".1.3.6.1.4.1.99999.1.2.0", Type: gosnmp.OctetString, Value: "up"},
+				},
+				Enterprise:   ".1.3.6.1.4.1.99999",
+				AgentAddress: "10.0.0.1",
+				GenericTrap:  0,
+				Timestamp:    100,
+			},
+			expected: testutil.MustMetric("snmp_trap",
+				map[string]string{
+					"source":        "127.0.0.1",
+					"version":       "1",
+					"agent_address": "10.0.0.1",
+					"oid":           ".1.3.6.1.6.3.1.1.5.1",
+					"name":          "coldStart",
+					"mib":           "SNMPv2-MIB",
+				},
+				map[string]interface{}{
+					"sysUpTimeInstance": uint64(100),
+					"testTrapText.0":    "up",
+				},
+				now),
+		},
+		{
+			name:    "v1 enterprise specific",
+			version: gosnmp.Version1,
+			trap: gosnmp.SnmpTrap{
+				Variables: []gosnmp.SnmpPDU{
+					{Name: ".1.3.6.1.4.1.99999.1.1.0", Type: gosnmp.Integer, Value: 7},
+				},
+				Enterprise:   ".1.3.6.1.4.1.99999.2",
+				AgentAddress: "10.0.0.1",
+				GenericTrap:  6,
+				SpecificTrap: 1,
+				Timestamp:    100,
+			},
+			expected: testutil.MustMetric("snmp_trap",
+				map[string]string{
+					"source":        "127.0.0.1",
+					"version":       "1",
+					"agent_address": "10.0.0.1",
+					"oid":           ".1.3.6.1.4.1.99999.2.0.1",
+					"name":          "testTrapNotifications.0.1",
+					"mib":           "TEST-TRAP-MIB",
+				},
+				map[string]interface{}{
+					"sysUpTimeInstance": uint64(100),
+					"testTrapValue.0":   int64(7),
+				},
+				now),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			port := freePort(t)
+			s := newTestSnmpTrap(port, now)
+
+			var acc testutil.Accumulator
+			require.NoError(t, s.Start(&acc))
+			defer s.Stop()
+
+			sendTrap(t, &gosnmp.GoSNMP{
+				Target:    "127.0.0.1",
+				Port:      port,
+				Community: "public",
+				Version:   tt.version,
+				Timeout:   2 * time.Second,
+			}, tt.trap)
+
+			acc.Wait(1)
+			testutil.RequireMetricsEqual(t, []telegraf.Metric{tt.expected}, acc.GetTelegrafMetrics())
+		})
+	}
+}
+
+func TestReceiveTrapV3(t *testing.T) {
+	now := time.Unix(42, 0)
+	port := freePort(t)
+	s := newTestSnmpTrap(port, now)
+	s.Version = "3"
+	s.SecName = "user"
+	s.SecLevel = "authPriv"
+	s.AuthProtocol = "SHA"
+	s.AuthPassword = "authpassword"
+	s.PrivProtocol = "AES"
+	s.PrivPassword = "privpassword"
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.Start(&acc))
+	defer s.Stop()
+
+	sendTrap(t, &gosnmp.GoSNMP{
+		Target:        "127.0.0.1",
+		Port:          port,
+		Version:       gosnmp.Version3,
+		Timeout:       2 * time.Second,
+		SecurityModel: gosnmp.UserSecurityModel,
+		MsgFlags:      gosnmp.AuthPriv,
+		SecurityParameters: &gosnmp.UsmSecurityParameters{
+			UserName:                 "user",
+			AuthoritativeEngineID:    "8000000001020304",
+			AuthenticationProtocol:   gosnmp.SHA,
+			AuthenticationPassphrase: "authpassword",
+			PrivacyProtocol:          gosnmp.AES,
+			PrivacyPassphrase:        "privpassword",
+		},
+	}, gosnmp.SnmpTrap{
+		Variables: []gosnmp.SnmpPDU{
+			{Name: ".1.3.6.1.2.1.1.3.0", Type: gosnmp.TimeTicks, Value: uint32(100)},
+			{Name: ".1.3.6.1.6.3.1.1.4.1.0", Type: gosnmp.ObjectIdentifier, Value: ".1.3.6.1.4.1.99999.2.1"},
+		},
+	})
+
+	acc.Wait(1)
+	expected := []telegraf.Metric{
+		testutil.MustMetric("snmp_trap",
+			map[string]string{
+				"source":  "127.0.0.1",
+				"version": "3",
+				"oid":     ".1.3.6.1.4.1.99999.2.1",
+				"name":    "testTrap",
+				"mib":     "TEST-TRAP-MIB",
+			},
+			map[string]interface{}{
+				"sysUpTimeInstance": uint64(100),
+			},
+			now),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestReceiveInform(t *testing.T) {
+	now := time.Unix(42, 0)
+	port := freePort(t)
+	s := newTestSnmpTrap(port, now)
+
+	var acc testutil.Accumulator
+	require.NoError(t, s.Start(&acc))
+	defer s.Stop()
+
+	// SendTrap waits for the response to an inform and fails if there is
+	// none before the timeout.
+	sendTrap(t, &gosnmp.GoSNMP{
+		Target:    "127.0.0.1",
+		Port:      port,
+		Community: "public",
+		Version:   gosnmp.Version2c,
+		Timeout:   2 * time.Second,
+	}, gosnmp.SnmpTrap{
+		Variables: []gosnmp.SnmpPDU{
+			{Name: ".1.3.6.1.2.1.1.3.0", Type: gosnmp.TimeTicks, Value: uint32(100)},
+			{Name: ".1.3.6.1.6.3.1.1.4.1.0", Type: gosnmp.ObjectIdentifier, Value: ".1.3.6.1.4.1.99999.2.1"},
+		},
+		IsInform: true,
+	})
+
+	acc.Wait(1)
+	expected := []telegraf.Metric{
+		testutil.MustMetric("snmp_trap",
+			map[string]string{
+				"source":  "127.0.0.1",
+				"version": "2c",
+				"oid":     ".1.3.6.1.4.1.99999.2.1",
+				"name":    "testTrap",
+				"mib":     "TEST-TRAP-MIB",
+			},
+			map[string]interface{}{
+				"sysUpTimeInstance": uint64(100),
+			},
+			now),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestStartError(t *testing.T) {
+	tests := []struct {
+		name string
+		trap *SnmpTrap
+		err  string
+	}{
+		{
+			name: "tcp transport",
+			trap: &SnmpTrap{ServiceAddress: "tcp://:162"},
+			err:  `unsupported transport "tcp" in service address`,
+		},
+		{
+			name: "invalid version",
+			trap: &SnmpTrap{ServiceAddress: "udp://:162", Version: "4"},
+			err:  `invalid version "4"`,
+		},
+		{
+			name: "invalid auth protocol",
+			trap: &SnmpTrap{ServiceAddress: "udp://:162", Version: "3", AuthProtocol: "foo"},
+			err:  `invalid auth_protocol "foo"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var acc testutil.Accumulator
+			require.EqualError(t, tt.trap.Start(&acc), tt.err)
+		})
+	}
+}
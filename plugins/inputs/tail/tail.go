@@ -12,6 +12,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal/globpath"
+	"github.com/influxdata/telegraf/internal/offsetstate"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
 )
@@ -30,9 +31,11 @@ type Tail struct {
 	FromBeginning bool
 	Pipe          bool
 	WatchMethod   string
+	OffsetsFile   string `toml:"offsets_file"`
 
 	tailers    map[string]*tail.Tail
 	offsets    map[string]int64
+	state      *offsetstate.State
 	parserFunc parsers.ParserFunc
 	wg         sync.WaitGroup
 	acc        telegraf.Accumulator
@@ -73,6 +76,12 @@ const sampleConfig = `
   ## Method used to watch for file updates.  Can be either "inotify" or "poll".
   # watch_method = "inotify"
 
+  ## File to save the offsets of the files in, so that reading resumes where
+  ## it stopped after a restart.  Files that were rotated or truncated in the
+  ## meantime are read from the beginning.  The offsets are saved every
+  ## interval and when telegraf stops.
+  # offsets_file = "/var/lib/telegraf/tail_offsets.json"
+
   ## Data format to consume.
   ## Each data format has its own unique set of configuration options, read
   ## more about them here:
@@ -92,6 +101,7 @@ func (t *Tail) Gather(acc telegraf.Accumulator) error {
 	t.Lock()
 	defer t.Unlock()
 
+	t.saveOffsets()
 	return t.tailNewFiles(true)
 }
 
@@ -102,6 +112,14 @@ func (t *Tail) Start(acc telegraf.Accumulator) error {
 	t.acc = acc
 	t.tailers = make(map[string]*tail.Tail)
 
+	if t.OffsetsFile != "" && !t.Pipe {
+		var err error
+		t.state, err = offsetstate.Open(t.OffsetsFile)
+		if err != nil {
+			return err
+		}
+	}
+
 	err := t.tailNewFiles(t.FromBeginning)
 
 	// clear offsets
@@ -133,14 +151,20 @@ func (t *Tail) tailNewFiles(fromBeginning bool) error {
 			}
 
 			var seek *tail.SeekInfo
-			if !t.Pipe && !fromBeginning {
-				if offset, ok := t.offsets[file]; ok {
+			if !t.Pipe {
+				if offset, ok := t.offsets[file]; ok && !fromBeginning {
 					log.Printf("D! [inputs.tail] using offset %d for file: %v", offset, file)
 					seek = &tail.SeekInfo{
 						Whence: 0,
 						Offset: offset,
 					}
-				} else {
+				} else if offset, ok := t.savedOffset(file); ok {
+					log.Printf("D! [inputs.tail] using saved offset %d for file: %v", offset, file)
+					seek = &tail.SeekInfo{
+						Whence: 0,
+						Offset: offset,
+					}
+				} else if !fromBeginning {
 					seek = &tail.SeekInfo{
 						Whence: 2,
 						Offset: 0,
@@ -240,11 +264,16 @@ func (t *Tail) Stop() {
 			// store offset for resume
 			offset, err := tailer.Tell()
 			if err == nil {
+				t.offsets[tailer.Filename] = offset
 				log.Printf("D! [inputs.tail] recording offset %d for file: %v", offset, tailer.Filename)
 			} else {
 				t.acc.AddError(fmt.Errorf("error recording offset for file %s", tailer.Filename))
 			}
 		}
+	}
+	t.saveOffsets()
+
+	for _, tailer := range t.tailers {
 		err := tailer.Stop()
 		if err != nil {
 			t.acc.AddError(fmt.Errorf("error stopping tail on file %s", tailer.Filename))
@@ -261,6 +290,36 @@ func (t *Tail) Stop() {
 	offsetsMutex.Unlock()
 }
 
+// savedOffset returns the offset saved in the offsets file for the file.
+func (t *Tail) savedOffset(file string) (int64, bool) {
+	if t.state == nil {
+		return 0, false
+	}
+	return t.state.Resume(file)
+}
+
+// saveOffsets writes the current offsets of the tailed files to the offsets
+// file.  Assumes t's lock is held!
+func (t *Tail) saveOffsets() {
+	if t.state == nil {
+		return
+	}
+
+	for _, tailer := range t.tailers {
+		offset, err := tailer.Tell()
+		if err != nil {
+			t.acc.AddError(fmt.Errorf("error recording offset for file %s", tailer.Filename))
+			continue
+		}
+		if err := t.state.Record(tailer.Filename, offset); err != nil {
+			t.acc.AddError(fmt.Errorf("error recording offset for file %s: %v", tailer.Filename, err))
+		}
+	}
+	if err := t.state.Save(); err != nil {
+		t.acc.AddError(fmt.Errorf("error saving offsets to %s: %v", t.OffsetsFile, err))
+	}
+}
+
 func (t *Tail) SetParserFunc(fn parsers.ParserFunc) {
 	t.parserFunc = fn
 }
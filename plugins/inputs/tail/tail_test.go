@@ -1,8 +1,10 @@
 package tail
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"runtime"
 	"testing"
 
@@ -88,6 +90,59 @@ func TestTailFromEnd(t *testing.T) {
 	assert.Len(t, acc.Metrics, 1)
 }
 
+func TestTailResumeFromOffset(t *testing.T) {
+	if os.Getenv("CIRCLE_PROJECT_REPONAME") != "" {
+		t.Skip("Skipping CI testing due to race conditions")
+	}
+
+	tmpfile, err := ioutil.TempFile("", "")
+	require.NoError(t, err)
+	defer os.Remove(tmpfile.Name())
+	defer tmpfile.Close()
+	_, err = tmpfile.WriteString("cpu,line=0 usage_idle=100\n")
+	require.NoError(t, err)
+
+	tt := NewTail()
+	tt.Files = []string{tmpfile.Name()}
+	tt.SetParserFunc(parsers.NewInfluxParser)
+
+	acc := testutil.Accumulator{}
+	require.NoError(t, tt.Start(&acc))
+	for _, tailer := range tt.tailers {
+		for n, err := tailer.Tell(); err == nil && n == 0; n, err = tailer.Tell() {
+			// wait for tailer to jump to end
+			runtime.Gosched()
+		}
+	}
+	_, err = tmpfile.WriteString("cpu,line=1 usage_idle=100\n")
+	require.NoError(t, err)
+	acc.Wait(1)
+	tt.Stop()
+
+	// A line written while the plugin is stopped, for example during a
+	// reload, is read by the new plugin from the recorded offset.
+	_, err = tmpfile.WriteString("cpu,line=2 usage_idle=100\n")
+	require.NoError(t, err)
+
+	tt = NewTail()
+	tt.Files = []string{tmpfile.Name()}
+	tt.SetParserFunc(parsers.NewInfluxParser)
+	defer tt.Stop()
+
+	acc = testutil.Accumulator{}
+	require.NoError(t, tt.Start(&acc))
+	acc.Wait(1)
+	acc.AssertContainsTaggedFields(t, "cpu",
+		map[string]interface{}{
+			"usage_idle": float64(100),
+		},
+		map[string]string{
+			"line": "2",
+			"path": tmpfile.Name(),
+		})
+	assert.Len(t, acc.Metrics, 1)
+}
+
 func TestTailBadLine(t *testing.T) {
 	tmpfile, err := ioutil.TempFile("", "")
 	require.NoError(t, err)
@@ -139,3 +194,67 @@ func TestTailDosLineendings(t *testing.T) {
 			"usage_idle": float64(200),
 		})
 }
+
+func TestTailOffsetsFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tail")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	logFile := filepath.Join(dir, "metrics.out")
+	offsetsFile := filepath.Join(dir, "offsets.json")
+	require.NoError(t, ioutil.WriteFile(logFile, []byte("cpu,line=1 usage_idle=100\n"), 0644))
+
+	tt := NewTail()
+	tt.FromBeginning = true
+	tt.Files = []string{logFile}
+	tt.OffsetsFile = offsetsFile
+	tt.SetParserFunc(parsers.NewInfluxParser)
+
+	acc := testutil.Accumulator{}
+	require.NoError(t, tt.Start(&acc))
+	acc.Wait(1)
+	tt.Stop()
+
+	buf, err := ioutil.ReadFile(offsetsFile)
+	require.NoError(t, err)
+	var saved struct {
+		Files map[string]struct {
+			Offset int64 `json:"offset"`
+		} `json:"files"`
+	}
+	require.NoError(t, json.Unmarshal(buf, &saved))
+	require.Equal(t, int64(len("cpu,line=1 usage_idle=100\n")), saved.Files[logFile].Offset)
+
+	// Open caches the state of a path for the process, the restarted plugin
+	// uses a copy of the file so that the offsets are loaded from disk.
+	restartFile := filepath.Join(dir, "restart.json")
+	require.NoError(t, ioutil.WriteFile(restartFile, buf, 0644))
+
+	// Lines written while stopped are read after the restart, the lines
+	// already read are not read again.
+	f, err := os.OpenFile(logFile, os.O_APPEND|os.O_WRONLY, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString("cpu,line=2 usage_idle=100\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	tt = NewTail()
+	tt.FromBeginning = true
+	tt.Files = []string{logFile}
+	tt.OffsetsFile = restartFile
+	tt.SetParserFunc(parsers.NewInfluxParser)
+	defer tt.Stop()
+
+	acc = testutil.Accumulator{}
+	require.NoError(t, tt.Start(&acc))
+	acc.Wait(1)
+	acc.AssertContainsTaggedFields(t, "cpu",
+		map[string]interface{}{
+			"usage_idle": float64(100),
+		},
+		map[string]string{
+			"line": "2",
+			"path": logFile,
+		})
+	assert.Len(t, acc.Metrics, 1)
+}
@@ -0,0 +1,120 @@
+package execd
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/process"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+const sampleConfig = `
+  ## Program to run as daemon, and its arguments.
+  ## The program reads the metrics on its stdin in the data format.
+  command = ["my-telegraf-output", "--some-flag", "value"]
+
+  ## Delay before the process is restarted after an unexpected termination.
+  ## The delay is doubled on every restart up to the max_restart_delay.
+  restart_delay = "10s"
+  # max_restart_delay = "5m"
+
+  ## Data format to export.
+  ## Each data format has its own unique set of configuration options, read
+  ## more about them here:
+  ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_OUTPUT.md
+  data_format = "influx"
+`
+
+type Execd struct {
+	Command         []string          `toml:"command"`
+	RestartDelay    internal.Duration `toml:"restart_delay"`
+	MaxRestartDelay internal.Duration `toml:"max_restart_delay"`
+
+	serializer serializers.Serializer
+	process    *process.Process
+}
+
+func NewExecd() *Execd {
+	return &Execd{
+		RestartDelay:    internal.Duration{Duration: 10 * time.Second},
+		MaxRestartDelay: internal.Duration{Duration: 5 * time.Minute},
+	}
+}
+
+func (e *Execd) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *Execd) Description() string {
+	return "Run executable as long-running output plugin"
+}
+
+func (e *Execd) SetSerializer(serializer serializers.Serializer) {
+	e.serializer = serializer
+}
+
+func (e *Execd) Init() error {
+	p, err := process.New(e.Command)
+	if err != nil {
+		return err
+	}
+	p.Name = "outputs.execd"
+	p.RestartDelay = e.RestartDelay.Duration
+	p.MaxRestartDelay = e.MaxRestartDelay.Duration
+	p.ReadStdoutFn = e.readStdout
+	p.ReadStderrFn = e.readStderr
+
+	e.process = p
+	return nil
+}
+
+func (e *Execd) Connect() error {
+	return e.process.Start()
+}
+
+func (e *Execd) Close() error {
+	e.process.Stop()
+	return nil
+}
+
+// Write writes the metrics to the program, it blocks while the program does
+// not read its input.  While the program is not running an error is
+// returned, so that the metrics are written again later.
+func (e *Execd) Write(metrics []telegraf.Metric) error {
+	b, err := e.serializer.SerializeBatch(metrics)
+	if err != nil {
+		return fmt.Errorf("could not serialize metrics: %v", err)
+	}
+
+	if _, err := e.process.Write(b); err != nil {
+		return fmt.Errorf("could not write metrics to process: %v", err)
+	}
+	return nil
+}
+
+func (e *Execd) readStdout(r io.Reader) {
+	process.ReadLines(r, func(line []byte) {
+		log.Printf("I! [outputs.execd] stdout: %q", line)
+	}, func(err error) {
+		log.Printf("E! [outputs.execd] Error reading stdout: %v", err)
+	})
+}
+
+func (e *Execd) readStderr(r io.Reader) {
+	process.ReadLines(r, func(line []byte) {
+		log.Printf("E! [outputs.execd] stderr: %q", line)
+	}, func(err error) {
+		log.Printf("E! [outputs.execd] Error reading stderr: %v", err)
+	})
+}
+
+func init() {
+	outputs.Add("execd", func() telegraf.Output {
+		return NewExecd()
+	})
+}
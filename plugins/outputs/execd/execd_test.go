@@ -0,0 +1,93 @@
+package execd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/serializers"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWrite(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test on windows; sh is not available")
+	}
+
+	dir, err := ioutil.TempDir("", "execd")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	out := filepath.Join(dir, "out")
+
+	serializer, err := serializers.NewInfluxSerializer()
+	require.NoError(t, err)
+
+	e := NewExecd()
+	e.Command = []string{"sh", "-c", "cat > " + out}
+	e.SetSerializer(serializer)
+	require.NoError(t, e.Init())
+	require.NoError(t, e.Connect())
+
+	metrics := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"value": 42.0},
+			time.Unix(0, 0)),
+		testutil.MustMetric("mem",
+			map[string]string{},
+			map[string]interface{}{"used": int64(1)},
+			time.Unix(1, 0)),
+	}
+	require.NoError(t, e.Write(metrics))
+	require.NoError(t, e.Close())
+
+	b, err := ioutil.ReadFile(out)
+	require.NoError(t, err)
+	require.Equal(t, "cpu,host=a value=42 0\nmem used=1i 1000000000\n", string(b))
+}
+
+func TestWriteNotRunning(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test on windows; true is not available")
+	}
+
+	serializer, err := serializers.NewInfluxSerializer()
+	require.NoError(t, err)
+
+	e := NewExecd()
+	e.Command = []string{"true"}
+	e.SetSerializer(serializer)
+	require.NoError(t, e.Init())
+	require.NoError(t, e.Connect())
+	defer e.Close()
+
+	m := testutil.MustMetric("cpu",
+		map[string]string{},
+		map[string]interface{}{"value": 42.0},
+		time.Unix(0, 0))
+
+	// The program exits immediately and is not restarted before the delay.
+	deadline := time.Now().Add(5 * time.Second)
+	for e.Write([]telegraf.Metric{m}) == nil {
+		require.True(t, time.Now().Before(deadline), "process did not exit")
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestInitNoCommand(t *testing.T) {
+	e := NewExecd()
+	require.EqualError(t, e.Init(), "no command specified")
+}
+
+func TestCloseAfterConnectError(t *testing.T) {
+	e := NewExecd()
+	e.Command = []string{"/nonexistent/command"}
+	require.NoError(t, e.Init())
+	require.Error(t, e.Connect())
+	require.NoError(t, e.Close())
+}
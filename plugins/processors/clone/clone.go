@@ -0,0 +1,63 @@
+package clone
+
+import (
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+var sampleConfig = `
+  ## All modifications on inputs and aggregators can be overridden:
+  # name_override = "new_name"
+  # name_prefix = "new_name_prefix"
+  # name_suffix = "new_name_suffix"
+
+  ## Tags to be added (all values must be strings)
+  # [processors.clone.tags]
+  #   additional_tag = "tag_value"
+`
+
+type Clone struct {
+	NameOverride string
+	NamePrefix   string
+	NameSuffix   string
+	Tags         map[string]string
+}
+
+func (c *Clone) SampleConfig() string {
+	return sampleConfig
+}
+
+func (c *Clone) Description() string {
+	return "Clone metrics and apply modifications."
+}
+
+// Apply passes the metrics unchanged, followed by a modified copy of each.
+func (c *Clone) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	cloned := make([]telegraf.Metric, 0, len(in))
+
+	for _, metric := range in {
+		clone := metric.Copy()
+
+		if len(c.NameOverride) > 0 {
+			clone.SetName(c.NameOverride)
+		}
+		if len(c.NamePrefix) > 0 {
+			clone.AddPrefix(c.NamePrefix)
+		}
+		if len(c.NameSuffix) > 0 {
+			clone.AddSuffix(c.NameSuffix)
+		}
+		for key, value := range c.Tags {
+			clone.AddTag(key, value)
+		}
+
+		cloned = append(cloned, clone)
+	}
+	return append(in, cloned...)
+}
+
+func init() {
+	processors.Add("clone", func() telegraf.Processor {
+		return &Clone{}
+	})
+}
@@ -0,0 +1,72 @@
+package clone
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestClone(t *testing.T) {
+	now := time.Unix(0, 0)
+	newMetric := func(name string, tags map[string]string) telegraf.Metric {
+		return testutil.MustMetric(name, tags,
+			map[string]interface{}{"value": int64(1)}, now)
+	}
+	input := []telegraf.Metric{
+		newMetric("cpu", map[string]string{"host": "a"}),
+		newMetric("mem", map[string]string{"host": "a"}),
+	}
+
+	tests := []struct {
+		name     string
+		clone    *Clone
+		expected []telegraf.Metric
+	}{
+		{
+			name:  "unmodified copy",
+			clone: &Clone{},
+			expected: []telegraf.Metric{
+				newMetric("cpu", map[string]string{"host": "a"}),
+				newMetric("mem", map[string]string{"host": "a"}),
+				newMetric("cpu", map[string]string{"host": "a"}),
+				newMetric("mem", map[string]string{"host": "a"}),
+			},
+		},
+		{
+			name:  "name override",
+			clone: &Clone{NameOverride: "system"},
+			expected: []telegraf.Metric{
+				newMetric("cpu", map[string]string{"host": "a"}),
+				newMetric("mem", map[string]string{"host": "a"}),
+				newMetric("system", map[string]string{"host": "a"}),
+				newMetric("system", map[string]string{"host": "a"}),
+			},
+		},
+		{
+			name: "prefix, suffix and tags",
+			clone: &Clone{
+				NamePrefix: "pre_",
+				NameSuffix: "_suf",
+				Tags:       map[string]string{"host": "b", "copy": "true"},
+			},
+			expected: []telegraf.Metric{
+				newMetric("cpu", map[string]string{"host": "a"}),
+				newMetric("mem", map[string]string{"host": "a"}),
+				newMetric("pre_cpu_suf", map[string]string{"host": "b", "copy": "true"}),
+				newMetric("pre_mem_suf", map[string]string{"host": "b", "copy": "true"}),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := make([]telegraf.Metric, 0, len(input))
+			for _, m := range input {
+				metrics = append(metrics, m.Copy())
+			}
+			actual := tt.clone.Apply(metrics...)
+			testutil.RequireMetricsEqual(t, tt.expected, actual)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package dedup
+
+import (
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+var sampleConfig = `
+  ## Maximum time to suppress output
+  dedup_interval = "600s"
+`
+
+// series is the last emitted state of a series.
+type series struct {
+	time   time.Time
+	fields map[string]interface{}
+}
+
+type Dedup struct {
+	DedupInterval internal.Duration `toml:"dedup_interval"`
+
+	flushTime time.Time
+	cache     map[uint64]*series
+}
+
+func (d *Dedup) SampleConfig() string {
+	return sampleConfig
+}
+
+func (d *Dedup) Description() string {
+	return "Filter metrics with repeating field values"
+}
+
+// Apply drops the metrics whose fields are unchanged since the last metric
+// emitted for the series, unless the last metric is older than the
+// dedup_interval.
+func (d *Dedup) Apply(metrics ...telegraf.Metric) []telegraf.Metric {
+	results := make([]telegraf.Metric, 0, len(metrics))
+	for _, m := range metrics {
+		id := m.HashID()
+		last, ok := d.cache[id]
+		if ok && m.Time().Sub(last.time) < d.DedupInterval.Duration &&
+			sameFields(last.fields, m.FieldList()) {
+			m.Drop()
+			continue
+		}
+
+		d.cache[id] = &series{time: m.Time(), fields: m.Fields()}
+		results = append(results, m)
+	}
+	d.cleanup()
+	return results
+}
+
+// sameFields returns true if the fields have the same keys and values.
+func sameFields(fields map[string]interface{}, list []*telegraf.Field) bool {
+	if len(fields) != len(list) {
+		return false
+	}
+	for _, field := range list {
+		v, ok := fields[field.Key]
+		if !ok || v != field.Value {
+			return false
+		}
+	}
+	return true
+}
+
+// cleanup removes the series not seen for a dedup_interval from the cache,
+// at most once per dedup_interval.
+func (d *Dedup) cleanup() {
+	now := time.Now()
+	if now.Sub(d.flushTime) < d.DedupInterval.Duration {
+		return
+	}
+	d.flushTime = now
+
+	for id, s := range d.cache {
+		if now.Sub(s.time) >= d.DedupInterval.Duration {
+			delete(d.cache, id)
+		}
+	}
+}
+
+func newDedup() *Dedup {
+	return &Dedup{
+		DedupInterval: internal.Duration{Duration: 10 * time.Minute},
+		flushTime:     time.Now(),
+		cache:         make(map[uint64]*series),
+	}
+}
+
+func init() {
+	processors.Add("dedup", func() telegraf.Processor {
+		return newDedup()
+	})
+}
@@ -0,0 +1,116 @@
+package dedup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newMetric(tm time.Time, value interface{}) telegraf.Metric {
+	return testutil.MustMetric("metric",
+		map[string]string{"tag": "tag_value"},
+		map[string]interface{}{"value": value},
+		tm)
+}
+
+func TestDedup(t *testing.T) {
+	now := time.Now()
+	tests := []testutil.ProcessorTest{
+		{
+			Name: "unchanged value is dropped",
+			Input: []telegraf.Metric{
+				newMetric(now, 1),
+				newMetric(now.Add(time.Second), 1),
+			},
+			Expected: []telegraf.Metric{
+				newMetric(now, 1),
+			},
+		},
+		{
+			Name: "changed value is passed",
+			Input: []telegraf.Metric{
+				newMetric(now, 1),
+				newMetric(now.Add(time.Second), 2),
+				newMetric(now.Add(2*time.Second), 2),
+			},
+			Expected: []telegraf.Metric{
+				newMetric(now, 1),
+				newMetric(now.Add(time.Second), 2),
+			},
+		},
+		{
+			Name: "unchanged value is passed after interval",
+			Input: []telegraf.Metric{
+				newMetric(now, 1),
+				newMetric(now.Add(5*time.Minute), 1),
+				newMetric(now.Add(11*time.Minute), 1),
+			},
+			Expected: []telegraf.Metric{
+				newMetric(now, 1),
+				newMetric(now.Add(11*time.Minute), 1),
+			},
+		},
+		{
+			Name: "changed field type is passed",
+			Input: []telegraf.Metric{
+				newMetric(now, 1),
+				newMetric(now.Add(time.Second), 1.0),
+			},
+			Expected: []telegraf.Metric{
+				newMetric(now, 1),
+				newMetric(now.Add(time.Second), 1.0),
+			},
+		},
+		{
+			Name: "added field is passed",
+			Input: []telegraf.Metric{
+				newMetric(now, 1),
+				testutil.MustMetric("metric",
+					map[string]string{"tag": "tag_value"},
+					map[string]interface{}{"value": 1, "other": 2},
+					now.Add(time.Second)),
+			},
+			Expected: []telegraf.Metric{
+				newMetric(now, 1),
+				testutil.MustMetric("metric",
+					map[string]string{"tag": "tag_value"},
+					map[string]interface{}{"value": 1, "other": 2},
+					now.Add(time.Second)),
+			},
+		},
+		{
+			Name: "series are separate",
+			Input: []telegraf.Metric{
+				newMetric(now, 1),
+				testutil.MustMetric("metric",
+					map[string]string{"tag": "other_value"},
+					map[string]interface{}{"value": 1},
+					now.Add(time.Second)),
+			},
+			Expected: []telegraf.Metric{
+				newMetric(now, 1),
+				testutil.MustMetric("metric",
+					map[string]string{"tag": "other_value"},
+					map[string]interface{}{"value": 1},
+					now.Add(time.Second)),
+			},
+		},
+	}
+	testutil.RunProcessorTests(t, func() telegraf.Processor {
+		return newDedup()
+	}, tests)
+}
+
+func TestCacheCleanup(t *testing.T) {
+	d := newDedup()
+	d.flushTime = time.Now().Add(-time.Hour)
+
+	d.Apply(newMetric(time.Now().Add(-time.Hour), 1))
+	require.Len(t, d.cache, 0)
+
+	d.Apply(newMetric(time.Now(), 1))
+	require.Len(t, d.cache, 1)
+}
@@ -0,0 +1,152 @@
+package execd
+
+import (
+	"io"
+	"log"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/process"
+	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/processors"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+const sampleConfig = `
+  ## Program to run as daemon, and its arguments.
+  ## The program reads the metrics on its stdin and writes the processed
+  ## metrics to its stdout, both in the data format.
+  command = ["cat"]
+
+  ## Delay before the process is restarted after an unexpected termination.
+  ## The delay is doubled on every restart up to the max_restart_delay.
+  restart_delay = "10s"
+  # max_restart_delay = "5m"
+
+  ## Data format used to write the metrics to the program and to read the
+  ## metrics returned by the program.
+  ## Each data format has its own unique set of configuration options, read
+  ## more about them here:
+  ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_INPUT.md
+  ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_OUTPUT.md
+  data_format = "influx"
+`
+
+type Execd struct {
+	Command         []string          `toml:"command"`
+	RestartDelay    internal.Duration `toml:"restart_delay"`
+	MaxRestartDelay internal.Duration `toml:"max_restart_delay"`
+
+	acc        telegraf.Accumulator
+	parser     parsers.Parser
+	serializer serializers.Serializer
+	process    *process.Process
+}
+
+func NewExecd() *Execd {
+	return &Execd{
+		RestartDelay:    internal.Duration{Duration: 10 * time.Second},
+		MaxRestartDelay: internal.Duration{Duration: 5 * time.Minute},
+	}
+}
+
+func (e *Execd) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *Execd) Description() string {
+	return "Run executable as long-running processor plugin"
+}
+
+func (e *Execd) SetParser(parser parsers.Parser) {
+	e.parser = parser
+}
+
+func (e *Execd) SetSerializer(serializer serializers.Serializer) {
+	e.serializer = serializer
+}
+
+func (e *Execd) Init() error {
+	p, err := process.New(e.Command)
+	if err != nil {
+		return err
+	}
+	p.Name = "processors.execd"
+	p.RestartDelay = e.RestartDelay.Duration
+	p.MaxRestartDelay = e.MaxRestartDelay.Duration
+	p.ReadStdoutFn = e.readStdout
+	p.ReadStderrFn = e.readStderr
+
+	e.process = p
+	return nil
+}
+
+// Start starts the program, the metrics it returns are added to acc.
+func (e *Execd) Start(acc telegraf.Accumulator) error {
+	e.acc = acc
+	return e.process.Start()
+}
+
+// Stop closes the stdin of the program and waits for it to exit, the
+// metrics it returns until then are still added.
+func (e *Execd) Stop() {
+	e.process.Stop()
+}
+
+// Apply writes the metrics to the program, they are replaced by the metrics
+// the program returns.
+//
+// Writing blocks while the program does not read its input, slowing down
+// the pipeline.  While the program is not running, the metrics are passed
+// through unmodified.
+func (e *Execd) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	var results []telegraf.Metric
+	for _, m := range in {
+		b, err := e.serializer.Serialize(m)
+		if err != nil {
+			log.Printf("E! [processors.execd] Could not serialize metric: %v", err)
+			results = append(results, m)
+			continue
+		}
+
+		if _, err := e.process.Write(b); err != nil {
+			log.Printf("E! [processors.execd] Could not write metric to process: %v", err)
+			results = append(results, m)
+			continue
+		}
+
+		m.Drop()
+	}
+	return results
+}
+
+func (e *Execd) readStdout(r io.Reader) {
+	process.ReadLines(r, func(line []byte) {
+		metrics, err := e.parser.Parse(line)
+		if err != nil {
+			log.Printf("E! [processors.execd] Parse error: %v", err)
+			return
+		}
+
+		for _, m := range metrics {
+			e.acc.AddMetric(m)
+		}
+	}, func(err error) {
+		log.Printf("E! [processors.execd] Error reading stdout: %v", err)
+	})
+}
+
+func (e *Execd) readStderr(r io.Reader) {
+	process.ReadLines(r, func(line []byte) {
+		log.Printf("E! [processors.execd] stderr: %q", line)
+	}, func(err error) {
+		log.Printf("E! [processors.execd] Error reading stderr: %v", err)
+	})
+}
+
+func init() {
+	processors.Add("execd", func() telegraf.Processor {
+		return NewExecd()
+	})
+}
@@ -0,0 +1,137 @@
+package execd
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/plugins/serializers"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newExecd(t *testing.T, command ...string) *Execd {
+	parser, err := parsers.NewInfluxParser()
+	require.NoError(t, err)
+	serializer, err := serializers.NewInfluxSerializer()
+	require.NoError(t, err)
+
+	e := NewExecd()
+	e.Command = command
+	e.SetParser(parser)
+	e.SetSerializer(serializer)
+	return e
+}
+
+func TestApply(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test on windows; sed is not available")
+	}
+
+	e := newExecd(t, "sed", "-u", "s/^cpu/cpu_processed/")
+	require.NoError(t, e.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, e.Start(&acc))
+	defer e.Stop()
+
+	m := testutil.MustMetric("cpu",
+		map[string]string{"host": "a"},
+		map[string]interface{}{"value": 42.0},
+		time.Unix(0, 0))
+	require.Empty(t, e.Apply(m))
+	acc.Wait(1)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cpu_processed",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"value": 42.0},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestStopAddsPending(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test on windows; sed is not available")
+	}
+
+	// Without -u, sed only writes its output once its input is closed.
+	e := newExecd(t, "sed", "s/^cpu/cpu_processed/")
+	require.NoError(t, e.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, e.Start(&acc))
+
+	require.Empty(t, e.Apply(
+		testutil.MustMetric("cpu",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"value": 42.0},
+			time.Unix(0, 0)),
+		testutil.MustMetric("cpu",
+			map[string]string{"host": "b"},
+			map[string]interface{}{"value": 43.0},
+			time.Unix(0, 0)),
+	))
+	e.Stop()
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cpu_processed",
+			map[string]string{"host": "a"},
+			map[string]interface{}{"value": 42.0},
+			time.Unix(0, 0)),
+		testutil.MustMetric("cpu_processed",
+			map[string]string{"host": "b"},
+			map[string]interface{}{"value": 43.0},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestApplyNotRunning(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test on windows; cat is not available")
+	}
+
+	// The program is only started by Start.
+	e := newExecd(t, "cat")
+	require.NoError(t, e.Init())
+
+	m := testutil.MustMetric("cpu",
+		map[string]string{},
+		map[string]interface{}{"value": 42.0},
+		time.Unix(0, 0))
+	testutil.RequireMetricsEqual(t, []telegraf.Metric{m}, e.Apply(m))
+}
+
+func TestLongLine(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Skipping test on windows; sh is not available")
+	}
+
+	// Only the line too long to read is skipped, the following metrics are
+	// still added.
+	e := newExecd(t, "sh", "-c",
+		"head -c 1000000 /dev/zero | tr '\\0' a; echo; echo 'cpu value=42 0'; cat > /dev/null")
+	require.NoError(t, e.Init())
+
+	var acc testutil.Accumulator
+	require.NoError(t, e.Start(&acc))
+	defer e.Stop()
+	acc.Wait(1)
+
+	expected := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{},
+			map[string]interface{}{"value": 42.0},
+			time.Unix(0, 0)),
+	}
+	testutil.RequireMetricsEqual(t, expected, acc.GetTelegrafMetrics())
+}
+
+func TestInitNoCommand(t *testing.T) {
+	e := newExecd(t)
+	require.EqualError(t, e.Init(), "no command specified")
+}
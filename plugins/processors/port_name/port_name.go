@@ -0,0 +1,193 @@
+package port_name
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+const sampleConfig = `
+  ## Name of the tag holding the port number, or "port/protocol"
+  # tag = "port"
+  ## Or name of the field holding the port number
+  # field = "port"
+
+  ## Name of the tag or field, depending on the source, to store the service
+  ## name in
+  # dest = "service"
+
+  ## Protocol used when it is not part of the port or found in the metric,
+  ## tcp or udp
+  # default_protocol = "tcp"
+
+  ## Tag or field containing the protocol, tcp or udp
+  # protocol_tag = "proto"
+  # protocol_field = "proto"
+
+  ## File in the /etc/services format with additional services, overriding
+  ## the embedded well-known ports
+  # services_file = "/etc/services"
+`
+
+type PortName struct {
+	Tag             string `toml:"tag"`
+	Field           string `toml:"field"`
+	Dest            string `toml:"dest"`
+	DefaultProtocol string `toml:"default_protocol"`
+	ProtocolTag     string `toml:"protocol_tag"`
+	ProtocolField   string `toml:"protocol_field"`
+	ServicesFile    string `toml:"services_file"`
+
+	// services maps the protocol and port to the service name.
+	services map[string]map[int]string
+}
+
+func NewPortName() *PortName {
+	return &PortName{
+		Dest:            "service",
+		DefaultProtocol: "tcp",
+	}
+}
+
+func (pn *PortName) SampleConfig() string {
+	return sampleConfig
+}
+
+func (pn *PortName) Description() string {
+	return "Given a tag or field with a port number, add the service name"
+}
+
+func (pn *PortName) Init() error {
+	if pn.Tag != "" && pn.Field != "" {
+		return errors.New("tag and field cannot both be set")
+	}
+	if pn.Tag == "" && pn.Field == "" {
+		pn.Tag = "port"
+	}
+
+	pn.services = make(map[string]map[int]string)
+	if err := readServices(strings.NewReader(services), pn.services); err != nil {
+		return err
+	}
+	if pn.ServicesFile != "" {
+		file, err := os.Open(pn.ServicesFile)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		if err := readServices(file, pn.services); err != nil {
+			return fmt.Errorf("reading %s: %v", pn.ServicesFile, err)
+		}
+	}
+	return nil
+}
+
+func (pn *PortName) Apply(metrics ...telegraf.Metric) []telegraf.Metric {
+	for _, m := range metrics {
+		value, ok := pn.port(m)
+		if !ok {
+			continue
+		}
+
+		// The protocol may follow the port number, as in "443/tcp".
+		proto := pn.DefaultProtocol
+		if i := strings.IndexByte(value, '/'); i >= 0 {
+			value, proto = value[:i], value[i+1:]
+		}
+		port, err := strconv.Atoi(value)
+		if err != nil {
+			log.Printf("E! [processors.port_name] Invalid port %q", value)
+			continue
+		}
+
+		if v, ok := m.GetTag(pn.ProtocolTag); ok {
+			proto = v
+		}
+		if v, ok := m.GetField(pn.ProtocolField); ok {
+			if s, ok := v.(string); ok {
+				proto = s
+			}
+		}
+
+		service, ok := pn.services[strings.ToLower(proto)][port]
+		if !ok {
+			continue
+		}
+		if pn.Tag != "" {
+			m.AddTag(pn.Dest, service)
+		} else {
+			m.AddField(pn.Dest, service)
+		}
+	}
+	return metrics
+}
+
+// port returns the port of the metric as a string.
+func (pn *PortName) port(m telegraf.Metric) (string, bool) {
+	if pn.Tag != "" {
+		return m.GetTag(pn.Tag)
+	}
+
+	v, ok := m.GetField(pn.Field)
+	if !ok {
+		return "", false
+	}
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case uint64:
+		return strconv.FormatUint(v, 10), true
+	default:
+		log.Printf("E! [processors.port_name] Unexpected type %T for field %s", v, pn.Field)
+		return "", false
+	}
+}
+
+// readServices adds the services of a file in the /etc/services format.
+// Each line holds a service name and a port/protocol, followed by optional
+// aliases, comments start with a #.
+func readServices(r io.Reader, services map[string]map[int]string) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		parts := strings.SplitN(fields[1], "/", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		port, err := strconv.Atoi(parts[0])
+		if err != nil || port < 0 {
+			continue
+		}
+
+		proto := strings.ToLower(parts[1])
+		if _, ok := services[proto]; !ok {
+			services[proto] = make(map[int]string)
+		}
+		services[proto][port] = fields[0]
+	}
+	return scanner.Err()
+}
+
+func init() {
+	processors.Add("port_name", func() telegraf.Processor {
+		return NewPortName()
+	})
+}
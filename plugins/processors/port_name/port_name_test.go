@@ -0,0 +1,196 @@
+package port_name
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+func TestPortName(t *testing.T) {
+	now := time.Unix(0, 0)
+	fields := map[string]interface{}{"result_code": "success"}
+	tests := []testutil.ProcessorTest{
+		{
+			Name: "default protocol",
+			Input: []telegraf.Metric{
+				testutil.MustMetric("net_response",
+					map[string]string{"port": "22"}, fields, now),
+			},
+			Expected: []telegraf.Metric{
+				testutil.MustMetric("net_response",
+					map[string]string{"port": "22", "service": "ssh"}, fields, now),
+			},
+		},
+		{
+			Name: "protocol in port",
+			Input: []telegraf.Metric{
+				testutil.MustMetric("net_response",
+					map[string]string{"port": "514/udp"}, fields, now),
+			},
+			Expected: []telegraf.Metric{
+				testutil.MustMetric("net_response",
+					map[string]string{"port": "514/udp", "service": "syslog"}, fields, now),
+			},
+		},
+		{
+			Name: "protocol tag",
+			Input: []telegraf.Metric{
+				testutil.MustMetric("net_response",
+					map[string]string{"port": "514", "protocol": "UDP"}, fields, now),
+			},
+			Expected: []telegraf.Metric{
+				testutil.MustMetric("net_response",
+					map[string]string{"port": "514", "protocol": "UDP", "service": "syslog"},
+					fields, now),
+			},
+		},
+		{
+			Name: "unknown port",
+			Input: []telegraf.Metric{
+				testutil.MustMetric("net_response",
+					map[string]string{"port": "65000"}, fields, now),
+			},
+			Expected: []telegraf.Metric{
+				testutil.MustMetric("net_response",
+					map[string]string{"port": "65000"}, fields, now),
+			},
+		},
+		{
+			Name: "invalid port",
+			Input: []telegraf.Metric{
+				testutil.MustMetric("net_response",
+					map[string]string{"port": "ssh"}, fields, now),
+			},
+			Expected: []telegraf.Metric{
+				testutil.MustMetric("net_response",
+					map[string]string{"port": "ssh"}, fields, now),
+			},
+		},
+	}
+	testutil.RunProcessorTests(t, func() telegraf.Processor {
+		pn := NewPortName()
+		pn.ProtocolTag = "protocol"
+		return pn
+	}, tests)
+}
+
+func TestPortNameField(t *testing.T) {
+	now := time.Unix(0, 0)
+	tests := []testutil.ProcessorTest{
+		{
+			Name: "integer field",
+			Input: []telegraf.Metric{
+				testutil.MustMetric("flow",
+					map[string]string{},
+					map[string]interface{}{"dst_port": int64(53), "proto": "udp"},
+					now),
+			},
+			Expected: []telegraf.Metric{
+				testutil.MustMetric("flow",
+					map[string]string{},
+					map[string]interface{}{
+						"dst_port":    int64(53),
+						"proto":       "udp",
+						"dst_service": "domain",
+					},
+					now),
+			},
+		},
+		{
+			Name: "string field",
+			Input: []telegraf.Metric{
+				testutil.MustMetric("flow",
+					map[string]string{},
+					map[string]interface{}{"dst_port": "443", "proto": "tcp"},
+					now),
+			},
+			Expected: []telegraf.Metric{
+				testutil.MustMetric("flow",
+					map[string]string{},
+					map[string]interface{}{
+						"dst_port":    "443",
+						"proto":       "tcp",
+						"dst_service": "https",
+					},
+					now),
+			},
+		},
+	}
+	testutil.RunProcessorTests(t, func() telegraf.Processor {
+		pn := NewPortName()
+		pn.Field = "dst_port"
+		pn.Dest = "dst_service"
+		pn.ProtocolField = "proto"
+		return pn
+	}, tests)
+}
+
+func TestServicesFile(t *testing.T) {
+	now := time.Unix(0, 0)
+	fields := map[string]interface{}{"value": 42.0}
+	tests := []testutil.ProcessorTest{
+		{
+			Name: "file service",
+			Input: []telegraf.Metric{
+				testutil.MustMetric("conntrack",
+					map[string]string{"port": "8125/udp"}, fields, now),
+			},
+			Expected: []telegraf.Metric{
+				testutil.MustMetric("conntrack",
+					map[string]string{"port": "8125/udp", "service": "statsd"}, fields, now),
+			},
+		},
+		{
+			Name: "file overrides embedded service",
+			Input: []telegraf.Metric{
+				testutil.MustMetric("conntrack",
+					map[string]string{"port": "8080"}, fields, now),
+			},
+			Expected: []telegraf.Metric{
+				testutil.MustMetric("conntrack",
+					map[string]string{"port": "8080", "service": "http-proxy"}, fields, now),
+			},
+		},
+		{
+			Name: "embedded service",
+			Input: []telegraf.Metric{
+				testutil.MustMetric("conntrack",
+					map[string]string{"port": "80"}, fields, now),
+			},
+			Expected: []telegraf.Metric{
+				testutil.MustMetric("conntrack",
+					map[string]string{"port": "80", "service": "http"}, fields, now),
+			},
+		},
+	}
+	testutil.RunProcessorTests(t, func() telegraf.Processor {
+		pn := NewPortName()
+		pn.ServicesFile = "testdata/services"
+		return pn
+	}, tests)
+
+	testutil.RunProcessorTests(t, func() telegraf.Processor {
+		pn := NewPortName()
+		pn.ServicesFile = "testdata/missing"
+		return pn
+	}, []testutil.ProcessorTest{
+		{
+			Name:      "missing file",
+			InitError: "open testdata/missing: no such file or directory",
+		},
+	})
+
+	testutil.RunProcessorTests(t, func() telegraf.Processor {
+		pn := NewPortName()
+		pn.Tag = "port"
+		pn.Field = "port"
+		return pn
+	}, []testutil.ProcessorTest{
+		{
+			Name:      "tag and field",
+			InitError: "tag and field cannot both be set",
+		},
+	})
+}
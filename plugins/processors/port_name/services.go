@@ -0,0 +1,106 @@
+package port_name
+
+// services is the embedded table of well-known ports, in the format of the
+// /etc/services file.
+const services = `
+echo		7/tcp
+echo		7/udp
+discard		9/tcp
+discard		9/udp
+daytime		13/tcp
+daytime		13/udp
+ftp-data	20/tcp
+ftp		21/tcp
+ssh		22/tcp
+ssh		22/udp
+telnet		23/tcp
+smtp		25/tcp
+time		37/tcp
+time		37/udp
+tacacs		49/tcp
+tacacs		49/udp
+whois		43/tcp
+domain		53/tcp
+domain		53/udp
+bootps		67/udp
+bootpc		68/udp
+tftp		69/udp
+gopher		70/tcp
+finger		79/tcp
+http		80/tcp
+http		80/udp
+kerberos	88/tcp
+kerberos	88/udp
+pop3		110/tcp
+sunrpc		111/tcp
+sunrpc		111/udp
+auth		113/tcp
+nntp		119/tcp
+ntp		123/tcp
+ntp		123/udp
+netbios-ns	137/tcp
+netbios-ns	137/udp
+netbios-dgm	138/udp
+netbios-ssn	139/tcp
+imap		143/tcp
+snmp		161/tcp
+snmp		161/udp
+snmp-trap	162/tcp
+snmp-trap	162/udp
+bgp		179/tcp
+irc		194/tcp
+ldap		389/tcp
+ldap		389/udp
+https		443/tcp
+https		443/udp
+microsoft-ds	445/tcp
+kpasswd		464/tcp
+kpasswd		464/udp
+submissions	465/tcp
+isakmp		500/udp
+shell		514/tcp
+syslog		514/udp
+printer		515/tcp
+dhcpv6-client	546/udp
+dhcpv6-server	547/udp
+rtsp		554/tcp
+rtsp		554/udp
+submission	587/tcp
+ipp		631/tcp
+ldaps		636/tcp
+domain-s	853/tcp
+rsync		873/tcp
+ftps-data	989/tcp
+ftps		990/tcp
+imaps		993/tcp
+pop3s		995/tcp
+socks		1080/tcp
+openvpn		1194/tcp
+openvpn		1194/udp
+ms-sql-s	1433/tcp
+ms-sql-m	1434/udp
+mqtt		1883/tcp
+radius		1812/udp
+radius-acct	1813/udp
+nfs		2049/tcp
+nfs		2049/udp
+mysql		3306/tcp
+ms-wbt-server	3389/tcp
+sip		5060/tcp
+sip		5060/udp
+sip-tls		5061/tcp
+xmpp-client	5222/tcp
+xmpp-server	5269/tcp
+mdns		5353/udp
+postgresql	5432/tcp
+amqp		5672/tcp
+coap		5683/udp
+x11		6000/tcp
+redis		6379/tcp
+http-alt	8080/tcp
+secure-mqtt	8883/tcp
+git		9418/tcp
+memcache	11211/tcp
+memcache	11211/udp
+mongodb		27017/tcp
+`
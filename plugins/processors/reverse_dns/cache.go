@@ -0,0 +1,110 @@
+package reverse_dns
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// lookup is the result of a reverse lookup of an address.  The name and err
+// are set once the lookup is done, and may only be read after done is closed.
+type lookup struct {
+	addr    string
+	name    string
+	err     error
+	expires time.Time
+	done    chan struct{}
+}
+
+func newLookup(addr string) *lookup {
+	return &lookup{addr: addr, done: make(chan struct{})}
+}
+
+// finish records the result of the lookup and marks it as done.
+func (l *lookup) finish(name string, err error, expires time.Time) {
+	l.name = name
+	l.err = err
+	l.expires = expires
+	close(l.done)
+}
+
+// isDone returns true if the lookup is finished.
+func (l *lookup) isDone() bool {
+	select {
+	case <-l.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// lookupCache is a LRU cache of lookups bounded to size entries, finished
+// lookups expire after the ttl.  Lookups in progress are stored as well, so
+// that an address is only looked up once at a time.
+type lookupCache struct {
+	sync.Mutex
+	ttl     time.Duration
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+
+	now func() time.Time
+}
+
+func newLookupCache(ttl time.Duration, size int) *lookupCache {
+	return &lookupCache{
+		ttl:     ttl,
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+		now:     time.Now,
+	}
+}
+
+// get returns the lookup of the address if it is in progress or has not
+// expired.
+func (c *lookupCache) get(addr string) (*lookup, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	e, ok := c.entries[addr]
+	if !ok {
+		return nil, false
+	}
+	l := e.Value.(*lookup)
+	if l.isDone() && !c.now().Before(l.expires) {
+		c.order.Remove(e)
+		delete(c.entries, addr)
+		return nil, false
+	}
+	c.order.MoveToFront(e)
+	return l, true
+}
+
+// add stores the lookup, evicting the least recently used lookups if the
+// cache is full.
+func (c *lookupCache) add(l *lookup) {
+	c.Lock()
+	defer c.Unlock()
+
+	if e, ok := c.entries[l.addr]; ok {
+		c.order.Remove(e)
+	}
+	c.entries[l.addr] = c.order.PushFront(l)
+	for c.order.Len() > c.size {
+		e := c.order.Back()
+		c.order.Remove(e)
+		delete(c.entries, e.Value.(*lookup).addr)
+	}
+}
+
+// expiry returns the expiry time of a lookup finished now.
+func (c *lookupCache) expiry() time.Time {
+	return c.now().Add(c.ttl)
+}
+
+func (c *lookupCache) len() int {
+	c.Lock()
+	defer c.Unlock()
+	return c.order.Len()
+}
@@ -0,0 +1,196 @@
+package reverse_dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+const sampleConfig = `
+  ## How long a lookup is cached, failed lookups are cached as well.
+  # cache_ttl = "24h"
+
+  ## Maximum number of addresses in the cache.
+  # cache_size = 10000
+
+  ## Maximum time a lookup may take, a lookup timing out is cached as
+  ## failed.
+  # lookup_timeout = "3s"
+
+  ## Maximum number of lookups in progress at once, addresses found while
+  ## all lookups are in progress are looked up with a later metric.
+  # max_parallel_lookups = 10
+
+  [[processors.reverse_dns.lookup]]
+    ## Tag containing the IP address to look up.
+    tag = "source_ip"
+    ## Tag to store the name in.
+    dest = "source_name"
+
+  # [[processors.reverse_dns.lookup]]
+  #   ## Field containing the IP address to look up.
+  #   field = "dest_ip"
+  #   ## Field to store the name in.
+  #   dest = "dest_name"
+`
+
+type lookupConfig struct {
+	Tag   string `toml:"tag"`
+	Field string `toml:"field"`
+	Dest  string `toml:"dest"`
+}
+
+// resolver is implemented by net.Resolver.
+type resolver interface {
+	LookupAddr(ctx context.Context, addr string) ([]string, error)
+}
+
+type ReverseDNS struct {
+	Lookups            []lookupConfig    `toml:"lookup"`
+	CacheTTL           internal.Duration `toml:"cache_ttl"`
+	CacheSize          int               `toml:"cache_size"`
+	LookupTimeout      internal.Duration `toml:"lookup_timeout"`
+	MaxParallelLookups int               `toml:"max_parallel_lookups"`
+
+	resolver resolver
+	cache    *lookupCache
+	slots    chan struct{}
+}
+
+func NewReverseDNS() *ReverseDNS {
+	return &ReverseDNS{
+		CacheTTL:           internal.Duration{Duration: 24 * time.Hour},
+		CacheSize:          10000,
+		LookupTimeout:      internal.Duration{Duration: 3 * time.Second},
+		MaxParallelLookups: 10,
+		resolver:           net.DefaultResolver,
+	}
+}
+
+func (r *ReverseDNS) SampleConfig() string {
+	return sampleConfig
+}
+
+func (r *ReverseDNS) Description() string {
+	return "Look up the names of IP addresses in tags and fields"
+}
+
+func (r *ReverseDNS) Init() error {
+	for _, l := range r.Lookups {
+		if (l.Tag == "") == (l.Field == "") {
+			return errors.New("one of tag or field must be set for each lookup")
+		}
+		if l.Dest == "" {
+			return errors.New("dest must be set for each lookup")
+		}
+	}
+	if r.CacheSize < 1 {
+		return fmt.Errorf("invalid cache_size %d", r.CacheSize)
+	}
+	if r.MaxParallelLookups < 1 {
+		return fmt.Errorf("invalid max_parallel_lookups %d", r.MaxParallelLookups)
+	}
+
+	r.cache = newLookupCache(r.CacheTTL.Duration, r.CacheSize)
+	r.slots = make(chan struct{}, r.MaxParallelLookups)
+	return nil
+}
+
+// Apply adds the names of the addresses found in the cache.  The other
+// addresses are looked up in the background without waiting for the result,
+// the lookups are cached so that the following metrics with the same
+// addresses are resolved.
+func (r *ReverseDNS) Apply(metrics ...telegraf.Metric) []telegraf.Metric {
+	for _, m := range metrics {
+		for _, config := range r.Lookups {
+			addr, ok := address(m, config)
+			if !ok {
+				continue
+			}
+			l, ok := r.cache.get(addr)
+			if !ok {
+				r.start(addr)
+				continue
+			}
+			if !l.isDone() || l.err != nil {
+				continue
+			}
+			if config.Tag != "" {
+				m.AddTag(config.Dest, l.name)
+			} else {
+				m.AddField(config.Dest, l.name)
+			}
+		}
+	}
+	return metrics
+}
+
+// start looks up the address in the background if one of the
+// max_parallel_lookups slots is free, otherwise the address is not looked up
+// until it is found in a later metric.
+func (r *ReverseDNS) start(addr string) {
+	select {
+	case r.slots <- struct{}{}:
+	default:
+		return
+	}
+
+	l := newLookup(addr)
+	r.cache.add(l)
+	go func() {
+		defer func() { <-r.slots }()
+
+		ctx, cancel := context.WithTimeout(context.Background(), r.LookupTimeout.Duration)
+		defer cancel()
+
+		var name string
+		names, err := r.resolver.LookupAddr(ctx, addr)
+		if err == nil && len(names) == 0 {
+			err = fmt.Errorf("no names found for %s", addr)
+		}
+		if err == nil {
+			name = strings.TrimSuffix(names[0], ".")
+		}
+		l.finish(name, err, r.cache.expiry())
+	}()
+}
+
+// address returns the IP address to look up for the metric.
+func address(m telegraf.Metric, config lookupConfig) (string, bool) {
+	var addr string
+	if config.Tag != "" {
+		v, ok := m.GetTag(config.Tag)
+		if !ok {
+			return "", false
+		}
+		addr = v
+	} else {
+		v, ok := m.GetField(config.Field)
+		if !ok {
+			return "", false
+		}
+		s, ok := v.(string)
+		if !ok {
+			return "", false
+		}
+		addr = s
+	}
+
+	if net.ParseIP(addr) == nil {
+		return "", false
+	}
+	return addr, true
+}
+
+func init() {
+	processors.Add("reverse_dns", func() telegraf.Processor {
+		return NewReverseDNS()
+	})
+}
@@ -0,0 +1,253 @@
+package reverse_dns
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// stubResolver resolves the addresses in names, lookups are blocked until
+// release is closed if it is set.
+type stubResolver struct {
+	sync.Mutex
+	names   map[string]string
+	release chan struct{}
+	calls   int
+}
+
+func (r *stubResolver) LookupAddr(ctx context.Context, addr string) ([]string, error) {
+	r.Lock()
+	r.calls++
+	r.Unlock()
+
+	if r.release != nil {
+		<-r.release
+	}
+	name, ok := r.names[addr]
+	if !ok {
+		return nil, errors.New("not found")
+	}
+	return []string{name}, nil
+}
+
+func (r *stubResolver) numCalls() int {
+	r.Lock()
+	defer r.Unlock()
+	return r.calls
+}
+
+func newStubResolver() *stubResolver {
+	return &stubResolver{
+		names: map[string]string{
+			"127.0.0.1": "localhost.",
+			"10.0.0.1":  "router.example.org.",
+		},
+	}
+}
+
+func newReverseDNS(t *testing.T, r resolver) *ReverseDNS {
+	p := NewReverseDNS()
+	p.resolver = r
+	p.Lookups = []lookupConfig{
+		{Tag: "source_ip", Dest: "source_name"},
+		{Field: "dest_ip", Dest: "dest_name"},
+	}
+	require.NoError(t, p.Init())
+	return p
+}
+
+// wait waits until the lookups in progress are finished.
+func wait(p *ReverseDNS) {
+	for len(p.slots) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestInitError(t *testing.T) {
+	tests := []struct {
+		name    string
+		lookups []lookupConfig
+		err     string
+	}{
+		{
+			name:    "no tag or field",
+			lookups: []lookupConfig{{Dest: "name"}},
+			err:     "one of tag or field must be set for each lookup",
+		},
+		{
+			name:    "tag and field",
+			lookups: []lookupConfig{{Tag: "ip", Field: "ip", Dest: "name"}},
+			err:     "one of tag or field must be set for each lookup",
+		},
+		{
+			name:    "no dest",
+			lookups: []lookupConfig{{Tag: "ip"}},
+			err:     "dest must be set for each lookup",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewReverseDNS()
+			p.Lookups = tt.lookups
+			require.EqualError(t, p.Init(), tt.err)
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	p := newReverseDNS(t, newStubResolver())
+
+	now := time.Unix(0, 0)
+	input := []telegraf.Metric{
+		testutil.MustMetric("conntrack",
+			map[string]string{"source_ip": "127.0.0.1"},
+			map[string]interface{}{"dest_ip": "10.0.0.1", "bytes": int64(42)},
+			now),
+		testutil.MustMetric("conntrack",
+			map[string]string{"source_ip": "192.168.0.1"},
+			map[string]interface{}{"dest_ip": "not an address", "bytes": int64(42)},
+			now),
+	}
+	expected := []telegraf.Metric{
+		testutil.MustMetric("conntrack",
+			map[string]string{"source_ip": "127.0.0.1", "source_name": "localhost"},
+			map[string]interface{}{
+				"dest_ip":   "10.0.0.1",
+				"dest_name": "router.example.org",
+				"bytes":     int64(42),
+			},
+			now),
+		testutil.MustMetric("conntrack",
+			map[string]string{"source_ip": "192.168.0.1"},
+			map[string]interface{}{"dest_ip": "not an address", "bytes": int64(42)},
+			now),
+	}
+
+	// The first metrics are passed on before the lookups are finished.
+	copies := make([]telegraf.Metric, 0, len(input))
+	for _, m := range input {
+		copies = append(copies, m.Copy())
+	}
+	testutil.RequireMetricsEqual(t, input, p.Apply(copies...))
+
+	wait(p)
+	testutil.RequireMetricsEqual(t, expected, p.Apply(input...))
+}
+
+func TestCache(t *testing.T) {
+	r := newStubResolver()
+	p := newReverseDNS(t, r)
+	now := time.Unix(0, 0)
+	p.cache.now = func() time.Time { return now }
+
+	newMetric := func() telegraf.Metric {
+		return testutil.MustMetric("conntrack",
+			map[string]string{"source_ip": "127.0.0.1"},
+			map[string]interface{}{"dest_ip": "192.168.0.1"},
+			now)
+	}
+
+	// An address is only looked up once while its lookup is in progress.
+	p.Apply(newMetric(), newMetric())
+	wait(p)
+	require.Equal(t, 2, r.numCalls())
+
+	// Failed lookups are cached as well
+	actual := p.Apply(newMetric())
+	require.Equal(t, 2, r.numCalls())
+	require.Equal(t, "localhost", actual[0].Tags()["source_name"])
+
+	now = now.Add(p.CacheTTL.Duration)
+	p.Apply(newMetric())
+	wait(p)
+	require.Equal(t, 4, r.numCalls())
+	actual = p.Apply(newMetric())
+	require.Equal(t, "localhost", actual[0].Tags()["source_name"])
+}
+
+func TestApplyDoesNotWait(t *testing.T) {
+	r := newStubResolver()
+	r.release = make(chan struct{})
+	p := newReverseDNS(t, r)
+
+	newMetric := func() telegraf.Metric {
+		return testutil.MustMetric("conntrack",
+			map[string]string{"source_ip": "127.0.0.1"},
+			map[string]interface{}{"bytes": int64(42)},
+			time.Unix(0, 0))
+	}
+
+	// The lookup is blocked, the metrics are passed on without the name.
+	actual := p.Apply(newMetric())
+	require.Equal(t, map[string]string{"source_ip": "127.0.0.1"}, actual[0].Tags())
+	actual = p.Apply(newMetric())
+	require.Equal(t, map[string]string{"source_ip": "127.0.0.1"}, actual[0].Tags())
+
+	close(r.release)
+	wait(p)
+
+	actual = p.Apply(newMetric())
+	require.Equal(t, "localhost", actual[0].Tags()["source_name"])
+	require.Equal(t, 1, r.numCalls())
+}
+
+func TestMaxParallelLookups(t *testing.T) {
+	r := newStubResolver()
+	r.release = make(chan struct{})
+	p := NewReverseDNS()
+	p.resolver = r
+	p.Lookups = []lookupConfig{{Tag: "source_ip", Dest: "source_name"}}
+	p.MaxParallelLookups = 1
+	require.NoError(t, p.Init())
+
+	newMetric := func(addr string) telegraf.Metric {
+		return testutil.MustMetric("conntrack",
+			map[string]string{"source_ip": addr},
+			map[string]interface{}{"bytes": int64(42)},
+			time.Unix(0, 0))
+	}
+
+	// The second address is not looked up while the first lookup is in
+	// progress.
+	actual := p.Apply(newMetric("127.0.0.1"), newMetric("10.0.0.1"))
+	require.Len(t, actual, 2)
+	require.Equal(t, 1, p.cache.len())
+
+	close(r.release)
+	wait(p)
+
+	p.Apply(newMetric("10.0.0.1"))
+	wait(p)
+	require.Equal(t, 2, p.cache.len())
+	actual = p.Apply(newMetric("127.0.0.1"), newMetric("10.0.0.1"))
+	require.Equal(t, "localhost", actual[0].Tags()["source_name"])
+	require.Equal(t, "router.example.org", actual[1].Tags()["source_name"])
+}
+
+func TestCacheEviction(t *testing.T) {
+	c := newLookupCache(time.Hour, 2)
+	for _, addr := range []string{"10.0.0.1", "10.0.0.2"} {
+		l := newLookup(addr)
+		l.finish("name", nil, c.expiry())
+		c.add(l)
+	}
+
+	// Using 10.0.0.1 makes 10.0.0.2 the least recently used address.
+	_, ok := c.get("10.0.0.1")
+	require.True(t, ok)
+
+	c.add(newLookup("10.0.0.3"))
+	require.Equal(t, 2, c.len())
+	_, ok = c.get("10.0.0.2")
+	require.False(t, ok)
+	_, ok = c.get("10.0.0.1")
+	require.True(t, ok)
+	_, ok = c.get("10.0.0.3")
+	require.True(t, ok)
+}
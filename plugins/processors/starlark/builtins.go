@@ -0,0 +1,210 @@
+package starlark
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/influxdata/telegraf/metric"
+	"go.starlark.net/starlark"
+)
+
+// newMetric implements Metric(name), creating a metric without tags or fields
+// with the current time.
+func newMetric(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var name starlark.String
+	if err := starlark.UnpackPositionalArgs("Metric", args, kwargs, 1, &name); err != nil {
+		return nil, err
+	}
+
+	m, err := metric.New(string(name), nil, nil, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return &Metric{metric: m}, nil
+}
+
+// deepcopy implements deepcopy(metric), returning an independent copy of the
+// metric.
+func deepcopy(thread *starlark.Thread, _ *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var m *Metric
+	if err := starlark.UnpackPositionalArgs("deepcopy", args, kwargs, 1, &m); err != nil {
+		return nil, err
+	}
+	if m.metric == nil {
+		return nil, errDetached
+	}
+	return &Metric{metric: m.metric.Copy()}, nil
+}
+
+// dictMethods are the methods of the tags and fields dicts, modelled after
+// the methods of the builtin dict type.
+var dictMethods = map[string]*starlark.Builtin{
+	"clear":      starlark.NewBuiltin("clear", dictClear),
+	"get":        starlark.NewBuiltin("get", dictGet),
+	"items":      starlark.NewBuiltin("items", dictItems),
+	"keys":       starlark.NewBuiltin("keys", dictKeys),
+	"pop":        starlark.NewBuiltin("pop", dictPop),
+	"setdefault": starlark.NewBuiltin("setdefault", dictSetDefault),
+	"update":     starlark.NewBuiltin("update", dictUpdate),
+	"values":     starlark.NewBuiltin("values", dictValues),
+}
+
+func dictAttr(recv starlark.Value, name string) (starlark.Value, error) {
+	method, ok := dictMethods[name]
+	if !ok {
+		return nil, nil
+	}
+	return method.BindReceiver(recv), nil
+}
+
+func dictAttrNames() []string {
+	names := make([]string, 0, len(dictMethods))
+	for name := range dictMethods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// mutableDict is implemented by the tags and fields dicts.
+type mutableDict interface {
+	starlark.IterableMapping
+	starlark.HasSetKey
+	clear() error
+	delete(k starlark.Value) (starlark.Value, bool, error)
+}
+
+func dictClear(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0); err != nil {
+		return nil, err
+	}
+	return starlark.None, b.Receiver().(mutableDict).clear()
+}
+
+func dictGet(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var key, dflt starlark.Value = nil, starlark.None
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &key, &dflt); err != nil {
+		return nil, err
+	}
+	v, found, err := b.Receiver().(mutableDict).Get(key)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return dflt, nil
+	}
+	return v, nil
+}
+
+func dictItems(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0); err != nil {
+		return nil, err
+	}
+	items := b.Receiver().(mutableDict).Items()
+	list := make([]starlark.Value, 0, len(items))
+	for _, item := range items {
+		list = append(list, item)
+	}
+	return starlark.NewList(list), nil
+}
+
+func dictKeys(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0); err != nil {
+		return nil, err
+	}
+	items := b.Receiver().(mutableDict).Items()
+	list := make([]starlark.Value, 0, len(items))
+	for _, item := range items {
+		list = append(list, item[0])
+	}
+	return starlark.NewList(list), nil
+}
+
+func dictValues(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 0); err != nil {
+		return nil, err
+	}
+	items := b.Receiver().(mutableDict).Items()
+	list := make([]starlark.Value, 0, len(items))
+	for _, item := range items {
+		list = append(list, item[1])
+	}
+	return starlark.NewList(list), nil
+}
+
+func dictPop(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var key, dflt starlark.Value
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &key, &dflt); err != nil {
+		return nil, err
+	}
+	v, found, err := b.Receiver().(mutableDict).delete(key)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		return v, nil
+	}
+	if dflt != nil {
+		return dflt, nil
+	}
+	return nil, fmt.Errorf("%s: missing key %s", b.Name(), key)
+}
+
+func dictSetDefault(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var key, dflt starlark.Value = nil, starlark.None
+	if err := starlark.UnpackPositionalArgs(b.Name(), args, kwargs, 1, &key, &dflt); err != nil {
+		return nil, err
+	}
+	dict := b.Receiver().(mutableDict)
+	v, found, err := dict.Get(key)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		return v, nil
+	}
+	return dflt, dict.SetKey(key, dflt)
+}
+
+// dictUpdate implements update([pairs], **kwargs), where pairs is either a
+// mapping or an iterable of key/value pairs.
+func dictUpdate(_ *starlark.Thread, b *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	if len(args) > 1 {
+		return nil, fmt.Errorf("%s: got %d arguments, want at most 1", b.Name(), len(args))
+	}
+	dict := b.Receiver().(mutableDict)
+
+	if len(args) == 1 {
+		switch updates := args[0].(type) {
+		case starlark.IterableMapping:
+			for _, item := range updates.Items() {
+				if err := dict.SetKey(item[0], item[1]); err != nil {
+					return nil, err
+				}
+			}
+		case starlark.Iterable:
+			iter := updates.Iterate()
+			defer iter.Done()
+			var pair starlark.Value
+			for i := 0; iter.Next(&pair); i++ {
+				seq, ok := pair.(starlark.Indexable)
+				if !ok || seq.Len() != 2 {
+					return nil, fmt.Errorf("%s: element #%d is not a key/value pair", b.Name(), i)
+				}
+				if err := dict.SetKey(seq.Index(0), seq.Index(1)); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			return nil, fmt.Errorf("%s: got %s, want iterable", b.Name(), updates.Type())
+		}
+	}
+
+	for _, kwarg := range kwargs {
+		if err := dict.SetKey(kwarg[0], kwarg[1]); err != nil {
+			return nil, err
+		}
+	}
+	return starlark.None, nil
+}
@@ -0,0 +1,433 @@
+package starlark
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"go.starlark.net/starlark"
+)
+
+// errDetached is returned when the script uses a metric it kept from a
+// previous call to apply.
+var errDetached = errors.New("metric was returned or dropped by a previous call to apply, use deepcopy to keep a metric between calls")
+
+// Metric is a telegraf.Metric exposed to the script.  Its name, tags, fields
+// and time can be read and modified through the attributes of the same name.
+//
+// Once the metric is returned downstream or dropped it is detached, the
+// script can no longer use it in later calls to apply.
+type Metric struct {
+	metric telegraf.Metric
+	frozen bool
+}
+
+// detach releases the metric once it was returned or dropped.
+func (m *Metric) detach() {
+	m.metric = nil
+}
+
+func (m *Metric) String() string {
+	if m.metric == nil {
+		return "Metric(<detached>)"
+	}
+	return fmt.Sprintf("Metric(%q, tags=%s, fields=%s, time=%d)",
+		m.metric.Name(), m.Tags().String(), m.Fields().String(),
+		m.metric.Time().UnixNano())
+}
+
+func (m *Metric) Type() string {
+	return "Metric"
+}
+
+func (m *Metric) Freeze() {
+	m.frozen = true
+}
+
+func (m *Metric) Truth() starlark.Bool {
+	return starlark.True
+}
+
+func (m *Metric) Hash() (uint32, error) {
+	return 0, errors.New("not hashable")
+}
+
+func (m *Metric) AttrNames() []string {
+	return []string{"fields", "name", "tags", "time"}
+}
+
+func (m *Metric) Attr(name string) (starlark.Value, error) {
+	if m.metric == nil {
+		return nil, errDetached
+	}
+
+	switch name {
+	case "name":
+		return starlark.String(m.metric.Name()), nil
+	case "tags":
+		return m.Tags(), nil
+	case "fields":
+		return m.Fields(), nil
+	case "time":
+		return starlark.MakeInt64(m.metric.Time().UnixNano()), nil
+	default:
+		return nil, nil
+	}
+}
+
+func (m *Metric) SetField(name string, value starlark.Value) error {
+	if m.metric == nil {
+		return errDetached
+	}
+	if m.frozen {
+		return errors.New("cannot modify frozen metric")
+	}
+
+	switch name {
+	case "name":
+		str, ok := value.(starlark.String)
+		if !ok {
+			return fmt.Errorf("type error: name must be a string, got %s", value.Type())
+		}
+		m.metric.SetName(string(str))
+		return nil
+	case "time":
+		i, ok := value.(starlark.Int)
+		if !ok {
+			return fmt.Errorf("type error: time must be an int, got %s", value.Type())
+		}
+		ns, ok := i.Int64()
+		if !ok {
+			return errors.New("type error: time out of range")
+		}
+		m.metric.SetTime(time.Unix(0, ns))
+		return nil
+	case "tags", "fields":
+		return fmt.Errorf("cannot set %s, modify them in place", name)
+	default:
+		return starlark.NoSuchAttrError(
+			fmt.Sprintf("cannot assign to field '%s'", name))
+	}
+}
+
+func (m *Metric) Tags() *TagDict {
+	return &TagDict{m}
+}
+
+func (m *Metric) Fields() *FieldDict {
+	return &FieldDict{m}
+}
+
+// TagDict is the dict-like view of the tags of a metric.
+type TagDict struct {
+	m *Metric
+}
+
+func (d *TagDict) String() string {
+	return dictString(d.Items())
+}
+
+func (d *TagDict) Type() string {
+	return "Tags"
+}
+
+func (d *TagDict) Freeze() {
+	d.m.Freeze()
+}
+
+func (d *TagDict) Hash() (uint32, error) {
+	return 0, errors.New("not hashable")
+}
+
+func (d *TagDict) Truth() starlark.Bool {
+	return d.Len() != 0
+}
+
+func (d *TagDict) AttrNames() []string {
+	return dictAttrNames()
+}
+
+func (d *TagDict) Attr(name string) (starlark.Value, error) {
+	return dictAttr(d, name)
+}
+
+func (d *TagDict) Len() int {
+	if d.m.metric == nil {
+		return 0
+	}
+	return len(d.m.metric.TagList())
+}
+
+func (d *TagDict) Get(key starlark.Value) (starlark.Value, bool, error) {
+	if d.m.metric == nil {
+		return nil, false, errDetached
+	}
+	k, ok := key.(starlark.String)
+	if !ok {
+		return nil, false, errors.New("tag key must be of type 'str'")
+	}
+	v, ok := d.m.metric.GetTag(string(k))
+	if !ok {
+		return starlark.None, false, nil
+	}
+	return starlark.String(v), true, nil
+}
+
+func (d *TagDict) SetKey(key, value starlark.Value) error {
+	if d.m.metric == nil {
+		return errDetached
+	}
+	if d.m.frozen {
+		return errors.New("cannot modify frozen metric")
+	}
+	k, ok := key.(starlark.String)
+	if !ok {
+		return errors.New("tag key must be of type 'str'")
+	}
+	v, ok := value.(starlark.String)
+	if !ok {
+		return errors.New("tag value must be of type 'str'")
+	}
+	d.m.metric.AddTag(string(k), string(v))
+	return nil
+}
+
+func (d *TagDict) Items() []starlark.Tuple {
+	if d.m.metric == nil {
+		return nil
+	}
+	items := make([]starlark.Tuple, 0, len(d.m.metric.TagList()))
+	for _, tag := range d.m.metric.TagList() {
+		items = append(items, starlark.Tuple{
+			starlark.String(tag.Key), starlark.String(tag.Value),
+		})
+	}
+	return items
+}
+
+// Iterate iterates over a snapshot of the tag keys, so that the tags may be
+// modified while iterating.
+func (d *TagDict) Iterate() starlark.Iterator {
+	return newKeyIterator(d.Items())
+}
+
+func (d *TagDict) clear() error {
+	if d.m.metric == nil {
+		return errDetached
+	}
+	if d.m.frozen {
+		return errors.New("cannot modify frozen metric")
+	}
+	for _, item := range d.Items() {
+		d.m.metric.RemoveTag(string(item[0].(starlark.String)))
+	}
+	return nil
+}
+
+func (d *TagDict) delete(key starlark.Value) (starlark.Value, bool, error) {
+	if d.m.frozen {
+		return nil, false, errors.New("cannot modify frozen metric")
+	}
+	v, found, err := d.Get(key)
+	if found {
+		d.m.metric.RemoveTag(string(key.(starlark.String)))
+	}
+	return v, found, err
+}
+
+// FieldDict is the dict-like view of the fields of a metric.
+type FieldDict struct {
+	m *Metric
+}
+
+func (d *FieldDict) String() string {
+	return dictString(d.Items())
+}
+
+func (d *FieldDict) Type() string {
+	return "Fields"
+}
+
+func (d *FieldDict) Freeze() {
+	d.m.Freeze()
+}
+
+func (d *FieldDict) Hash() (uint32, error) {
+	return 0, errors.New("not hashable")
+}
+
+func (d *FieldDict) Truth() starlark.Bool {
+	return d.Len() != 0
+}
+
+func (d *FieldDict) AttrNames() []string {
+	return dictAttrNames()
+}
+
+func (d *FieldDict) Attr(name string) (starlark.Value, error) {
+	return dictAttr(d, name)
+}
+
+func (d *FieldDict) Len() int {
+	if d.m.metric == nil {
+		return 0
+	}
+	return len(d.m.metric.FieldList())
+}
+
+func (d *FieldDict) Get(key starlark.Value) (starlark.Value, bool, error) {
+	if d.m.metric == nil {
+		return nil, false, errDetached
+	}
+	k, ok := key.(starlark.String)
+	if !ok {
+		return nil, false, errors.New("field key must be of type 'str'")
+	}
+	v, ok := d.m.metric.GetField(string(k))
+	if !ok {
+		return starlark.None, false, nil
+	}
+	sv, err := asStarlarkValue(v)
+	return sv, err == nil, err
+}
+
+func (d *FieldDict) SetKey(key, value starlark.Value) error {
+	if d.m.metric == nil {
+		return errDetached
+	}
+	if d.m.frozen {
+		return errors.New("cannot modify frozen metric")
+	}
+	k, ok := key.(starlark.String)
+	if !ok {
+		return errors.New("field key must be of type 'str'")
+	}
+	v, err := asGoValue(value)
+	if err != nil {
+		return err
+	}
+	d.m.metric.AddField(string(k), v)
+	return nil
+}
+
+func (d *FieldDict) Items() []starlark.Tuple {
+	if d.m.metric == nil {
+		return nil
+	}
+	items := make([]starlark.Tuple, 0, len(d.m.metric.FieldList()))
+	for _, field := range d.m.metric.FieldList() {
+		v, err := asStarlarkValue(field.Value)
+		if err != nil {
+			continue
+		}
+		items = append(items, starlark.Tuple{starlark.String(field.Key), v})
+	}
+	return items
+}
+
+// Iterate iterates over a snapshot of the field keys, so that the fields may
+// be modified while iterating.
+func (d *FieldDict) Iterate() starlark.Iterator {
+	return newKeyIterator(d.Items())
+}
+
+func (d *FieldDict) clear() error {
+	if d.m.metric == nil {
+		return errDetached
+	}
+	if d.m.frozen {
+		return errors.New("cannot modify frozen metric")
+	}
+	for _, item := range d.Items() {
+		d.m.metric.RemoveField(string(item[0].(starlark.String)))
+	}
+	return nil
+}
+
+func (d *FieldDict) delete(key starlark.Value) (starlark.Value, bool, error) {
+	if d.m.frozen {
+		return nil, false, errors.New("cannot modify frozen metric")
+	}
+	v, found, err := d.Get(key)
+	if found {
+		d.m.metric.RemoveField(string(key.(starlark.String)))
+	}
+	return v, found, err
+}
+
+// asStarlarkValue converts a field value to a Starlark value.
+func asStarlarkValue(value interface{}) (starlark.Value, error) {
+	switch v := value.(type) {
+	case float64:
+		return starlark.Float(v), nil
+	case int64:
+		return starlark.MakeInt64(v), nil
+	case uint64:
+		return starlark.MakeUint64(v), nil
+	case string:
+		return starlark.String(v), nil
+	case bool:
+		return starlark.Bool(v), nil
+	default:
+		return nil, fmt.Errorf("invalid type %T", value)
+	}
+}
+
+// asGoValue converts a Starlark value to a field value.
+func asGoValue(value starlark.Value) (interface{}, error) {
+	switch v := value.(type) {
+	case starlark.Float:
+		return float64(v), nil
+	case starlark.Int:
+		if i, ok := v.Int64(); ok {
+			return i, nil
+		}
+		if u, ok := v.Uint64(); ok {
+			return u, nil
+		}
+		return nil, errors.New("type error: int out of range")
+	case starlark.String:
+		return string(v), nil
+	case starlark.Bool:
+		return bool(v), nil
+	default:
+		return nil, fmt.Errorf("invalid type, field values must be float, int, string or bool, got %s",
+			value.Type())
+	}
+}
+
+func dictString(items []starlark.Tuple) string {
+	var b strings.Builder
+	b.WriteString("{")
+	for i, item := range items {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(item[0].String())
+		b.WriteString(": ")
+		b.WriteString(item[1].String())
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+type keyIterator struct {
+	items []starlark.Tuple
+}
+
+func newKeyIterator(items []starlark.Tuple) *keyIterator {
+	return &keyIterator{items: items}
+}
+
+func (it *keyIterator) Next(p *starlark.Value) bool {
+	if len(it.items) == 0 {
+		return false
+	}
+	*p = it.items[0][0]
+	it.items = it.items[1:]
+	return true
+}
+
+func (it *keyIterator) Done() {}
@@ -0,0 +1,214 @@
+package starlark
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/processors"
+	"go.starlark.net/resolve"
+	"go.starlark.net/starlark"
+)
+
+const description = "Process metrics using a Starlark script"
+
+var sampleConfig = `
+  ## The Starlark source can be set as a string in this configuration file, or
+  ## by referencing a file containing the script.  Only one source or script
+  ## should be set at once.
+  ##
+  ## Source of the Starlark script.
+  source = '''
+def apply(metric):
+	return metric
+'''
+
+  ## File containing a Starlark script.
+  # script = "/usr/local/bin/myscript.star"
+
+  ## Maximum number of execution steps of the script when it is loaded and
+  ## for each metric, scripts exceeding it are stopped.  The default of 0
+  ## does not limit the execution.
+  # max_execution_steps = 0
+`
+
+type Starlark struct {
+	Source            string `toml:"source"`
+	Script            string `toml:"script"`
+	MaxExecutionSteps uint64 `toml:"max_execution_steps"`
+
+	thread    *starlark.Thread
+	applyFunc *starlark.Function
+}
+
+func (s *Starlark) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *Starlark) Description() string {
+	return description
+}
+
+// Init compiles and runs the script once, the script must define a function
+// named apply taking the metric as its only argument.
+func (s *Starlark) Init() error {
+	if s.Source == "" && s.Script == "" {
+		return errors.New("one of source or script must be set")
+	}
+	if s.Source != "" && s.Script != "" {
+		return errors.New("both source and script cannot be set")
+	}
+
+	s.thread = &starlark.Thread{
+		Name: "processors.starlark",
+		Print: func(_ *starlark.Thread, msg string) {
+			log.Printf("I! [processors.starlark] %s", msg)
+		},
+	}
+	s.thread.SetMaxExecutionSteps(s.MaxExecutionSteps)
+
+	// The script is read from the file when the source is nil.
+	var src interface{}
+	filename := s.Script
+	if s.Source != "" {
+		src = s.Source
+		filename = "processor.starlark"
+	}
+
+	// The globals of the script are frozen after it is run, the state dict
+	// remains mutable so that the script can keep state between calls.
+	predeclared := starlark.StringDict{
+		"Metric":   starlark.NewBuiltin("Metric", newMetric),
+		"deepcopy": starlark.NewBuiltin("deepcopy", deepcopy),
+		"state":    starlark.NewDict(0),
+	}
+
+	globals, err := starlark.ExecFile(s.thread, filename, src, predeclared)
+	if err != nil {
+		if err, ok := err.(*starlark.EvalError); ok {
+			return errors.New(err.Backtrace())
+		}
+		return err
+	}
+
+	apply, ok := globals["apply"]
+	if !ok {
+		return errors.New("apply function not defined")
+	}
+	s.applyFunc, ok = apply.(*starlark.Function)
+	if !ok {
+		return errors.New("apply is not a function")
+	}
+	if s.applyFunc.NumParams() != 1 {
+		return errors.New("apply function must take one parameter")
+	}
+	return nil
+}
+
+func (s *Starlark) Apply(metrics ...telegraf.Metric) []telegraf.Metric {
+	results := make([]telegraf.Metric, 0, len(metrics))
+	for _, m := range metrics {
+		in := &Metric{metric: m}
+		rv, err := s.call(in)
+		if err != nil {
+			logError(err)
+			in.detach()
+			m.Drop()
+			continue
+		}
+
+		returned, err := unwrapResult(rv)
+		if err != nil {
+			log.Printf("E! [processors.starlark] %v", err)
+			in.detach()
+			m.Drop()
+			continue
+		}
+
+		// A metric returned more than once is copied so that every result is
+		// independent.  The returned metrics are sent downstream, so they are
+		// detached in case the script kept them in its state.
+		seen := make(map[*Metric]bool, len(returned))
+		for _, r := range returned {
+			if seen[r] {
+				results = append(results, r.metric.Copy())
+				continue
+			}
+			seen[r] = true
+			results = append(results, r.metric)
+		}
+		for r := range seen {
+			r.detach()
+		}
+		if !seen[in] {
+			in.detach()
+			m.Drop()
+		}
+	}
+	return results
+}
+
+// call calls the apply function with the metric.  The execution steps are
+// limited for each call, the thread is reset after a call was stopped.
+func (s *Starlark) call(m *Metric) (starlark.Value, error) {
+	s.thread.Steps = 0
+	s.thread.Uncancel()
+	return starlark.Call(s.thread, s.applyFunc, starlark.Tuple{m}, nil)
+}
+
+// unwrapResult returns the metrics returned by the apply function, which may
+// return None, a metric or a list of metrics.
+func unwrapResult(rv starlark.Value) ([]*Metric, error) {
+	switch rv := rv.(type) {
+	case starlark.NoneType:
+		return nil, nil
+	case *Metric:
+		if rv.metric == nil {
+			return nil, errDetached
+		}
+		return []*Metric{rv}, nil
+	case *starlark.List:
+		metrics := make([]*Metric, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			m, ok := rv.Index(i).(*Metric)
+			if !ok {
+				return nil, fmt.Errorf("invalid type in list returned by apply: %s",
+					rv.Index(i).Type())
+			}
+			if m.metric == nil {
+				return nil, errDetached
+			}
+			metrics = append(metrics, m)
+		}
+		return metrics, nil
+	default:
+		return nil, fmt.Errorf("invalid type returned by apply: %s", rv.Type())
+	}
+}
+
+func logError(err error) {
+	if err, ok := err.(*starlark.EvalError); ok {
+		for _, line := range strings.Split(err.Backtrace(), "\n") {
+			log.Printf("E! [processors.starlark] %s", line)
+		}
+		return
+	}
+	log.Printf("E! [processors.starlark] %v", err)
+}
+
+func init() {
+	// Enable the language features that are optional in the Starlark
+	// specification.
+	resolve.AllowNestedDef = true
+	resolve.AllowLambda = true
+	resolve.AllowFloat = true
+	resolve.AllowSet = true
+	resolve.AllowGlobalReassign = true
+	resolve.AllowRecursion = true
+
+	processors.Add("starlark", func() telegraf.Processor {
+		return &Starlark{}
+	})
+}
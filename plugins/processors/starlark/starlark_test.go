@@ -0,0 +1,375 @@
+package starlark
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newStarlark(source string) func() telegraf.Processor {
+	return func() telegraf.Processor {
+		return &Starlark{Source: source}
+	}
+}
+
+func TestInitError(t *testing.T) {
+	tests := []struct {
+		name   string
+		plugin *Starlark
+		err    string
+	}{
+		{
+			name:   "no source or script",
+			plugin: &Starlark{},
+			err:    "one of source or script must be set",
+		},
+		{
+			name:   "source and script",
+			plugin: &Starlark{Source: "def apply(): pass", Script: "testdata/ratio.star"},
+			err:    "both source and script cannot be set",
+		},
+		{
+			name:   "no apply function",
+			plugin: &Starlark{Source: "x = 1"},
+			err:    "apply function not defined",
+		},
+		{
+			name:   "apply is not a function",
+			plugin: &Starlark{Source: "apply = 1"},
+			err:    "apply is not a function",
+		},
+		{
+			name:   "apply without parameter",
+			plugin: &Starlark{Source: "def apply(): pass"},
+			err:    "apply function must take one parameter",
+		},
+		{
+			name:   "syntax error",
+			plugin: &Starlark{Source: "def apply(metric):\nreturn metric"},
+			err:    "processor.starlark:2:7: got return, want indent",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.EqualError(t, tt.plugin.Init(), tt.err)
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	now := time.Unix(0, 0)
+	tests := []testutil.ProcessorTest{
+		{
+			Name: "pass through",
+			Input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"value": 42.0},
+					now),
+			},
+			Expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "a"},
+					map[string]interface{}{"value": 42.0},
+					now),
+			},
+		},
+	}
+	testutil.RunProcessorTests(t, newStarlark(`
+def apply(metric):
+	return metric
+`), tests)
+}
+
+func TestApplyModify(t *testing.T) {
+	now := time.Unix(0, 0)
+	tests := []testutil.ProcessorTest{
+		{
+			Name: "name tags fields and time",
+			Input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"host": "a", "cpu": "cpu0"},
+					map[string]interface{}{
+						"time_idle": 42.0,
+						"count":     int64(2),
+						"drop":      true,
+					},
+					now),
+			},
+			Expected: []telegraf.Metric{
+				testutil.MustMetric("cpu_usage",
+					map[string]string{"host": "A", "region": "east"},
+					map[string]interface{}{
+						"time_idle": 42.0,
+						"count":     int64(3),
+						"status":    "ok",
+					},
+					time.Unix(0, 10)),
+			},
+		},
+	}
+	testutil.RunProcessorTests(t, newStarlark(`
+def apply(metric):
+	metric.name = metric.name + "_usage"
+	metric.time = metric.time + 10
+	metric.tags["host"] = metric.tags["host"].upper()
+	metric.tags.pop("cpu")
+	metric.tags.update(region="east")
+	metric.fields["count"] += 1
+	metric.fields.setdefault("status", "ok")
+	for key in metric.fields:
+		if type(metric.fields[key]) == "bool":
+			metric.fields.pop(key)
+	return metric
+`), tests)
+}
+
+func TestApplyMaxExecutionSteps(t *testing.T) {
+	now := time.Unix(0, 0)
+	tests := []testutil.ProcessorTest{
+		{
+			Name: "loop is stopped",
+			Input: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"loop": "true"},
+					map[string]interface{}{"value": 42.0},
+					now),
+				testutil.MustMetric("cpu",
+					map[string]string{"loop": "false"},
+					map[string]interface{}{"value": 42.0},
+					now),
+			},
+			Expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"loop": "false"},
+					map[string]interface{}{"value": 42.0},
+					now),
+			},
+		},
+	}
+	testutil.RunProcessorTests(t, func() telegraf.Processor {
+		return &Starlark{
+			Source: `
+def apply(metric):
+	if metric.tags["loop"] == "true":
+		while True:
+			pass
+	return metric
+`,
+			MaxExecutionSteps: 1000,
+		}
+	}, tests)
+}
+
+func TestInitMaxExecutionSteps(t *testing.T) {
+	plugin := &Starlark{
+		Source: `
+def loop():
+	while True:
+		pass
+loop()
+
+def apply(metric):
+	return metric
+`,
+		MaxExecutionSteps: 1000,
+	}
+	err := plugin.Init()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "too many steps")
+}
+
+func TestApplyReturnValues(t *testing.T) {
+	now := time.Unix(0, 0)
+	input := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{},
+			map[string]interface{}{"value": int64(42)},
+			now),
+	}
+
+	testutil.RunProcessorTests(t, newStarlark(`
+def apply(metric):
+	return None
+`), []testutil.ProcessorTest{{Name: "none", Input: input}})
+
+	testutil.RunProcessorTests(t, newStarlark(`
+def apply(metric):
+	return 42
+`), []testutil.ProcessorTest{{Name: "invalid type", Input: input}})
+
+	testutil.RunProcessorTests(t, newStarlark(`
+def apply(metric):
+	return metric.fields["missing"]
+`), []testutil.ProcessorTest{{Name: "runtime error", Input: input}})
+
+	testutil.RunProcessorTests(t, newStarlark(`
+def apply(metric):
+	m = Metric("count")
+	m.fields["value"] = metric.fields["value"]
+	m.time = metric.time
+	c = deepcopy(metric)
+	c.name = "copy"
+	return [metric, m, c, metric]
+`), []testutil.ProcessorTest{
+		{
+			Name:  "list",
+			Input: input,
+			Expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"value": int64(42)},
+					now),
+				testutil.MustMetric("count",
+					map[string]string{},
+					map[string]interface{}{"value": int64(42)},
+					now),
+				testutil.MustMetric("copy",
+					map[string]string{},
+					map[string]interface{}{"value": int64(42)},
+					now),
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"value": int64(42)},
+					now),
+			},
+		},
+	})
+}
+
+func TestApplyState(t *testing.T) {
+	tests := []testutil.ProcessorTest{
+		{
+			Name: "delta",
+			Input: []telegraf.Metric{
+				testutil.MustMetric("net",
+					map[string]string{},
+					map[string]interface{}{"bytes": int64(100)},
+					time.Unix(10, 0)),
+				testutil.MustMetric("net",
+					map[string]string{},
+					map[string]interface{}{"bytes": int64(150)},
+					time.Unix(20, 0)),
+			},
+			Expected: []telegraf.Metric{
+				testutil.MustMetric("net",
+					map[string]string{},
+					map[string]interface{}{"bytes": int64(150), "delta": int64(50)},
+					time.Unix(20, 0)),
+			},
+		},
+	}
+	testutil.RunProcessorTests(t, newStarlark(`
+def apply(metric):
+	last = state.get("last")
+	state["last"] = metric.fields["bytes"]
+	if last == None:
+		return None
+	metric.fields["delta"] = metric.fields["bytes"] - last
+	return metric
+`), tests)
+}
+
+func TestApplyStateMetric(t *testing.T) {
+	input := []telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{},
+			map[string]interface{}{"value": int64(1)},
+			time.Unix(10, 0)),
+		testutil.MustMetric("cpu",
+			map[string]string{},
+			map[string]interface{}{"value": int64(2)},
+			time.Unix(20, 0)),
+	}
+	tests := []struct {
+		name     string
+		source   string
+		expected []telegraf.Metric
+	}{
+		{
+			// The metric kept in the state was sent downstream by the first
+			// call and cannot be returned again, the second call fails.
+			name: "kept metric",
+			source: `
+def apply(metric):
+	last = state.get("last")
+	state["last"] = metric
+	if last == None:
+		return metric
+	last.fields["value"] = 0
+	return [metric, last]
+`,
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"value": int64(1)},
+					time.Unix(10, 0)),
+			},
+		},
+		{
+			name: "kept copy",
+			source: `
+def apply(metric):
+	last = state.get("last")
+	state["last"] = deepcopy(metric)
+	if last == None:
+		return metric
+	last.fields["value"] = 0
+	return [metric, last]
+`,
+			expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"value": int64(1)},
+					time.Unix(10, 0)),
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"value": int64(2)},
+					time.Unix(20, 0)),
+				testutil.MustMetric("cpu",
+					map[string]string{},
+					map[string]interface{}{"value": int64(0)},
+					time.Unix(10, 0)),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plugin := &Starlark{Source: tt.source}
+			require.NoError(t, plugin.Init())
+
+			var actual []telegraf.Metric
+			for _, m := range input {
+				actual = append(actual, plugin.Apply(m.Copy())...)
+			}
+			testutil.RequireMetricsEqual(t, tt.expected, actual)
+		})
+	}
+}
+
+func TestScript(t *testing.T) {
+	now := time.Unix(0, 0)
+	tests := []testutil.ProcessorTest{
+		{
+			Name: "ratio",
+			Input: []telegraf.Metric{
+				testutil.MustMetric("mem",
+					map[string]string{},
+					map[string]interface{}{"used": int64(25), "total": int64(100)},
+					now),
+			},
+			Expected: []telegraf.Metric{
+				testutil.MustMetric("mem",
+					map[string]string{},
+					map[string]interface{}{"used": int64(25), "total": int64(100), "ratio": 0.25},
+					now),
+			},
+		},
+	}
+	testutil.RunProcessorTests(t, func() telegraf.Processor {
+		return &Starlark{Script: "testdata/ratio.star"}
+	}, tests)
+}
@@ -0,0 +1,156 @@
+package tag_limit
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/processors"
+)
+
+const sampleConfig = `
+  ## Maximum number of tags to preserve, 0 disables the limit
+  limit = 10
+
+  ## List of tags to preferentially preserve
+  keep = ["host", "region"]
+
+  ## Maximum number of distinct values of each tag key per value_window, the
+  ## values over the limit are replaced by the overflow_value.  0 disables
+  ## the limit.
+  # value_limit = 0
+
+  ## Window over which the distinct values are counted
+  # value_window = "1h"
+
+  ## Value replacing the tag values over the value_limit
+  # overflow_value = "other"
+
+  ## Tag keys whose values are limited, all tag keys if empty
+  # value_tags = []
+`
+
+type TagLimit struct {
+	Limit         int               `toml:"limit"`
+	Keep          []string          `toml:"keep"`
+	ValueLimit    int               `toml:"value_limit"`
+	ValueWindow   internal.Duration `toml:"value_window"`
+	OverflowValue string            `toml:"overflow_value"`
+	ValueTags     []string          `toml:"value_tags"`
+
+	keep        map[string]bool
+	valueTags   map[string]bool
+	values      map[string]map[string]bool
+	windowStart time.Time
+
+	now func() time.Time
+}
+
+func NewTagLimit() *TagLimit {
+	return &TagLimit{
+		ValueWindow:   internal.Duration{Duration: time.Hour},
+		OverflowValue: "other",
+		now:           time.Now,
+	}
+}
+
+func (d *TagLimit) SampleConfig() string {
+	return sampleConfig
+}
+
+func (d *TagLimit) Description() string {
+	return "Restricts the number of tags and tag values of metrics"
+}
+
+func (d *TagLimit) Init() error {
+	if d.Limit < 0 {
+		return fmt.Errorf("invalid limit %d", d.Limit)
+	}
+	if d.ValueLimit < 0 {
+		return fmt.Errorf("invalid value_limit %d", d.ValueLimit)
+	}
+
+	d.keep = make(map[string]bool, len(d.Keep))
+	for _, key := range d.Keep {
+		d.keep[key] = true
+	}
+	d.valueTags = make(map[string]bool, len(d.ValueTags))
+	for _, key := range d.ValueTags {
+		d.valueTags[key] = true
+	}
+	d.values = make(map[string]map[string]bool)
+	return nil
+}
+
+func (d *TagLimit) Apply(in ...telegraf.Metric) []telegraf.Metric {
+	for _, metric := range in {
+		if d.Limit > 0 {
+			d.limitTags(metric)
+		}
+		if d.ValueLimit > 0 {
+			d.limitValues(metric)
+		}
+	}
+	return in
+}
+
+// limitTags removes the tags over the limit, starting from the end of the
+// sorted tag list and skipping the tags to keep.  If there are more tags to
+// keep than the limit, all of them are kept.
+func (d *TagLimit) limitTags(metric telegraf.Metric) {
+	tags := metric.TagList()
+	if len(tags) <= d.Limit {
+		return
+	}
+
+	remove := make([]string, 0, len(tags)-d.Limit)
+	for i := len(tags) - 1; i >= 0 && len(remove) < len(tags)-d.Limit; i-- {
+		if !d.keep[tags[i].Key] {
+			remove = append(remove, tags[i].Key)
+		}
+	}
+	for _, key := range remove {
+		metric.RemoveTag(key)
+	}
+}
+
+// limitValues replaces the values of the tags that are not among the first
+// value_limit distinct values seen for the tag key in the current window.
+func (d *TagLimit) limitValues(metric telegraf.Metric) {
+	now := d.now()
+	if now.Sub(d.windowStart) >= d.ValueWindow.Duration {
+		d.values = make(map[string]map[string]bool)
+		d.windowStart = now
+	}
+
+	var overflow []string
+	for _, tag := range metric.TagList() {
+		if len(d.valueTags) > 0 && !d.valueTags[tag.Key] {
+			continue
+		}
+
+		values, ok := d.values[tag.Key]
+		if !ok {
+			values = make(map[string]bool)
+			d.values[tag.Key] = values
+		}
+		if values[tag.Value] {
+			continue
+		}
+		if len(values) >= d.ValueLimit {
+			overflow = append(overflow, tag.Key)
+			continue
+		}
+		values[tag.Value] = true
+	}
+	for _, key := range overflow {
+		metric.AddTag(key, d.OverflowValue)
+	}
+}
+
+func init() {
+	processors.Add("tag_limit", func() telegraf.Processor {
+		return NewTagLimit()
+	})
+}
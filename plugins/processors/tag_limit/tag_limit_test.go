@@ -0,0 +1,121 @@
+package tag_limit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newTagLimit(limit int, keep ...string) func() telegraf.Processor {
+	return func() telegraf.Processor {
+		d := NewTagLimit()
+		d.Limit = limit
+		d.Keep = keep
+		return d
+	}
+}
+
+func TestLimit(t *testing.T) {
+	now := time.Unix(0, 0)
+	tags := map[string]string{"a": "1", "b": "2", "c": "3", "d": "4"}
+	fields := map[string]interface{}{"value": 42.0}
+
+	testutil.RunProcessorTests(t, newTagLimit(2), []testutil.ProcessorTest{
+		{
+			Name:  "over limit",
+			Input: []telegraf.Metric{testutil.MustMetric("cpu", tags, fields, now)},
+			Expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"a": "1", "b": "2"}, fields, now),
+			},
+		},
+		{
+			Name: "under limit",
+			Input: []telegraf.Metric{
+				testutil.MustMetric("cpu", map[string]string{"d": "4"}, fields, now),
+			},
+			Expected: []telegraf.Metric{
+				testutil.MustMetric("cpu", map[string]string{"d": "4"}, fields, now),
+			},
+		},
+	})
+
+	testutil.RunProcessorTests(t, newTagLimit(2, "c", "d"), []testutil.ProcessorTest{
+		{
+			Name:  "keep",
+			Input: []telegraf.Metric{testutil.MustMetric("cpu", tags, fields, now)},
+			Expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"c": "3", "d": "4"}, fields, now),
+			},
+		},
+	})
+
+	testutil.RunProcessorTests(t, newTagLimit(1, "b", "c"), []testutil.ProcessorTest{
+		{
+			Name:  "keep over limit",
+			Input: []telegraf.Metric{testutil.MustMetric("cpu", tags, fields, now)},
+			Expected: []telegraf.Metric{
+				testutil.MustMetric("cpu",
+					map[string]string{"b": "2", "c": "3"}, fields, now),
+			},
+		},
+	})
+
+	testutil.RunProcessorTests(t, newTagLimit(0), []testutil.ProcessorTest{
+		{
+			Name:     "disabled",
+			Input:    []telegraf.Metric{testutil.MustMetric("cpu", tags, fields, now)},
+			Expected: []telegraf.Metric{testutil.MustMetric("cpu", tags, fields, now)},
+		},
+	})
+}
+
+func TestValueLimit(t *testing.T) {
+	now := time.Unix(0, 0)
+	fields := map[string]interface{}{"value": 42.0}
+	newMetric := func(host, path string) telegraf.Metric {
+		return testutil.MustMetric("http",
+			map[string]string{"host": host, "path": path}, fields, now)
+	}
+
+	d := NewTagLimit()
+	d.ValueLimit = 2
+	d.ValueTags = []string{"path"}
+	clock := now
+	d.now = func() time.Time { return clock }
+	require.NoError(t, d.Init())
+
+	actual := testutil.ApplyProcessor(d,
+		newMetric("a", "/"),
+		newMetric("b", "/login"),
+		newMetric("c", "/user/1"),
+		newMetric("d", "/"),
+	)
+	expected := []telegraf.Metric{
+		newMetric("a", "/"),
+		newMetric("b", "/login"),
+		newMetric("c", "other"),
+		newMetric("d", "/"),
+	}
+	testutil.RequireMetricsEqual(t, expected, actual)
+
+	// The values are forgotten once the window is over.
+	clock = clock.Add(d.ValueWindow.Duration)
+	actual = testutil.ApplyProcessor(d, newMetric("c", "/user/1"))
+	expected = []telegraf.Metric{newMetric("c", "/user/1")}
+	testutil.RequireMetricsEqual(t, expected, actual)
+}
+
+func TestInitError(t *testing.T) {
+	d := NewTagLimit()
+	d.Limit = -1
+	require.EqualError(t, d.Init(), "invalid limit -1")
+
+	d = NewTagLimit()
+	d.ValueLimit = -1
+	require.EqualError(t, d.Init(), "invalid value_limit -1")
+}
@@ -10,3 +10,20 @@ type Processor interface {
 	// Apply the filter to the given metric.
 	Apply(in ...Metric) []Metric
 }
+
+// StreamingProcessor is a processor that emits metrics independently of the
+// calls to Apply, for example the output of a program the metrics are passed
+// to.
+type StreamingProcessor interface {
+	Processor
+
+	// Start the StreamingProcessor.  The metrics added to the Accumulator are
+	// passed on to the following processors, it may be retained and used
+	// until Stop returns.
+	Start(Accumulator) error
+
+	// Stop the StreamingProcessor.  Apply is not called afterwards, the
+	// metrics still held by the processor must be added to the Accumulator
+	// before Stop returns.
+	Stop()
+}
@@ -0,0 +1,64 @@
+package testutil
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/telegraf"
+	"github.com/stretchr/testify/require"
+)
+
+// ProcessorTest is a test case for a processor.
+type ProcessorTest struct {
+	Name string
+
+	// Input are the metrics passed to the processor, one at a time and in
+	// order, so that processors keeping state between calls can be tested.
+	Input []telegraf.Metric
+
+	// Expected are all metrics returned by the processor.
+	Expected []telegraf.Metric
+
+	// InitError is the expected error of the Init function, if set the
+	// metrics are not processed and the processor must implement
+	// telegraf.Initializer.
+	InitError string
+}
+
+// RunProcessorTests runs each test on a new processor created by
+// newProcessor, calling its Init function first if it implements
+// telegraf.Initializer.
+func RunProcessorTests(
+	t *testing.T,
+	newProcessor func() telegraf.Processor,
+	tests []ProcessorTest,
+	opts ...cmp.Option,
+) {
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			processor := newProcessor()
+			p, ok := processor.(telegraf.Initializer)
+			if tt.InitError != "" {
+				require.True(t, ok, "processor has no Init function")
+				require.EqualError(t, p.Init(), tt.InitError)
+				return
+			}
+			if ok {
+				require.NoError(t, p.Init())
+			}
+
+			actual := ApplyProcessor(processor, tt.Input...)
+			RequireMetricsEqual(t, tt.Expected, actual, opts...)
+		})
+	}
+}
+
+// ApplyProcessor passes the metrics to the processor one at a time and
+// returns all metrics returned by the processor.
+func ApplyProcessor(processor telegraf.Processor, metrics ...telegraf.Metric) []telegraf.Metric {
+	var results []telegraf.Metric
+	for _, m := range metrics {
+		results = append(results, processor.Apply(m)...)
+	}
+	return results
+}